@@ -1,9 +1,12 @@
 package coinbasepro
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"time"
 )
 
 type Account struct {
@@ -16,22 +19,138 @@ type Account struct {
 
 // Ledger
 
+// LedgerEntryType is the kind of balance change of a ledger entry, the details of an entry depend on it.
+type LedgerEntryType string
+
+const (
+	LedgerEntryTypeTransfer   LedgerEntryType = "transfer"
+	LedgerEntryTypeMatch      LedgerEntryType = "match"
+	LedgerEntryTypeFee        LedgerEntryType = "fee"
+	LedgerEntryTypeRebate     LedgerEntryType = "rebate"
+	LedgerEntryTypeConversion LedgerEntryType = "conversion"
+)
+
 type LedgerEntry struct {
-	ID        string        `json:"id,number"`
-	CreatedAt Time          `json:"created_at,string"`
-	Amount    string        `json:"amount"`
-	Balance   string        `json:"balance"`
-	Type      string        `json:"type"`
-	Details   LedgerDetails `json:"details"`
+	ID        string          `json:"id"`
+	CreatedAt Time            `json:"created_at,string"`
+	Amount    Decimal         `json:"amount"`
+	Balance   Decimal         `json:"balance"`
+	Type      LedgerEntryType `json:"type"`
+	Details   LedgerDetails   `json:"details"`
 }
 
+// LedgerDetails holds the details of every entry type, use the typed accessors of LedgerEntry to read the
+// details of a type.
 type LedgerDetails struct {
 	OrderID   string `json:"order_id"`
 	TradeID   string `json:"trade_id"`
 	ProductID string `json:"product_id"`
+	// TransferID can be passed to GetTransfer, TransferType is the type of the transfer.
+	TransferID   string `json:"transfer_id"`
+	TransferType string `json:"transfer_type"`
+	// From and To are the profiles of a transfer between profiles.
+	From              string `json:"from"`
+	To                string `json:"to"`
+	ProfileTransferID string `json:"profile_transfer_id"`
+	// ConversionID is set for conversions, it can be passed to GetConversion.
+	ConversionID string `json:"conversion_id"`
+}
+
+// LedgerTradeDetails are the details of match, fee and rebate entries.
+type LedgerTradeDetails struct {
+	OrderID   string
+	TradeID   string
+	ProductID string
+}
+
+// LedgerTransferDetails are the details of transfer entries, From and To are only set for transfers between
+// profiles.
+type LedgerTransferDetails struct {
+	TransferID        string
+	TransferType      string
+	From              string
+	To                string
+	ProfileTransferID string
+}
+
+// LedgerConversionDetails are the details of conversion entries.
+type LedgerConversionDetails struct {
+	ConversionID string
+}
+
+// UnmarshalJSON accepts the id of an entry as a string as well as the number sent by older versions of the API.
+func (e *LedgerEntry) UnmarshalJSON(data []byte) error {
+	type ledgerEntry LedgerEntry
+	var v struct {
+		ledgerEntry
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*e = LedgerEntry(v.ledgerEntry)
+
+	id := bytes.TrimSpace(v.ID)
+	switch {
+	case len(id) == 0 || bytes.Equal(id, []byte("null")):
+		e.ID = ""
+	case id[0] == '"':
+		return json.Unmarshal(id, &e.ID)
+	default:
+		var n json.Number
+		if err := json.Unmarshal(id, &n); err != nil {
+			return fmt.Errorf("invalid ledger entry id %s", id)
+		}
+		e.ID = n.String()
+	}
+
+	return nil
+}
+
+// IsConversion reports whether the entry is one side of a conversion.
+func (e LedgerEntry) IsConversion() bool {
+	return e.Type == LedgerEntryTypeConversion
+}
+
+// TradeDetails returns the details of a match, fee or rebate entry, ok is false for other types.
+func (e LedgerEntry) TradeDetails() (details LedgerTradeDetails, ok bool) {
+	switch e.Type {
+	case LedgerEntryTypeMatch, LedgerEntryTypeFee, LedgerEntryTypeRebate:
+		return LedgerTradeDetails{OrderID: e.Details.OrderID, TradeID: e.Details.TradeID, ProductID: e.Details.ProductID}, true
+	}
+
+	return LedgerTradeDetails{}, false
+}
+
+// TransferDetails returns the details of a transfer entry, ok is false for other types.
+func (e LedgerEntry) TransferDetails() (details LedgerTransferDetails, ok bool) {
+	if e.Type != LedgerEntryTypeTransfer {
+		return LedgerTransferDetails{}, false
+	}
+
+	return LedgerTransferDetails{
+		TransferID:        e.Details.TransferID,
+		TransferType:      e.Details.TransferType,
+		From:              e.Details.From,
+		To:                e.Details.To,
+		ProfileTransferID: e.Details.ProfileTransferID,
+	}, true
+}
+
+// ConversionDetails returns the details of a conversion entry, ok is false for other types.
+func (e LedgerEntry) ConversionDetails() (details LedgerConversionDetails, ok bool) {
+	if e.Type != LedgerEntryTypeConversion {
+		return LedgerConversionDetails{}, false
+	}
+
+	return LedgerConversionDetails{ConversionID: e.Details.ConversionID}, true
 }
 
 type GetAccountLedgerParams struct {
+	// StartDate and EndDate limit the entries to the ones created in between, when set.
+	StartDate  time.Time
+	EndDate    time.Time
 	Pagination PaginationParams
 }
 
@@ -69,6 +188,12 @@ func (c *client) ListAccountLedger(id string, p ...GetAccountLedgerParams) *Curs
 	paginationParams := PaginationParams{}
 	if len(p) > 0 {
 		paginationParams = p[0].Pagination
+		if !p[0].StartDate.IsZero() {
+			paginationParams.AddExtraParam("start_date", p[0].StartDate.UTC().Format(time.RFC3339Nano))
+		}
+		if !p[0].EndDate.IsZero() {
+			paginationParams.AddExtraParam("end_date", p[0].EndDate.UTC().Format(time.RFC3339Nano))
+		}
 	}
 
 	return c.newCursor(http.MethodGet, fmt.Sprintf("/accounts/%s/ledger", id), paginationParams)
@@ -2,7 +2,10 @@ package coinbasepro_test
 
 import (
 	"context"
+	"encoding/json"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/moonr-app/go-coinbasepro"
 )
@@ -100,3 +103,120 @@ func TestListHolds(t *testing.T) {
 		}
 	}
 }
+
+func TestLedgerEntryDetails(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var defaultProfile coinbasepro.Profile
+	for _, p := range profiles {
+		if p.IsDefault {
+			defaultProfile = p
+		}
+	}
+
+	profile, err := client.CreateProfile(ctx, "ledger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateProfileTransfer(ctx, coinbasepro.ProfileTransfer{From: defaultProfile.ID, To: profile.ID, Currency: "USD", Amount: "10.00"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var usd coinbasepro.Account
+	for _, a := range accounts {
+		if a.Currency == "USD" {
+			usd = a
+		}
+	}
+
+	var entries []coinbasepro.LedgerEntry
+	if err := client.ListAccountLedger(usd.ID).NextPage(ctx, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	types := make(map[coinbasepro.LedgerEntryType]bool)
+	for _, e := range entries {
+		types[e.Type] = true
+
+		switch e.Type {
+		case coinbasepro.LedgerEntryTypeMatch, coinbasepro.LedgerEntryTypeFee:
+			details, ok := e.TradeDetails()
+			if !ok || details.OrderID == "" || details.ProductID != "BTC-USD" {
+				t.Errorf("unexpected trade details %+v", details)
+			}
+			if _, ok := e.TransferDetails(); ok {
+				t.Error("expected no transfer details on a trade entry")
+			}
+		case coinbasepro.LedgerEntryTypeTransfer:
+			details, ok := e.TransferDetails()
+			if !ok || details.TransferID == "" {
+				t.Errorf("unexpected transfer details %+v", details)
+			}
+			if details.TransferType == string(coinbasepro.TransferTypeInternalWithdraw) && (details.From != defaultProfile.ID || details.To != profile.ID || details.ProfileTransferID == "") {
+				t.Errorf("unexpected profile transfer details %+v", details)
+			}
+		}
+	}
+	if !types[coinbasepro.LedgerEntryTypeMatch] || !types[coinbasepro.LedgerEntryTypeFee] || !types[coinbasepro.LedgerEntryTypeTransfer] {
+		t.Errorf("expected match, fee and transfer entries, got %v", types)
+	}
+
+	var later []coinbasepro.LedgerEntry
+	params := coinbasepro.GetAccountLedgerParams{StartDate: time.Now().Add(time.Minute)}
+	if err := client.ListAccountLedger(usd.ID, params).NextPage(ctx, &later); err != nil {
+		t.Fatal(err)
+	}
+	if len(later) != 0 {
+		t.Errorf("expected no entries after the start date, got %+v", later)
+	}
+
+	var earlier []coinbasepro.LedgerEntry
+	params = coinbasepro.GetAccountLedgerParams{EndDate: time.Now().Add(time.Minute)}
+	if err := client.ListAccountLedger(usd.ID, params).NextPage(ctx, &earlier); err != nil {
+		t.Fatal(err)
+	}
+	if len(earlier) != len(entries) {
+		t.Errorf("expected %d entries before the end date, got %d", len(entries), len(earlier))
+	}
+}
+
+func TestLedgerEntryUnmarshalJSON(t *testing.T) {
+	data := []byte(`[
+		{"id":"1001","amount":"0.0010000000000000","balance":"239.6698710000000000","type":"match"},
+		{"id":1002,"amount":-12.5,"balance":"227.169871","type":"fee"}
+	]`)
+
+	var entries []coinbasepro.LedgerEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if entries[0].ID != "1001" || entries[1].ID != "1002" {
+		t.Errorf("unexpected ids %q and %q", entries[0].ID, entries[1].ID)
+	}
+	if entries[1].Type != coinbasepro.LedgerEntryTypeFee {
+		t.Errorf("unexpected type %q", entries[1].Type)
+	}
+
+	amount, ok := entries[1].Amount.Rat()
+	if !ok || amount.Cmp(big.NewRat(-25, 2)) != 0 {
+		t.Errorf("unexpected amount %q", entries[1].Amount)
+	}
+	if balance, _ := entries[0].Balance.Rat(); balance.FloatString(6) != "239.669871" {
+		t.Errorf("unexpected balance %q", entries[0].Balance)
+	}
+}
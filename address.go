@@ -0,0 +1,332 @@
+package coinbasepro
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strings"
+)
+
+const (
+	bitcoinAlphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+	rippleAlphabet  = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+	bech32Charset   = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+	bech32Constant  = 1
+	bech32mConstant = 0x2bc830a3
+)
+
+// addressFormat validates and normalizes the addresses of a network.
+type addressFormat struct {
+	base58Alphabet string
+	base58Versions []byte
+	bech32Prefixes []string
+	ethereum       bool
+}
+
+var addressFormats = map[string]addressFormat{
+	"bitcoin": {
+		base58Alphabet: bitcoinAlphabet,
+		base58Versions: []byte{0x00, 0x05, 0x6f, 0xc4},
+		bech32Prefixes: []string{"bc", "tb"},
+	},
+	"litecoin": {
+		base58Alphabet: bitcoinAlphabet,
+		base58Versions: []byte{0x30, 0x32, 0x05, 0x6f, 0x3a},
+		bech32Prefixes: []string{"ltc", "tltc"},
+	},
+	"ethereum":         {ethereum: true},
+	"ethereum_classic": {ethereum: true},
+	"ripple": {
+		base58Alphabet: rippleAlphabet,
+		base58Versions: []byte{0x00},
+	},
+}
+
+// currencyNetworks maps currencies to the network their addresses are validated for when no network is given.
+var currencyNetworks = map[string]string{
+	"BTC": "bitcoin",
+	"LTC": "litecoin",
+	"ETH": "ethereum",
+	"ETC": "ethereum_classic",
+	"XRP": "ripple",
+}
+
+// addressNetwork returns network, or the main network of currency when network is empty.
+func addressNetwork(currency, network string) string {
+	if network == "" {
+		return currencyNetworks[strings.ToUpper(currency)]
+	}
+
+	return network
+}
+
+// ValidateAddress checks the format and checksum of a crypto address. Addresses are validated for network, or for
+// the main network of currency when network is empty. Base58Check and bech32/bech32m addresses are supported for
+// bitcoin and litecoin, EIP-55 checksummed or single case hex addresses for ethereum and classic addresses for
+// ripple. Addresses of other networks are only checked to be non-empty and without whitespace.
+func ValidateAddress(currency, network, address string) error {
+	_, err := normalizeAddress(currency, network, address)
+	return err
+}
+
+// normalizeAddress validates address and returns the form it is compared in, case-insensitive formats are lower
+// cased.
+func normalizeAddress(currency, network, address string) (string, error) {
+	if address == "" {
+		return "", errors.New("address cannot be empty")
+	}
+	if strings.ContainsAny(address, " \t\r\n") {
+		return "", fmt.Errorf("address %q contains whitespace", address)
+	}
+
+	format, ok := addressFormats[strings.ToLower(addressNetwork(currency, network))]
+	if !ok {
+		return address, nil
+	}
+
+	if format.ethereum {
+		if err := validateEthereumAddress(address); err != nil {
+			return "", err
+		}
+		return strings.ToLower(address), nil
+	}
+
+	for _, prefix := range format.bech32Prefixes {
+		if strings.HasPrefix(strings.ToLower(address), prefix+"1") {
+			if err := validateSegwitAddress(prefix, address); err != nil {
+				return "", err
+			}
+			return strings.ToLower(address), nil
+		}
+	}
+
+	if err := validateBase58Address(format.base58Alphabet, format.base58Versions, address); err != nil {
+		return "", err
+	}
+
+	return address, nil
+}
+
+func validateBase58Address(alphabet string, versions []byte, address string) error {
+	n := new(big.Int)
+	for _, r := range address {
+		i := strings.IndexRune(alphabet, r)
+		if i < 0 {
+			return fmt.Errorf("address %q contains invalid character %q", address, r)
+		}
+		n.Mul(n, big.NewInt(58))
+		n.Add(n, big.NewInt(int64(i)))
+	}
+
+	leading := len(address) - len(strings.TrimLeft(address, alphabet[:1]))
+	decoded := append(make([]byte, leading), n.Bytes()...)
+	if len(decoded) != 25 {
+		return fmt.Errorf("address %q has invalid length", address)
+	}
+
+	first := sha256.Sum256(decoded[:21])
+	second := sha256.Sum256(first[:])
+	if string(second[:4]) != string(decoded[21:]) {
+		return fmt.Errorf("address %q has invalid checksum", address)
+	}
+
+	for _, version := range versions {
+		if decoded[0] == version {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("address %q has unknown version %#x", address, decoded[0])
+}
+
+// validateSegwitAddress validates a BIP-173 or BIP-350 address with the human readable part prefix.
+func validateSegwitAddress(prefix, address string) error {
+	if len(address) > 90 || (strings.ToLower(address) != address && strings.ToUpper(address) != address) {
+		return fmt.Errorf("address %q is not a valid bech32 address", address)
+	}
+	address = strings.ToLower(address)
+
+	data := make([]byte, 0, len(address)-len(prefix)-1)
+	for _, r := range address[len(prefix)+1:] {
+		i := strings.IndexRune(bech32Charset, r)
+		if i < 0 {
+			return fmt.Errorf("address %q contains invalid character %q", address, r)
+		}
+		data = append(data, byte(i))
+	}
+	if len(data) < 7 {
+		return fmt.Errorf("address %q is too short", address)
+	}
+
+	values := make([]byte, 0, 2*len(prefix)+1+len(data))
+	for _, c := range []byte(prefix) {
+		values = append(values, c>>5)
+	}
+	values = append(values, 0)
+	for _, c := range []byte(prefix) {
+		values = append(values, c&31)
+	}
+	checksum := bech32Polymod(append(values, data...))
+
+	version := data[0]
+	program, ok := convertBits(data[1:len(data)-6], 5, 8)
+	switch {
+	case !ok || version > 16 || len(program) < 2 || len(program) > 40:
+		return fmt.Errorf("address %q has an invalid witness program", address)
+	case version == 0 && len(program) != 20 && len(program) != 32:
+		return fmt.Errorf("address %q has an invalid witness program", address)
+	case version == 0 && checksum != bech32Constant, version > 0 && checksum != bech32mConstant:
+		return fmt.Errorf("address %q has invalid checksum", address)
+	}
+
+	return nil
+}
+
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+	checksum := uint32(1)
+	for _, v := range values {
+		top := checksum >> 25
+		checksum = (checksum&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>uint(i))&1 == 1 {
+				checksum ^= generator[i]
+			}
+		}
+	}
+
+	return checksum
+}
+
+// convertBits regroups data from groups of from bits to groups of to bits without padding.
+func convertBits(data []byte, from, to uint) ([]byte, bool) {
+	var (
+		acc       uint32
+		remaining uint
+		converted []byte
+	)
+	for _, v := range data {
+		acc = acc<<from | uint32(v)
+		remaining += from
+		for remaining >= to {
+			remaining -= to
+			converted = append(converted, byte(acc>>remaining&(1<<to-1)))
+		}
+	}
+
+	if remaining >= from || acc<<(to-remaining)&(1<<to-1) != 0 {
+		return nil, false
+	}
+
+	return converted, true
+}
+
+// validateEthereumAddress validates a hex address, mixed case addresses must have a valid EIP-55 checksum.
+func validateEthereumAddress(address string) error {
+	if len(address) != 42 || !strings.HasPrefix(address, "0x") {
+		return fmt.Errorf("address %q is not a valid ethereum address", address)
+	}
+
+	digits := address[2:]
+	if _, err := hex.DecodeString(digits); err != nil {
+		return fmt.Errorf("address %q is not a valid ethereum address", address)
+	}
+	if strings.ToLower(digits) == digits || strings.ToUpper(digits) == digits {
+		return nil
+	}
+
+	hash := keccak256([]byte(strings.ToLower(digits)))
+	for i, c := range digits {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+
+		if (c >= 'a' && c <= 'f' && nibble >= 8) || (c >= 'A' && c <= 'F' && nibble < 8) {
+			return fmt.Errorf("address %q has invalid checksum", address)
+		}
+	}
+
+	return nil
+}
+
+var keccakRoundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+var (
+	keccakRotations = [24]int{1, 3, 6, 10, 15, 21, 28, 36, 45, 55, 2, 14, 27, 41, 56, 8, 25, 43, 62, 18, 39, 61, 20, 44}
+	keccakLanes     = [24]int{10, 7, 11, 17, 18, 3, 5, 16, 8, 21, 24, 4, 15, 23, 19, 13, 12, 2, 20, 14, 22, 9, 6, 1}
+)
+
+// keccak256 is the original Keccak hash used by ethereum, it pads differently than SHA3-256.
+func keccak256(data []byte) [32]byte {
+	const rate = 136
+
+	padded := make([]byte, (len(data)/rate+1)*rate)
+	copy(padded, data)
+	padded[len(data)] ^= 0x01
+	padded[len(padded)-1] ^= 0x80
+
+	var state [25]uint64
+	for block := 0; block < len(padded); block += rate {
+		for i := 0; i < rate/8; i++ {
+			state[i] ^= binary.LittleEndian.Uint64(padded[block+8*i:])
+		}
+		keccakF(&state)
+	}
+
+	var hash [32]byte
+	for i := 0; i < 4; i++ {
+		binary.LittleEndian.PutUint64(hash[8*i:], state[i])
+	}
+
+	return hash
+}
+
+func keccakF(state *[25]uint64) {
+	var column [5]uint64
+	for round := 0; round < 24; round++ {
+		// theta
+		for x := 0; x < 5; x++ {
+			column[x] = state[x] ^ state[x+5] ^ state[x+10] ^ state[x+15] ^ state[x+20]
+		}
+		for x := 0; x < 5; x++ {
+			t := column[(x+4)%5] ^ bits.RotateLeft64(column[(x+1)%5], 1)
+			for y := 0; y < 25; y += 5 {
+				state[y+x] ^= t
+			}
+		}
+
+		// rho and pi
+		t := state[1]
+		for i := 0; i < 24; i++ {
+			lane := keccakLanes[i]
+			t, state[lane] = state[lane], bits.RotateLeft64(t, keccakRotations[i])
+		}
+
+		// chi
+		for y := 0; y < 25; y += 5 {
+			for x := 0; x < 5; x++ {
+				column[x] = state[y+x]
+			}
+			for x := 0; x < 5; x++ {
+				state[y+x] ^= ^column[(x+1)%5] & column[(x+2)%5]
+			}
+		}
+
+		// iota
+		state[0] ^= keccakRoundConstants[round]
+	}
+}
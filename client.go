@@ -30,6 +30,8 @@ type (
 		retryCount        int
 		retryInterval     time.Duration
 		timeOffsetSeconds int
+		withdrawalGuard   *WithdrawalGuard
+		profileID         string
 	}
 	ClientOption func(*client) error
 )
@@ -0,0 +1,97 @@
+package coinbasepro
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// CoinbaseAccount is a wallet of the Coinbase account linked to the profile. Its ID can be used as CoinbaseAccountID
+// of transfers and withdrawals.
+type CoinbaseAccount struct {
+	ID                  string `json:"id"`
+	Name                string `json:"name"`
+	Balance             string `json:"balance"`
+	Currency            string `json:"currency"`
+	Type                string `json:"type"`
+	Primary             bool   `json:"primary"`
+	Active              bool   `json:"active"`
+	AvailableOnConsumer bool   `json:"available_on_consumer"`
+	HoldBalance         string `json:"hold_balance"`
+	HoldCurrency        string `json:"hold_currency"`
+	// WireDepositInformation is set for USD accounts.
+	WireDepositInformation *WireDepositInformation `json:"wire_deposit_information,omitempty"`
+	// SepaDepositInformation is set for EUR accounts.
+	SepaDepositInformation *SepaDepositInformation `json:"sepa_deposit_information,omitempty"`
+}
+
+type WireDepositInformation struct {
+	AccountNumber  string      `json:"account_number"`
+	RoutingNumber  string      `json:"routing_number"`
+	BankName       string      `json:"bank_name"`
+	BankAddress    string      `json:"bank_address"`
+	BankCountry    BankCountry `json:"bank_country"`
+	AccountName    string      `json:"account_name"`
+	AccountAddress string      `json:"account_address"`
+	Reference      string      `json:"reference"`
+}
+
+type BankCountry struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+}
+
+type SepaDepositInformation struct {
+	IBAN            string `json:"iban"`
+	Swift           string `json:"swift"`
+	BankName        string `json:"bank_name"`
+	BankAddress     string `json:"bank_address"`
+	BankCountryName string `json:"bank_country_name"`
+	AccountName     string `json:"account_name"`
+	AccountAddress  string `json:"account_address"`
+	Reference       string `json:"reference"`
+}
+
+// CryptoDepositAddress is a one-time address which deposits into the exchange account of its currency.
+type CryptoDepositAddress struct {
+	ID                     string           `json:"id"`
+	Address                string           `json:"address"`
+	AddressInfo            AddressInfo      `json:"address_info"`
+	Name                   string           `json:"name"`
+	Network                string           `json:"network"`
+	URIScheme              string           `json:"uri_scheme"`
+	DepositURI             string           `json:"deposit_uri"`
+	Warnings               []AddressWarning `json:"warnings"`
+	ExchangeDepositAddress bool             `json:"exchange_deposit_address"`
+	CreatedAt              Time             `json:"created_at,string"`
+	UpdatedAt              Time             `json:"updated_at,string"`
+}
+
+type AddressInfo struct {
+	Address string `json:"address"`
+	// DestinationTag must be sent along with deposits to currencies like XRP and XLM, deposits without it are lost.
+	DestinationTag string `json:"destination_tag"`
+}
+
+type AddressWarning struct {
+	Title    string `json:"title"`
+	Details  string `json:"details"`
+	ImageURL string `json:"image_url"`
+}
+
+// GetCoinbaseAccounts retrieves the wallets of the linked Coinbase account
+func (c *client) GetCoinbaseAccounts(ctx context.Context) ([]CoinbaseAccount, error) {
+	var accounts []CoinbaseAccount
+	url := fmt.Sprintf("/coinbase-accounts")
+	_, err := c.Request(ctx, http.MethodGet, url, nil, &accounts)
+	return accounts, err
+}
+
+// GenerateCryptoDepositAddress generates a new address for depositing into the crypto account coinbaseAccountID
+func (c *client) GenerateCryptoDepositAddress(ctx context.Context, coinbaseAccountID string) (CryptoDepositAddress, error) {
+	var address CryptoDepositAddress
+	requestURL := fmt.Sprintf("/coinbase-accounts/%s/addresses", url.PathEscape(coinbaseAccountID))
+	_, err := c.Request(ctx, http.MethodPost, requestURL, nil, &address)
+	return address, err
+}
@@ -0,0 +1,81 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestGetCoinbaseAccounts(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	accounts, err := client.GetCoinbaseAccounts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(accounts) == 0 {
+		t.Fatal("expected Coinbase accounts")
+	}
+
+	for _, a := range accounts {
+		if a.ID == "" || a.Currency == "" || a.Type == "" {
+			t.Errorf("unexpected Coinbase account %+v", a)
+		}
+		if a.Currency == "USD" && a.WireDepositInformation == nil {
+			t.Error("expected wire deposit information for USD account")
+		}
+	}
+}
+
+func TestGenerateCryptoDepositAddress(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+	accounts, err := client.GetCoinbaseAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range accounts {
+		if a.Type != "wallet" {
+			continue
+		}
+
+		address, err := client.GenerateCryptoDepositAddress(ctx, a.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if address.Address == "" || address.AddressInfo.Address != address.Address || address.CreatedAt.Time().IsZero() {
+			t.Errorf("unexpected deposit address %+v", address)
+		}
+	}
+}
+
+func TestCreateWithdrawalCoinbase(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+	accounts, err := client.GetCoinbaseAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range accounts {
+		if a.Currency != "BTC" {
+			continue
+		}
+
+		withdrawal, err := client.CreateWithdrawalCoinbase(ctx, coinbasepro.WithdrawalCoinbase{
+			Currency:          a.Currency,
+			Amount:            "0.01",
+			CoinbaseAccountID: a.ID,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if withdrawal.Amount != "0.01000000" {
+			t.Errorf("unexpected withdrawal %+v", withdrawal)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package coinbaseprotest
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// coinbaseAccount is a wallet of the Coinbase account linked to the exchange, withdrawals to it credit its
+	// balance.
+	coinbaseAccount struct {
+		id       string
+		name     string
+		currency string
+		kind     string
+		primary  bool
+		balance  *big.Rat
+		wire     *wireDeposit
+		sepa     *sepaDeposit
+	}
+
+	coinbaseAccountResponse struct {
+		ID                     string       `json:"id"`
+		Name                   string       `json:"name"`
+		Balance                string       `json:"balance"`
+		Currency               string       `json:"currency"`
+		Type                   string       `json:"type"`
+		Primary                bool         `json:"primary"`
+		Active                 bool         `json:"active"`
+		AvailableOnConsumer    bool         `json:"available_on_consumer"`
+		HoldBalance            string       `json:"hold_balance"`
+		HoldCurrency           string       `json:"hold_currency"`
+		WireDepositInformation *wireDeposit `json:"wire_deposit_information,omitempty"`
+		SepaDepositInformation *sepaDeposit `json:"sepa_deposit_information,omitempty"`
+	}
+
+	wireDeposit struct {
+		AccountNumber  string      `json:"account_number"`
+		RoutingNumber  string      `json:"routing_number"`
+		BankName       string      `json:"bank_name"`
+		BankAddress    string      `json:"bank_address"`
+		BankCountry    bankCountry `json:"bank_country"`
+		AccountName    string      `json:"account_name"`
+		AccountAddress string      `json:"account_address"`
+		Reference      string      `json:"reference"`
+	}
+
+	bankCountry struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+
+	sepaDeposit struct {
+		IBAN            string `json:"iban"`
+		Swift           string `json:"swift"`
+		BankName        string `json:"bank_name"`
+		BankAddress     string `json:"bank_address"`
+		BankCountryName string `json:"bank_country_name"`
+		AccountName     string `json:"account_name"`
+		AccountAddress  string `json:"account_address"`
+		Reference       string `json:"reference"`
+	}
+
+	depositAddress struct {
+		ID                     string               `json:"id"`
+		Address                string               `json:"address"`
+		AddressInfo            depositAddressInfo   `json:"address_info"`
+		Name                   string               `json:"name"`
+		CreatedAt              string               `json:"created_at"`
+		UpdatedAt              string               `json:"updated_at"`
+		Network                string               `json:"network"`
+		URIScheme              string               `json:"uri_scheme"`
+		Resource               string               `json:"resource"`
+		ResourcePath           string               `json:"resource_path"`
+		Warnings               []depositAddressNote `json:"warnings"`
+		DepositURI             string               `json:"deposit_uri"`
+		ExchangeDepositAddress bool                 `json:"exchange_deposit_address"`
+	}
+
+	depositAddressInfo struct {
+		Address        string `json:"address"`
+		DestinationTag string `json:"destination_tag,omitempty"`
+	}
+
+	depositAddressNote struct {
+		Title    string `json:"title"`
+		Details  string `json:"details"`
+		ImageURL string `json:"image_url"`
+	}
+)
+
+func newCoinbaseAccounts(currencies []currency) []*coinbaseAccount {
+	var accounts []*coinbaseAccount
+	for _, c := range currencies {
+		a := &coinbaseAccount{
+			id:       newID(),
+			name:     c.ID + " Wallet",
+			currency: c.ID,
+			kind:     "wallet",
+			primary:  c.ID == "BTC",
+			balance:  new(big.Rat),
+		}
+
+		if c.Details.Type == "fiat" {
+			a.kind = "fiat"
+		}
+
+		switch c.ID {
+		case "USD":
+			a.wire = &wireDeposit{
+				AccountNumber:  "0199003122",
+				RoutingNumber:  "026013356",
+				BankName:       "Metropolitan Commercial Bank",
+				BankAddress:    "99 Park Ave 4th Fl New York, NY 10016",
+				BankCountry:    bankCountry{Code: "US", Name: "United States"},
+				AccountName:    "Coinbase, Inc",
+				AccountAddress: "548 Market Street, #23008, San Francisco, CA 94104",
+				Reference:      "BAOCAEUX",
+			}
+		case "EUR":
+			a.sepa = &sepaDeposit{
+				IBAN:            "EE957700771001355096",
+				Swift:           "LHVBEE22",
+				BankName:        "AS LHV Pank",
+				BankAddress:     "Tartu mnt 2, 10145 Tallinn, Estonia",
+				BankCountryName: "Estonia",
+				AccountName:     "Coinbase UK, Ltd.",
+				AccountAddress:  "9th Floor, 107 Cheapside, London, EC2V 6DN, United Kingdom",
+				Reference:       "CBAEUXOVFXOXYX",
+			}
+		}
+
+		accounts = append(accounts, a)
+	}
+
+	return accounts
+}
+
+func (e *exchange) coinbaseAccount(id string) *coinbaseAccount {
+	for _, a := range e.coinbaseAccounts {
+		if a.id == id {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) getCoinbaseAccounts(w http.ResponseWriter, r *request) {
+	accounts := []coinbaseAccountResponse{}
+	for _, a := range e.coinbaseAccounts {
+		accounts = append(accounts, coinbaseAccountResponse{
+			ID:                     a.id,
+			Name:                   a.name,
+			Balance:                formatAmount(a.balance, 8),
+			Currency:               a.currency,
+			Type:                   a.kind,
+			Primary:                a.primary,
+			Active:                 true,
+			AvailableOnConsumer:    true,
+			HoldBalance:            "0.00",
+			HoldCurrency:           "USD",
+			WireDepositInformation: a.wire,
+			SepaDepositInformation: a.sepa,
+		})
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+func (e *exchange) createDepositAddress(w http.ResponseWriter, r *request) {
+	a := e.coinbaseAccount(r.args[0])
+	if a == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	c, ok := chains[a.currency]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "Cannot generate an address for a fiat account")
+		return
+	}
+	network := c.network
+
+	random := strings.ReplaceAll(newID(), "-", "")
+	info := depositAddressInfo{Address: "0x" + random + hex.EncodeToString([]byte(random[:4]))}
+	switch a.currency {
+	case "BTC":
+		info.Address = "bc1q" + random[:28]
+	case "XRP":
+		// deposits share the address of the exchange and are told apart by their tag
+		info.Address = "rLW9gnQo7BQhU6igk5keqYnH3TVrCxGRzm"
+		info.DestinationTag = strconv.FormatUint(uint64(binary.BigEndian.Uint32([]byte(random[:4]))), 10)
+	}
+
+	id, now := newID(), stamp(time.Now())
+	writeJSON(w, http.StatusOK, depositAddress{
+		ID:                     id,
+		Address:                info.Address,
+		AddressInfo:            info,
+		Name:                   "New exchange deposit address",
+		CreatedAt:              now,
+		UpdatedAt:              now,
+		Network:                network,
+		URIScheme:              network,
+		Resource:               "address",
+		ResourcePath:           "/v2/accounts/" + a.id + "/addresses/" + id,
+		Warnings:               []depositAddressNote{},
+		DepositURI:             network + ":" + info.Address,
+		ExchangeDepositAddress: true,
+	})
+}
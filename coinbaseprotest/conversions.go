@@ -0,0 +1,87 @@
+package coinbaseprotest
+
+import (
+	"math/big"
+	"net/http"
+	"time"
+)
+
+type conversion struct {
+	ID            string `json:"id"`
+	Amount        string `json:"amount"`
+	FromAccountID string `json:"from_account_id"`
+	ToAccountID   string `json:"to_account_id"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	CreatedAt     string `json:"created_at"`
+}
+
+// stablecoins maps the currencies which can be converted to each other.
+var stablecoins = map[string]string{
+	"USD":  "USDC",
+	"USDC": "USD",
+}
+
+func (e *exchange) createConversion(w http.ResponseWriter, r *request) {
+	var req struct {
+		ProfileID string `json:"profile_id"`
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Amount    string `json:"amount"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	profileID := req.ProfileID
+	if profileID == "" {
+		profileID = e.defaultProfile().ID
+	}
+	if p := e.profile(profileID); p == nil || !p.Active {
+		writeError(w, http.StatusBadRequest, "Profile not found")
+		return
+	}
+
+	if stablecoins[req.From] != req.To || req.To == "" {
+		writeError(w, http.StatusBadRequest, "Invalid conversion pair")
+		return
+	}
+	from, to := e.account(profileID, req.From), e.account(profileID, req.To)
+
+	amount, ok := parseAmount(req.Amount)
+	if !ok || amount.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+	if e.available(from).Cmp(amount) < 0 {
+		writeError(w, http.StatusBadRequest, "Insufficient funds")
+		return
+	}
+
+	c := &conversion{
+		ID:            newID(),
+		Amount:        formatAmount(amount, 8),
+		FromAccountID: from.id,
+		ToAccountID:   to.id,
+		From:          req.From,
+		To:            req.To,
+		CreatedAt:     stamp(time.Now()),
+	}
+	e.conversions = append(e.conversions, c)
+
+	e.credit(from, new(big.Rat).Neg(amount), "conversion", ledgerDetails{ConversionID: c.ID})
+	e.credit(to, amount, "conversion", ledgerDetails{ConversionID: c.ID})
+
+	writeJSON(w, http.StatusOK, c)
+}
+
+func (e *exchange) getConversion(w http.ResponseWriter, r *request) {
+	for _, c := range e.conversions {
+		if c.ID == r.args[0] {
+			writeJSON(w, http.StatusOK, c)
+			return
+		}
+	}
+
+	writeError(w, http.StatusNotFound, "NotFound")
+}
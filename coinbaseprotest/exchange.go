@@ -0,0 +1,948 @@
+package coinbaseprotest
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	timeLayout = "2006-01-02T15:04:05.000000Z"
+
+	makerFeeRate = "0.0040"
+	takerFeeRate = "0.0060"
+)
+
+// exchange is the state of the simulated exchange. All requests are authenticated with the key of the default
+// profile.
+type exchange struct {
+	mu      sync.Mutex
+	publish func(channel, productID string, msg interface{})
+
+	products         []product
+	currencies       []currency
+	paymentMethods   []paymentMethod
+	prices           map[string]*big.Rat
+	profiles         []profile
+	accounts         []*account
+	ledger           map[string][]ledgerEntry
+	orders           []*order
+	fills            []fill
+	trades           map[string][]trade
+	reports          map[string]*report
+	transfers        []*transfer
+	coinbaseAccounts []*coinbaseAccount
+	conversions      []*conversion
+	sequence         int64
+	tradeID          int
+	userID           string
+}
+
+type (
+	product struct {
+		ID              string `json:"id"`
+		BaseCurrency    string `json:"base_currency"`
+		QuoteCurrency   string `json:"quote_currency"`
+		BaseMinSize     string `json:"base_min_size"`
+		BaseMaxSize     string `json:"base_max_size"`
+		QuoteIncrement  string `json:"quote_increment"`
+		BaseIncrement   string `json:"base_increment"`
+		DisplayName     string `json:"display_name"`
+		MinMarketFunds  string `json:"min_market_funds"`
+		MaxMarketFunds  string `json:"max_market_funds"`
+		MarginEnabled   bool   `json:"margin_enabled"`
+		PostOnly        bool   `json:"post_only"`
+		LimitOnly       bool   `json:"limit_only"`
+		CancelOnly      bool   `json:"cancel_only"`
+		TradingDisabled bool   `json:"trading_disabled"`
+		Status          string `json:"status"`
+		StatusMessage   string `json:"status_message"`
+	}
+
+	currency struct {
+		ID           string          `json:"id"`
+		Name         string          `json:"name"`
+		MinSize      string          `json:"min_size"`
+		Status       string          `json:"status"`
+		MaxPrecision string          `json:"max_precision"`
+		Details      currencyDetails `json:"details"`
+	}
+
+	currencyDetails struct {
+		Type                 string `json:"type"`
+		NetworkConfirmations int    `json:"network_confirmations,omitempty"`
+	}
+
+	paymentMethod struct {
+		ID            string `json:"id"`
+		Type          string `json:"type"`
+		Name          string `json:"name"`
+		Currency      string `json:"currency"`
+		PrimaryBuy    bool   `json:"primary_buy"`
+		AllowDeposit  bool   `json:"allow_deposit"`
+		AllowWithdraw bool   `json:"allow_withdraw"`
+	}
+
+	profile struct {
+		ID        string `json:"id"`
+		UserID    string `json:"user_id"`
+		Name      string `json:"name"`
+		Active    bool   `json:"active"`
+		IsDefault bool   `json:"is_default"`
+		CreatedAt string `json:"created_at"`
+	}
+
+	account struct {
+		id        string
+		profileID string
+		currency  string
+		balance   *big.Rat
+	}
+
+	accountResponse struct {
+		ID             string `json:"id"`
+		Currency       string `json:"currency"`
+		Balance        string `json:"balance"`
+		Hold           string `json:"hold"`
+		Available      string `json:"available"`
+		ProfileID      string `json:"profile_id"`
+		TradingEnabled bool   `json:"trading_enabled"`
+	}
+
+	ledgerEntry struct {
+		ID        string        `json:"id"`
+		CreatedAt string        `json:"created_at"`
+		Amount    string        `json:"amount"`
+		Balance   string        `json:"balance"`
+		Type      string        `json:"type"`
+		Details   ledgerDetails `json:"details"`
+
+		sequence int64
+	}
+
+	ledgerDetails struct {
+		OrderID      string `json:"order_id,omitempty"`
+		TradeID      string `json:"trade_id,omitempty"`
+		ProductID    string `json:"product_id,omitempty"`
+		TransferID   string `json:"transfer_id,omitempty"`
+		TransferType string `json:"transfer_type,omitempty"`
+		ConversionID string `json:"conversion_id,omitempty"`
+
+		From              string `json:"from,omitempty"`
+		To                string `json:"to,omitempty"`
+		ProfileTransferID string `json:"profile_transfer_id,omitempty"`
+	}
+
+	hold struct {
+		ID        string `json:"id"`
+		AccountID string `json:"account_id"`
+		CreatedAt string `json:"created_at"`
+		UpdatedAt string `json:"updated_at"`
+		Amount    string `json:"amount"`
+		Type      string `json:"type"`
+		Ref       string `json:"ref"`
+	}
+
+	fill struct {
+		TradeID   int    `json:"trade_id"`
+		ProductID string `json:"product_id"`
+		OrderID   string `json:"order_id"`
+		UserID    string `json:"user_id"`
+		ProfileID string `json:"profile_id"`
+		Liquidity string `json:"liquidity"`
+		Price     string `json:"price"`
+		Size      string `json:"size"`
+		Fee       string `json:"fee"`
+		CreatedAt string `json:"created_at"`
+		Side      string `json:"side"`
+		Settled   bool   `json:"settled"`
+		USDVolume string `json:"usd_volume"`
+	}
+
+	trade struct {
+		Time    string `json:"time"`
+		TradeID int    `json:"trade_id"`
+		Price   string `json:"price"`
+		Size    string `json:"size"`
+		Side    string `json:"side"`
+	}
+)
+
+// route maps a method and path pattern to a handler, * matches a single path segment.
+type route struct {
+	method  string
+	pattern string
+	handle  func(e *exchange, w http.ResponseWriter, r *request)
+}
+
+type request struct {
+	*http.Request
+	args []string
+	body []byte
+}
+
+var routes = []route{
+	{http.MethodGet, "/time", (*exchange).getTime},
+	{http.MethodGet, "/currencies", (*exchange).getCurrencies},
+	{http.MethodGet, "/products", (*exchange).getProducts},
+	{http.MethodGet, "/products/*", (*exchange).getProduct},
+	{http.MethodGet, "/products/*/book", (*exchange).getBook},
+	{http.MethodGet, "/products/*/ticker", (*exchange).getTicker},
+	{http.MethodGet, "/products/*/trades", (*exchange).listTrades},
+	{http.MethodGet, "/products/*/candles", (*exchange).getCandles},
+	{http.MethodGet, "/products/*/stats", (*exchange).getStats},
+	{http.MethodGet, "/accounts", (*exchange).getAccounts},
+	{http.MethodGet, "/accounts/*", (*exchange).getAccount},
+	{http.MethodGet, "/accounts/*/ledger", (*exchange).listLedger},
+	{http.MethodGet, "/accounts/*/holds", (*exchange).listHolds},
+	{http.MethodGet, "/fees", (*exchange).getFees},
+	{http.MethodGet, "/fills", (*exchange).listFills},
+	{http.MethodPost, "/orders", (*exchange).placeOrder},
+	{http.MethodGet, "/orders", (*exchange).listOrders},
+	{http.MethodDelete, "/orders", (*exchange).cancelAllOrders},
+	{http.MethodGet, "/orders/*", (*exchange).getOrder},
+	{http.MethodDelete, "/orders/*", (*exchange).cancelOrder},
+	{http.MethodGet, "/profiles", (*exchange).getProfiles},
+	{http.MethodGet, "/profiles/*", (*exchange).getProfile},
+	{http.MethodPost, "/profiles", (*exchange).createProfile},
+	{http.MethodPut, "/profiles/*", (*exchange).renameProfile},
+	{http.MethodPut, "/profiles/*/deactivate", (*exchange).deactivateProfile},
+	{http.MethodPost, "/profiles/transfer", (*exchange).createProfileTransfer},
+	{http.MethodPost, "/reports", (*exchange).createReport},
+	{http.MethodGet, "/reports", (*exchange).listReports},
+	{http.MethodGet, "/reports/*", (*exchange).getReport},
+	{http.MethodGet, reportFilesPath + "*", (*exchange).getReportFile},
+	{http.MethodGet, "/payment-methods", (*exchange).getPaymentMethods},
+	{http.MethodPost, "/deposits/payment-method", (*exchange).createDeposit},
+	{http.MethodPost, "/withdrawals/payment-method", (*exchange).createWithdrawalPaymentMethod},
+	{http.MethodPost, "/withdrawals/coinbase-account", (*exchange).createWithdrawalCoinbase},
+	{http.MethodPost, "/withdrawals/crypto", (*exchange).createWithdrawalCrypto},
+	{http.MethodGet, "/withdrawals/fee-estimate", (*exchange).getWithdrawalFeeEstimate},
+	{http.MethodPost, "/transfers", (*exchange).createTransfer},
+	{http.MethodGet, "/transfers", (*exchange).listTransfers},
+	{http.MethodGet, "/transfers/*", (*exchange).getTransfer},
+	{http.MethodPost, "/conversions", (*exchange).createConversion},
+	{http.MethodGet, "/conversions/*", (*exchange).getConversion},
+	{http.MethodGet, "/coinbase-accounts", (*exchange).getCoinbaseAccounts},
+	{http.MethodPost, "/coinbase-accounts/*/addresses", (*exchange).createDepositAddress},
+}
+
+func newExchange() *exchange {
+	e := &exchange{
+		prices:  make(map[string]*big.Rat),
+		ledger:  make(map[string][]ledgerEntry),
+		trades:  make(map[string][]trade),
+		reports: make(map[string]*report),
+		userID:  newID(),
+		publish: func(string, string, interface{}) {},
+	}
+
+	e.currencies = []currency{
+		{ID: "BTC", Name: "Bitcoin", MinSize: "0.00000001", Status: "online", MaxPrecision: "0.00000001", Details: currencyDetails{Type: "crypto", NetworkConfirmations: 3}},
+		{ID: "ETH", Name: "Ether", MinSize: "0.00000001", Status: "online", MaxPrecision: "0.00000001", Details: currencyDetails{Type: "crypto", NetworkConfirmations: 35}},
+		{ID: "EUR", Name: "Euro", MinSize: "0.01", Status: "online", MaxPrecision: "0.01", Details: currencyDetails{Type: "fiat"}},
+		{ID: "GBP", Name: "British Pound", MinSize: "0.01", Status: "online", MaxPrecision: "0.01", Details: currencyDetails{Type: "fiat"}},
+		{ID: "USD", Name: "United States Dollar", MinSize: "0.01", Status: "online", MaxPrecision: "0.01", Details: currencyDetails{Type: "fiat"}},
+		{ID: "USDC", Name: "USD Coin", MinSize: "0.000001", Status: "online", MaxPrecision: "0.000001", Details: currencyDetails{Type: "crypto", NetworkConfirmations: 35}},
+		{ID: "XRP", Name: "XRP", MinSize: "0.000001", Status: "online", MaxPrecision: "0.000001", Details: currencyDetails{Type: "crypto", NetworkConfirmations: 1}},
+	}
+
+	for _, p := range []struct{ base, quote, increment, price string }{
+		{"BTC", "USD", "0.01", "30000"},
+		{"BTC", "EUR", "0.01", "27000"},
+		{"BTC", "GBP", "0.01", "24000"},
+		{"ETH", "USD", "0.01", "2000"},
+		{"ETH", "BTC", "0.00001", "0.066"},
+	} {
+		id := p.base + "-" + p.quote
+		e.products = append(e.products, product{
+			ID:             id,
+			BaseCurrency:   p.base,
+			QuoteCurrency:  p.quote,
+			BaseMinSize:    "0.00100000",
+			BaseMaxSize:    "280.00000000",
+			QuoteIncrement: p.increment,
+			BaseIncrement:  "0.00000001",
+			DisplayName:    p.base + "/" + p.quote,
+			MinMarketFunds: p.increment,
+			MaxMarketFunds: "1000000",
+			Status:         "online",
+		})
+		e.prices[id], _ = new(big.Rat).SetString(p.price)
+	}
+
+	e.paymentMethods = []paymentMethod{
+		{ID: newID(), Type: "ach_bank_account", Name: "Bank of America - eBan... ********7134", Currency: "USD", PrimaryBuy: true, AllowDeposit: true, AllowWithdraw: true},
+		{ID: newID(), Type: "sepa_bank_account", Name: "SEPA ********1234", Currency: "EUR", AllowDeposit: true, AllowWithdraw: true},
+	}
+
+	e.coinbaseAccounts = newCoinbaseAccounts(e.currencies)
+
+	now := stamp(time.Now())
+	e.profiles = []profile{
+		{ID: newID(), UserID: e.userID, Name: "default", Active: true, IsDefault: true, CreatedAt: now},
+		{ID: newID(), UserID: e.userID, Name: "trading", Active: true, CreatedAt: now},
+	}
+	for _, p := range e.profiles {
+		for _, c := range e.currencies {
+			e.accounts = append(e.accounts, &account{id: newID(), profileID: p.ID, currency: c.ID, balance: new(big.Rat)})
+		}
+	}
+
+	e.setBalances(map[string]string{"BTC": "10", "ETH": "100", "EUR": "100000", "GBP": "100000", "USD": "100000", "USDC": "100000", "XRP": "10000"})
+
+	for _, p := range e.products {
+		price := e.prices[p.ID]
+		for i, side := range []string{"buy", "sell", "buy"} {
+			e.tradeID++
+			e.trades[p.ID] = append(e.trades[p.ID], trade{
+				Time:    stamp(time.Now().Add(time.Duration(i-3) * time.Minute)),
+				TradeID: e.tradeID,
+				Price:   formatAmount(price, 8),
+				Size:    "0.10000000",
+				Side:    side,
+			})
+		}
+	}
+
+	return e
+}
+
+func (e *exchange) setBalances(balances map[string]string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	parsed := make(map[string]*big.Rat)
+	for c, balance := range balances {
+		if e.account(e.defaultProfile().ID, c) == nil {
+			return fmt.Errorf("unknown currency %s", c)
+		}
+
+		amount, ok := parseAmount(balance)
+		if !ok || amount.Sign() < 0 {
+			return fmt.Errorf("invalid balance %s for %s", balance, c)
+		}
+		parsed[c] = amount
+	}
+
+	e.transfers = nil
+	for _, a := range e.accounts {
+		if a.profileID != e.defaultProfile().ID {
+			continue
+		}
+
+		a.balance = new(big.Rat)
+		delete(e.ledger, a.id)
+
+		if amount, ok := parsed[a.currency]; ok && amount.Sign() > 0 {
+			e.transfer(a, amount, "deposit", map[string]string{})
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) setPrice(productID, price string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.product(productID) == nil {
+		return fmt.Errorf("unknown product %s", productID)
+	}
+
+	p, ok := parseAmount(price)
+	if !ok || p.Sign() <= 0 {
+		return fmt.Errorf("invalid price %s", price)
+	}
+	e.prices[productID] = p
+
+	return nil
+}
+
+func (e *exchange) serve(w http.ResponseWriter, r *http.Request, body []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.expireOrders(time.Now())
+
+	segments := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	for _, rt := range routes {
+		if rt.method != r.Method {
+			continue
+		}
+
+		args, ok := matchRoute(rt.pattern, segments)
+		if !ok {
+			continue
+		}
+
+		rt.handle(e, w, &request{Request: r, args: args, body: body})
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "Route not found")
+}
+
+func matchRoute(pattern string, segments []string) ([]string, bool) {
+	parts := strings.Split(strings.Trim(pattern, "/"), "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	var args []string
+	for i, part := range parts {
+		switch {
+		case part == "*":
+			args = append(args, segments[i])
+		case part != segments[i]:
+			return nil, false
+		}
+	}
+
+	return args, true
+}
+
+func (e *exchange) nextSequence() int64 {
+	e.sequence++
+	return e.sequence
+}
+
+func (e *exchange) product(id string) *product {
+	for i := range e.products {
+		if e.products[i].ID == id {
+			return &e.products[i]
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) defaultProfile() profile {
+	return e.profiles[0]
+}
+
+func (e *exchange) profile(id string) *profile {
+	for i := range e.profiles {
+		if e.profiles[i].ID == id {
+			return &e.profiles[i]
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) account(profileID, currency string) *account {
+	for _, a := range e.accounts {
+		if a.profileID == profileID && a.currency == currency {
+			return a
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) accountByID(id string) *account {
+	for _, a := range e.accounts {
+		if a.id == id && a.profileID == e.defaultProfile().ID {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// held is the amount of an account held by open orders.
+func (e *exchange) held(a *account) *big.Rat {
+	total := new(big.Rat)
+	for _, o := range e.orders {
+		if o.holdAccount == a && o.hold != nil {
+			total.Add(total, o.hold)
+		}
+	}
+
+	return total
+}
+
+func (e *exchange) available(a *account) *big.Rat {
+	return new(big.Rat).Sub(a.balance, e.held(a))
+}
+
+// credit adds amount to the balance of an account, negative amounts debit it, and records a ledger entry.
+func (e *exchange) credit(a *account, amount *big.Rat, entryType string, details ledgerDetails) {
+	a.balance = new(big.Rat).Add(a.balance, amount)
+
+	sequence := e.nextSequence()
+	e.ledger[a.id] = append([]ledgerEntry{{
+		ID:        strconv.FormatInt(sequence, 10),
+		CreatedAt: stamp(time.Now()),
+		Amount:    formatAmount(amount, 16),
+		Balance:   formatAmount(a.balance, 16),
+		Type:      entryType,
+		Details:   details,
+		sequence:  sequence,
+	}}, e.ledger[a.id]...)
+}
+
+func (e *exchange) accountResponse(a *account) accountResponse {
+	held := e.held(a)
+
+	return accountResponse{
+		ID:             a.id,
+		Currency:       a.currency,
+		Balance:        formatAmount(a.balance, 16),
+		Hold:           formatAmount(held, 16),
+		Available:      formatAmount(new(big.Rat).Sub(a.balance, held), 16),
+		ProfileID:      a.profileID,
+		TradingEnabled: true,
+	}
+}
+
+func (e *exchange) getTime(w http.ResponseWriter, r *request) {
+	now := time.Now()
+
+	writeJSON(w, http.StatusOK, struct {
+		ISO   string  `json:"iso"`
+		Epoch float64 `json:"epoch"`
+	}{stamp(now), float64(now.UnixNano()) / float64(time.Second)})
+}
+
+func (e *exchange) getCurrencies(w http.ResponseWriter, r *request) {
+	writeJSON(w, http.StatusOK, e.currencies)
+}
+
+func (e *exchange) getAccounts(w http.ResponseWriter, r *request) {
+	var accounts []accountResponse
+	for _, a := range e.accounts {
+		if a.profileID == e.defaultProfile().ID {
+			accounts = append(accounts, e.accountResponse(a))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, accounts)
+}
+
+func (e *exchange) getAccount(w http.ResponseWriter, r *request) {
+	a := e.accountByID(r.args[0])
+	if a == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, e.accountResponse(a))
+}
+
+func (e *exchange) listLedger(w http.ResponseWriter, r *request) {
+	a := e.accountByID(r.args[0])
+	if a == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	query := r.URL.Query()
+	start, err := parseDate(query.Get("start_date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid start_date")
+		return
+	}
+	end, err := parseDate(query.Get("end_date"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid end_date")
+		return
+	}
+
+	var (
+		entries []ledgerEntry
+		cursors []int64
+	)
+	for _, entry := range e.ledger[a.id] {
+		created, _ := time.Parse(timeLayout, entry.CreatedAt)
+		if created.Before(start) || (!end.IsZero() && !created.Before(end)) {
+			continue
+		}
+
+		entries = append(entries, entry)
+		cursors = append(cursors, entry.sequence)
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]ledgerEntry{}, entries[from:to]...))
+}
+
+func (e *exchange) listHolds(w http.ResponseWriter, r *request) {
+	a := e.accountByID(r.args[0])
+	if a == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	var (
+		holds   []hold
+		cursors []int64
+	)
+	for i := len(e.orders) - 1; i >= 0; i-- {
+		o := e.orders[i]
+		if o.holdAccount != a || o.hold == nil || o.hold.Sign() <= 0 {
+			continue
+		}
+
+		holds = append(holds, hold{
+			ID:        o.holdID,
+			AccountID: a.id,
+			CreatedAt: stamp(o.createdAt),
+			UpdatedAt: stamp(o.updatedAt),
+			Amount:    formatAmount(o.hold, 16),
+			Type:      "order",
+			Ref:       o.id,
+		})
+		cursors = append(cursors, o.sequence)
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]hold{}, holds[from:to]...))
+}
+
+func (e *exchange) getFees(w http.ResponseWriter, r *request) {
+	writeJSON(w, http.StatusOK, struct {
+		MakerFeeRate string `json:"maker_fee_rate"`
+		TakerFeeRate string `json:"taker_fee_rate"`
+		USDVolume    string `json:"usd_volume"`
+	}{makerFeeRate, takerFeeRate, "0.00"})
+}
+
+func (e *exchange) listFills(w http.ResponseWriter, r *request) {
+	orderID, productID := r.URL.Query().Get("order_id"), r.URL.Query().Get("product_id")
+	if orderID == "" && productID == "" {
+		writeError(w, http.StatusBadRequest, "order_id or product_id is required")
+		return
+	}
+
+	var (
+		fills   []fill
+		cursors []int64
+	)
+	for i := len(e.fills) - 1; i >= 0; i-- {
+		f := e.fills[i]
+		if (orderID != "" && f.OrderID != orderID) || (productID != "" && f.ProductID != productID) {
+			continue
+		}
+		if profileID := r.URL.Query().Get("profile_id"); profileID != "" && f.ProfileID != profileID {
+			continue
+		}
+
+		fills = append(fills, f)
+		cursors = append(cursors, int64(f.TradeID))
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]fill{}, fills[from:to]...))
+}
+
+func (e *exchange) getProfiles(w http.ResponseWriter, r *request) {
+	active := r.URL.Query().Get("active")
+
+	profiles := []profile{}
+	for _, p := range e.profiles {
+		if active != "" && strconv.FormatBool(p.Active) != active {
+			continue
+		}
+		profiles = append(profiles, p)
+	}
+
+	writeJSON(w, http.StatusOK, profiles)
+}
+
+func (e *exchange) getProfile(w http.ResponseWriter, r *request) {
+	p := e.profile(r.args[0])
+	if p == nil {
+		writeError(w, http.StatusNotFound, "Profile not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (e *exchange) createProfile(w http.ResponseWriter, r *request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if req.Name == "" {
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	p := profile{ID: newID(), UserID: e.userID, Name: req.Name, Active: true, CreatedAt: stamp(time.Now())}
+	e.profiles = append(e.profiles, p)
+	for _, c := range e.currencies {
+		e.accounts = append(e.accounts, &account{id: newID(), profileID: p.ID, currency: c.ID, balance: new(big.Rat)})
+	}
+
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (e *exchange) renameProfile(w http.ResponseWriter, r *request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	p := e.profile(r.args[0])
+	switch {
+	case p == nil:
+		writeError(w, http.StatusNotFound, "Profile not found")
+		return
+	case req.Name == "":
+		writeError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	p.Name = req.Name
+	writeJSON(w, http.StatusOK, p)
+}
+
+// deactivateProfile moves the funds of a profile to the profile to and deactivates it.
+func (e *exchange) deactivateProfile(w http.ResponseWriter, r *request) {
+	var req struct {
+		To string `json:"to"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	p, to := e.profile(r.args[0]), e.profile(req.To)
+	switch {
+	case p == nil || !p.Active:
+		writeError(w, http.StatusNotFound, "Profile not found")
+		return
+	case p.IsDefault:
+		writeError(w, http.StatusBadRequest, "The default profile cannot be deactivated")
+		return
+	case to == nil || !to.Active || to.ID == p.ID:
+		writeError(w, http.StatusBadRequest, "Invalid target profile")
+		return
+	}
+
+	for _, a := range e.accounts {
+		if a.profileID == p.ID && e.held(a).Sign() > 0 {
+			writeError(w, http.StatusBadRequest, "Profile has open orders")
+			return
+		}
+	}
+
+	for _, a := range e.accounts {
+		if a.profileID != p.ID || a.balance.Sign() <= 0 {
+			continue
+		}
+
+		e.profileTransfer(a, e.account(to.ID, a.currency), new(big.Rat).Set(a.balance))
+	}
+
+	p.Active = false
+	writeJSON(w, http.StatusOK, p)
+}
+
+func (e *exchange) createProfileTransfer(w http.ResponseWriter, r *request) {
+	var req struct {
+		From     string `json:"from"`
+		To       string `json:"to"`
+		Currency string `json:"currency"`
+		Amount   string `json:"amount"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	from, to := e.profile(req.From), e.profile(req.To)
+	if from == nil || to == nil || !from.Active || !to.Active {
+		writeError(w, http.StatusBadRequest, "Profile not found")
+		return
+	}
+
+	source, destination := e.account(from.ID, req.Currency), e.account(to.ID, req.Currency)
+	if source == nil {
+		writeError(w, http.StatusBadRequest, "Currency not found")
+		return
+	}
+
+	amount, ok := parseAmount(req.Amount)
+	if !ok || amount.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid amount")
+		return
+	}
+	if e.available(source).Cmp(amount) < 0 {
+		writeError(w, http.StatusBadRequest, "Insufficient funds")
+		return
+	}
+
+	e.profileTransfer(source, destination, amount)
+
+	writeJSON(w, http.StatusOK, struct{}{})
+}
+
+// profileTransfer moves amount between the accounts of two profiles, the ledger entries of both sides name the
+// profiles and share the id of the profile transfer.
+func (e *exchange) profileTransfer(source, destination *account, amount *big.Rat) {
+	id := newID()
+	for _, side := range []struct {
+		a            *account
+		amount       *big.Rat
+		transferType string
+	}{
+		{source, new(big.Rat).Neg(amount), "internal_withdraw"},
+		{destination, amount, "internal_deposit"},
+	} {
+		e.transfer(side.a, side.amount, side.transferType, map[string]string{})
+
+		details := &e.ledger[side.a.id][0].Details
+		details.From = source.profileID
+		details.To = destination.profileID
+		details.ProfileTransferID = id
+	}
+}
+
+// paginate selects the page of items requested by the before, after and limit parameters of r and sets the
+// CB-BEFORE and CB-AFTER headers. cursors identify the items which are sorted newest first.
+func paginate(w http.ResponseWriter, r *http.Request, cursors []int64) (int, int, bool) {
+	query := r.URL.Query()
+
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > 1000 {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return 0, 0, false
+		}
+		limit = l
+	}
+
+	from, to := 0, len(cursors)
+	switch {
+	case query.Get("after") != "":
+		after, err := strconv.ParseInt(query.Get("after"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid after")
+			return 0, 0, false
+		}
+
+		from = sort.Search(len(cursors), func(i int) bool { return cursors[i] < after })
+		if to > from+limit {
+			to = from + limit
+		}
+	case query.Get("before") != "":
+		before, err := strconv.ParseInt(query.Get("before"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid before")
+			return 0, 0, false
+		}
+
+		to = sort.Search(len(cursors), func(i int) bool { return cursors[i] <= before })
+		if from < to-limit {
+			from = to - limit
+		}
+	default:
+		if to > limit {
+			to = limit
+		}
+	}
+
+	if from < to && from > 0 {
+		w.Header().Set("CB-BEFORE", strconv.FormatInt(cursors[from], 10))
+	}
+	if from < to && to < len(cursors) {
+		w.Header().Set("CB-AFTER", strconv.FormatInt(cursors[to-1], 10))
+	}
+
+	return from, to, true
+}
+
+func decode(w http.ResponseWriter, r *request, v interface{}) bool {
+	if err := json.Unmarshal(r.body, v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body")
+		return false
+	}
+
+	return true
+}
+
+func parseAmount(s string) (*big.Rat, bool) {
+	if s == "" {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(s)
+}
+
+// formatAmount formats r with places decimals, rounding the last digit.
+func formatAmount(r *big.Rat, places int) string {
+	if r == nil {
+		return formatAmount(new(big.Rat), places)
+	}
+
+	return r.FloatString(places)
+}
+
+// truncate rounds r down to places decimals.
+func truncate(r *big.Rat, places int) *big.Rat {
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(places)), nil)
+	n := new(big.Int).Mul(r.Num(), scale)
+	n.Quo(n, r.Denom())
+
+	return new(big.Rat).SetFrac(n, scale)
+}
+
+func mustRat(s string) *big.Rat {
+	r, ok := parseAmount(s)
+	if !ok {
+		panic("invalid decimal " + s)
+	}
+
+	return r
+}
+
+func stamp(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
+
+// parseDate parses a date filter of a request, an empty date is the zero time.
+func parseDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339Nano, s)
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,345 @@
+package coinbaseprotest
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	ws "github.com/gorilla/websocket"
+)
+
+const (
+	heartbeatInterval = time.Second
+	feedBuffer        = 256
+)
+
+// message is a websocket feed message.
+type message struct {
+	Type          string            `json:"type"`
+	Sequence      int64             `json:"sequence,omitempty"`
+	ProductID     string            `json:"product_id,omitempty"`
+	Time          string            `json:"time,omitempty"`
+	TradeID       int               `json:"trade_id,omitempty"`
+	LastTradeID   int               `json:"last_trade_id,omitempty"`
+	OrderID       string            `json:"order_id,omitempty"`
+	ClientOID     string            `json:"client_oid,omitempty"`
+	OrderType     string            `json:"order_type,omitempty"`
+	MakerOrderID  string            `json:"maker_order_id,omitempty"`
+	TakerOrderID  string            `json:"taker_order_id,omitempty"`
+	Side          string            `json:"side,omitempty"`
+	Size          string            `json:"size,omitempty"`
+	Price         string            `json:"price,omitempty"`
+	Funds         string            `json:"funds,omitempty"`
+	RemainingSize string            `json:"remaining_size,omitempty"`
+	Reason        string            `json:"reason,omitempty"`
+	LastSize      string            `json:"last_size,omitempty"`
+	BestBid       string            `json:"best_bid,omitempty"`
+	BestAsk       string            `json:"best_ask,omitempty"`
+	UserID        string            `json:"user_id,omitempty"`
+	ProfileID     string            `json:"profile_id,omitempty"`
+	Bids          [][]string        `json:"bids,omitempty"`
+	Asks          [][]string        `json:"asks,omitempty"`
+	Changes       [][]string        `json:"changes,omitempty"`
+	Products      []product         `json:"products,omitempty"`
+	Currencies    []currency        `json:"currencies,omitempty"`
+	Channels      []subscribedTopic `json:"channels,omitempty"`
+	Message       string            `json:"message,omitempty"`
+}
+
+type subscribedTopic struct {
+	Name       string   `json:"name"`
+	ProductIds []string `json:"product_ids"`
+}
+
+// subscribeRequest is the first message of a connection. Channels are either names or objects with product ids.
+type subscribeRequest struct {
+	Type       string            `json:"type"`
+	ProductIds []string          `json:"product_ids"`
+	Channels   []json.RawMessage `json:"channels"`
+	Key        string            `json:"key"`
+	Passphrase string            `json:"passphrase"`
+	Timestamp  string            `json:"timestamp"`
+	Signature  string            `json:"signature"`
+}
+
+type feed struct {
+	server   *Server
+	upgrader ws.Upgrader
+
+	mu    sync.Mutex
+	conns map[*feedConn]struct{}
+}
+
+type feedConn struct {
+	conn   *ws.Conn
+	topics map[string][]string
+	send   chan interface{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+func newFeed(s *Server) *feed {
+	return &feed{
+		server: s,
+		conns:  make(map[*feedConn]struct{}),
+	}
+}
+
+func (f *feed) serve(w http.ResponseWriter, r *http.Request) {
+	conn, err := f.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &feedConn{
+		conn:   conn,
+		topics: make(map[string][]string),
+		send:   make(chan interface{}, feedBuffer),
+		done:   make(chan struct{}),
+	}
+	go c.write()
+
+	var req subscribeRequest
+	if err := conn.ReadJSON(&req); err != nil {
+		c.close()
+		return
+	}
+
+	if reason := f.subscribe(c, req); reason != "" {
+		f.mu.Lock()
+		f.conns[c] = struct{}{}
+		f.mu.Unlock()
+
+		c.enqueue(message{Type: "error", Message: "Failed to subscribe", Reason: reason})
+	} else {
+		go f.heartbeat(c)
+	}
+
+	// Drain the connection so closes by the client are noticed.
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			f.remove(c)
+			return
+		}
+	}
+}
+
+// subscribe registers the channels of req and queues the subscriptions message and the initial message of every
+// channel. It returns the reason when the request is rejected.
+func (f *feed) subscribe(c *feedConn, req subscribeRequest) string {
+	if req.Type != "subscribe" {
+		return "Type has to be either subscribe or unsubscribe"
+	}
+
+	if req.Signature != "" {
+		if message := f.server.verify(req.Key, req.Passphrase, req.Timestamp, req.Signature, http.MethodGet, "/users/self/verify", ""); message != "" {
+			return "Authentication Failed"
+		}
+	}
+
+	var topics []subscribedTopic
+	subscribed := make(map[string][]string)
+	for _, raw := range req.Channels {
+		topic := subscribedTopic{ProductIds: req.ProductIds}
+		if err := json.Unmarshal(raw, &topic.Name); err != nil {
+			if err := json.Unmarshal(raw, &topic); err != nil {
+				return "Invalid channels"
+			}
+			if len(topic.ProductIds) == 0 {
+				topic.ProductIds = req.ProductIds
+			}
+		}
+
+		switch topic.Name {
+		case "heartbeat", "ticker", "level2", "status", "matches", "user", "full":
+		default:
+			return topic.Name + " is not a valid channel"
+		}
+
+		if topic.Name != "status" && len(topic.ProductIds) == 0 {
+			return "No product ids provided"
+		}
+
+		e := f.server.exchange
+		e.mu.Lock()
+		for _, id := range topic.ProductIds {
+			if e.product(id) == nil {
+				e.mu.Unlock()
+				return id + " is not a valid product"
+			}
+		}
+		e.mu.Unlock()
+
+		topics = append(topics, topic)
+		subscribed[topic.Name] = topic.ProductIds
+	}
+
+	// Register the connection before the subscriptions message so that no message published after it is missed.
+	f.mu.Lock()
+	c.topics = subscribed
+	f.conns[c] = struct{}{}
+	f.mu.Unlock()
+
+	c.enqueue(message{Type: "subscriptions", Channels: topics})
+
+	for _, topic := range topics {
+		for _, msg := range f.initial(topic) {
+			c.enqueue(msg)
+		}
+	}
+
+	return ""
+}
+
+// initial returns the messages sent when a channel is subscribed.
+func (f *feed) initial(topic subscribedTopic) []message {
+	e := f.server.exchange
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	now := stamp(time.Now())
+
+	var messages []message
+	switch topic.Name {
+	case "status":
+		messages = append(messages, message{Type: "status", Products: e.products, Currencies: e.currencies})
+	case "heartbeat", "ticker", "level2":
+		for _, id := range topic.ProductIds {
+			p := e.product(id)
+			last := e.lastTrade(id)
+			bid, ask := e.top(p)
+
+			switch topic.Name {
+			case "heartbeat":
+				messages = append(messages, message{Type: "heartbeat", Sequence: e.nextSequence(), LastTradeID: last.TradeID, ProductID: id, Time: now})
+			case "ticker":
+				messages = append(messages, message{
+					Type:      "ticker",
+					Sequence:  e.nextSequence(),
+					ProductID: id,
+					Price:     last.Price,
+					BestBid:   formatAmount(bid, 8),
+					BestAsk:   formatAmount(ask, 8),
+					Side:      last.Side,
+					Time:      last.Time,
+					TradeID:   last.TradeID,
+					LastSize:  last.Size,
+				})
+			case "level2":
+				snapshot := message{Type: "snapshot", ProductID: id}
+				for i := 0; i < bookDepth; i++ {
+					snapshot.Bids = append(snapshot.Bids, []string{formatAmount(e.level(p, "buy", i), 8), "1.00000000"})
+					snapshot.Asks = append(snapshot.Asks, []string{formatAmount(e.level(p, "sell", i), 8), "1.00000000"})
+				}
+
+				messages = append(messages, snapshot, message{
+					Type:      "l2update",
+					ProductID: id,
+					Time:      now,
+					Changes:   [][]string{{"buy", formatAmount(bid, 8), "2.00000000"}},
+				})
+			}
+		}
+	}
+
+	return messages
+}
+
+// heartbeat sends a heartbeat for every product of the heartbeat channel each second.
+func (f *feed) heartbeat(c *feedConn) {
+	products, ok := c.topics["heartbeat"]
+	if !ok {
+		return
+	}
+
+	ticker := time.NewTicker(heartbeatInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		}
+
+		e := f.server.exchange
+		e.mu.Lock()
+		for _, id := range products {
+			c.enqueue(message{Type: "heartbeat", Sequence: e.nextSequence(), LastTradeID: e.lastTrade(id).TradeID, ProductID: id, Time: stamp(time.Now())})
+		}
+		e.mu.Unlock()
+	}
+}
+
+// publish queues msg on every connection subscribed to channel, an empty productID matches every subscription.
+func (f *feed) publish(channel, productID string, msg interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for c := range f.conns {
+		products, ok := c.topics[channel]
+		if !ok && channel == "user" {
+			products, ok = c.topics["full"]
+		}
+		if !ok || (productID != "" && channel != "status" && !contains(products, productID)) {
+			continue
+		}
+
+		c.enqueue(msg)
+	}
+}
+
+func (f *feed) remove(c *feedConn) {
+	f.mu.Lock()
+	delete(f.conns, c)
+	f.mu.Unlock()
+
+	c.close()
+}
+
+func (f *feed) disconnect() {
+	f.mu.Lock()
+	conns := f.conns
+	f.conns = make(map[*feedConn]struct{})
+	f.mu.Unlock()
+
+	for c := range conns {
+		c.close()
+	}
+}
+
+func (f *feed) close() {
+	f.disconnect()
+}
+
+// enqueue queues msg for the writer, slow connections which fill the buffer are closed like the exchange does.
+func (c *feedConn) enqueue(msg interface{}) {
+	select {
+	case <-c.done:
+	case c.send <- msg:
+	default:
+		c.close()
+	}
+}
+
+func (c *feedConn) write() {
+	for {
+		select {
+		case <-c.done:
+			return
+		case msg := <-c.send:
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.close()
+				return
+			}
+		}
+	}
+}
+
+func (c *feedConn) close() {
+	c.once.Do(func() {
+		close(c.done)
+		c.conn.Close()
+	})
+}
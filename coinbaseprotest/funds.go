@@ -0,0 +1,364 @@
+package coinbaseprotest
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	transfer struct {
+		ID             string            `json:"id"`
+		Type           string            `json:"type"`
+		CreatedAt      string            `json:"created_at"`
+		ProcessedAt    string            `json:"processed_at,omitempty"`
+		CanceledAt     string            `json:"canceled_at,omitempty"`
+		CompletedAt    string            `json:"completed_at,omitempty"`
+		AccountID      string            `json:"account_id"`
+		UserID         string            `json:"user_id"`
+		ProfileID      string            `json:"profile_id"`
+		Amount         string            `json:"amount"`
+		Currency       string            `json:"currency"`
+		Details        map[string]string `json:"details"`
+		IdempotencyKey string            `json:"idempotency_key,omitempty"`
+
+		sequence int64
+	}
+
+	transferRequest struct {
+		Amount            string `json:"amount"`
+		Currency          string `json:"currency"`
+		PaymentMethodID   string `json:"payment_method_id"`
+		CoinbaseAccountID string `json:"coinbase_account_id"`
+		CryptoAddress     string `json:"crypto_address"`
+		DestinationTag    string `json:"destination_tag"`
+		NoDestinationTag  bool   `json:"no_destination_tag"`
+		Network           string `json:"network"`
+		AddFeeToTotal     bool   `json:"add_network_fee_to_total"`
+		Nonce             int    `json:"nonce"`
+	}
+
+	transferResponse struct {
+		ID       string `json:"id"`
+		Amount   string `json:"amount"`
+		Currency string `json:"currency"`
+		PayoutAt string `json:"payout_at,omitempty"`
+		Fee      string `json:"fee,omitempty"`
+		Subtotal string `json:"subtotal,omitempty"`
+		Network  string `json:"network,omitempty"`
+	}
+)
+
+// chains describes the networks of the crypto currencies, fee is the network fee of withdrawals and tag is set for
+// networks which need a destination tag.
+var chains = map[string]struct {
+	network string
+	fee     string
+	tag     bool
+}{
+	"BTC":  {network: "bitcoin", fee: "0.0001"},
+	"ETH":  {network: "ethereum", fee: "0.001"},
+	"USDC": {network: "ethereum", fee: "1.00"},
+	"XRP":  {network: "ripple", fee: "0.01", tag: true},
+}
+
+func (e *exchange) getPaymentMethods(w http.ResponseWriter, r *request) {
+	writeJSON(w, http.StatusOK, e.paymentMethods)
+}
+
+func (e *exchange) paymentMethod(id string) *paymentMethod {
+	for i := range e.paymentMethods {
+		if e.paymentMethods[i].ID == id {
+			return &e.paymentMethods[i]
+		}
+	}
+
+	return nil
+}
+
+// move credits, or for withdrawals debits, the default account of currency and records the transfer. It writes
+// an error and returns nil when the request is invalid.
+func (e *exchange) move(w http.ResponseWriter, req transferRequest, transferType string, details map[string]string) *transfer {
+	a := e.account(e.defaultProfile().ID, req.Currency)
+	if a == nil {
+		writeError(w, http.StatusBadRequest, "Currency not found")
+		return nil
+	}
+
+	amount, ok := parseAmount(req.Amount)
+	if !ok || amount.Sign() <= 0 {
+		writeError(w, http.StatusBadRequest, "Invalid amount")
+		return nil
+	}
+
+	if transferType == "withdraw" {
+		if e.available(a).Cmp(amount) < 0 {
+			writeError(w, http.StatusBadRequest, "Insufficient funds")
+			return nil
+		}
+		amount = new(big.Rat).Neg(amount)
+	}
+
+	return e.transfer(a, amount, transferType, details)
+}
+
+// transfer credits amount to a, which is negative for withdrawals, and records a completed transfer.
+func (e *exchange) transfer(a *account, amount *big.Rat, transferType string, details map[string]string) *transfer {
+	now := stamp(time.Now())
+	t := &transfer{
+		ID:          newID(),
+		Type:        transferType,
+		CreatedAt:   now,
+		ProcessedAt: now,
+		CompletedAt: now,
+		AccountID:   a.id,
+		UserID:      e.userID,
+		ProfileID:   a.profileID,
+		Amount:      formatAmount(new(big.Rat).Abs(amount), 8),
+		Currency:    a.currency,
+		Details:     details,
+		sequence:    e.nextSequence(),
+	}
+	e.transfers = append(e.transfers, t)
+	e.credit(a, amount, "transfer", ledgerDetails{TransferID: t.ID, TransferType: transferType})
+
+	return t
+}
+
+func (e *exchange) listTransfers(w http.ResponseWriter, r *request) {
+	query := r.URL.Query()
+
+	var (
+		transfers []*transfer
+		cursors   []int64
+	)
+	for i := len(e.transfers) - 1; i >= 0; i-- {
+		t := e.transfers[i]
+		if v := query.Get("type"); v != "" && t.Type != v {
+			continue
+		}
+		if v := query.Get("profile_id"); v != "" && t.ProfileID != v {
+			continue
+		}
+
+		transfers = append(transfers, t)
+		cursors = append(cursors, t.sequence)
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]*transfer{}, transfers[from:to]...))
+}
+
+// getTransfer returns a transfer, a pending transfer is processed once its status has been requested and completed
+// once it has been requested while processing.
+func (e *exchange) getTransfer(w http.ResponseWriter, r *request) {
+	for _, t := range e.transfers {
+		if t.ID != r.args[0] {
+			continue
+		}
+
+		response := *t
+		switch {
+		case t.ProcessedAt == "":
+			t.ProcessedAt = stamp(time.Now())
+			t.Details = copyDetails(t.Details)
+			t.Details["crypto_transaction_hash"] = strings.ReplaceAll(newID()+newID(), "-", "")
+		case t.CompletedAt == "":
+			t.CompletedAt = stamp(time.Now())
+		}
+
+		writeJSON(w, http.StatusOK, response)
+		return
+	}
+
+	writeError(w, http.StatusNotFound, "NotFound")
+}
+
+func copyDetails(details map[string]string) map[string]string {
+	copied := make(map[string]string, len(details))
+	for k, v := range details {
+		copied[k] = v
+	}
+
+	return copied
+}
+
+func (e *exchange) createDeposit(w http.ResponseWriter, r *request) {
+	var req transferRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if m := e.paymentMethod(req.PaymentMethodID); m == nil || !m.AllowDeposit {
+		writeError(w, http.StatusBadRequest, "Invalid payment method")
+		return
+	}
+
+	t := e.move(w, req, "deposit", map[string]string{"payment_method_id": req.PaymentMethodID})
+	if t == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transferResponse{ID: t.ID, Amount: t.Amount, Currency: req.Currency, PayoutAt: t.CompletedAt})
+}
+
+func (e *exchange) createWithdrawalPaymentMethod(w http.ResponseWriter, r *request) {
+	var req transferRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if m := e.paymentMethod(req.PaymentMethodID); m == nil || !m.AllowWithdraw {
+		writeError(w, http.StatusBadRequest, "Invalid payment method")
+		return
+	}
+
+	t := e.move(w, req, "withdraw", map[string]string{"payment_method_id": req.PaymentMethodID})
+	if t == nil {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transferResponse{ID: t.ID, Amount: t.Amount, Currency: req.Currency, PayoutAt: t.CompletedAt, Fee: "0.00", Subtotal: t.Amount})
+}
+
+func (e *exchange) createWithdrawalCoinbase(w http.ResponseWriter, r *request) {
+	var req transferRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	destination := e.coinbaseAccount(req.CoinbaseAccountID)
+	if destination == nil || destination.currency != req.Currency {
+		writeError(w, http.StatusBadRequest, "Coinbase account not found")
+		return
+	}
+
+	t := e.move(w, req, "withdraw", map[string]string{"coinbase_account_id": req.CoinbaseAccountID})
+	if t == nil {
+		return
+	}
+	destination.balance.Add(destination.balance, mustRat(t.Amount))
+
+	writeJSON(w, http.StatusOK, transferResponse{ID: t.ID, Amount: t.Amount, Currency: req.Currency})
+}
+
+func (e *exchange) createWithdrawalCrypto(w http.ResponseWriter, r *request) {
+	var req transferRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if req.Nonce != 0 {
+		for _, t := range e.transfers {
+			if t.Type == "withdraw" && t.IdempotencyKey == strconv.Itoa(req.Nonce) {
+				writeJSON(w, http.StatusOK, cryptoWithdrawalResponse(t))
+				return
+			}
+		}
+	}
+
+	c, ok := chains[req.Currency]
+	switch {
+	case !ok:
+		writeError(w, http.StatusBadRequest, "Currency not found")
+		return
+	case req.CryptoAddress == "":
+		writeError(w, http.StatusBadRequest, "crypto_address is required")
+		return
+	case req.Network != "" && req.Network != c.network:
+		writeError(w, http.StatusBadRequest, "Invalid network")
+		return
+	case c.tag && req.DestinationTag == "" && !req.NoDestinationTag:
+		writeError(w, http.StatusBadRequest, "destination_tag is required, set no_destination_tag to send without one")
+		return
+	}
+
+	fee := mustRat(c.fee)
+	amount, ok := parseAmount(req.Amount)
+	if ok && req.AddFeeToTotal {
+		req.Amount = formatAmount(new(big.Rat).Add(amount, fee), 8)
+	}
+
+	details := map[string]string{"crypto_address": req.CryptoAddress, "network": c.network, "fee": c.fee}
+	if req.DestinationTag != "" {
+		details["destination_tag"] = req.DestinationTag
+	}
+
+	t := e.move(w, req, "withdraw", details)
+	if t == nil {
+		return
+	}
+
+	// crypto withdrawals are broadcast and confirmed while they are polled, see getTransfer
+	t.ProcessedAt, t.CompletedAt = "", ""
+	t.Details["subtotal"] = formatAmount(new(big.Rat).Sub(mustRat(t.Amount), fee), 8)
+	if req.Nonce != 0 {
+		t.IdempotencyKey = strconv.Itoa(req.Nonce)
+	}
+
+	writeJSON(w, http.StatusOK, cryptoWithdrawalResponse(t))
+}
+
+func cryptoWithdrawalResponse(t *transfer) interface{} {
+	return struct {
+		transferResponse
+		CryptoAddress  string `json:"crypto_address"`
+		DestinationTag string `json:"destination_tag,omitempty"`
+	}{
+		transferResponse{
+			ID:       t.ID,
+			Amount:   t.Amount,
+			Currency: t.Currency,
+			PayoutAt: t.CreatedAt,
+			Fee:      t.Details["fee"],
+			Subtotal: t.Details["subtotal"],
+			Network:  t.Details["network"],
+		},
+		t.Details["crypto_address"],
+		t.Details["destination_tag"],
+	}
+}
+
+func (e *exchange) getWithdrawalFeeEstimate(w http.ResponseWriter, r *request) {
+	query := r.URL.Query()
+
+	c, ok := chains[query.Get("currency")]
+	switch {
+	case !ok:
+		writeError(w, http.StatusBadRequest, "Currency not found")
+		return
+	case query.Get("crypto_address") == "":
+		writeError(w, http.StatusBadRequest, "crypto_address is required")
+		return
+	case query.Get("network") != "" && query.Get("network") != c.network:
+		writeError(w, http.StatusBadRequest, "Invalid network")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"fee": c.fee, "fee_before_subsidy": c.fee})
+}
+
+// createTransfer serves the legacy transfers endpoint, the request is acknowledged without moving funds.
+func (e *exchange) createTransfer(w http.ResponseWriter, r *request) {
+	var req struct {
+		Type   string `json:"type"`
+		Amount string `json:"amount"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	if req.Type != "deposit" && req.Type != "withdraw" {
+		writeError(w, http.StatusBadRequest, "Invalid type")
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(r.body)
+}
@@ -0,0 +1,229 @@
+package coinbaseprotest
+
+import (
+	"math/big"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const bookDepth = 5
+
+// top returns the best bid and ask of a product, the simulated book is one increment either side of its price.
+func (e *exchange) top(p *product) (*big.Rat, *big.Rat) {
+	price, increment := e.prices[p.ID], mustRat(p.QuoteIncrement)
+
+	return new(big.Rat).Sub(price, increment), new(big.Rat).Add(price, increment)
+}
+
+// level returns the price of level i of a side of the book, level 0 being the best price.
+func (e *exchange) level(p *product, side string, i int) *big.Rat {
+	bid, ask := e.top(p)
+	step := new(big.Rat).Mul(mustRat(p.QuoteIncrement), big.NewRat(int64(i), 1))
+
+	if side == "buy" {
+		return bid.Sub(bid, step)
+	}
+
+	return ask.Add(ask, step)
+}
+
+func (e *exchange) lastTrade(productID string) trade {
+	trades := e.trades[productID]
+	return trades[len(trades)-1]
+}
+
+func (e *exchange) findProduct(w http.ResponseWriter, id string) *product {
+	p := e.product(id)
+	if p == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+	}
+
+	return p
+}
+
+func (e *exchange) getProducts(w http.ResponseWriter, r *request) {
+	writeJSON(w, http.StatusOK, e.products)
+}
+
+func (e *exchange) getProduct(w http.ResponseWriter, r *request) {
+	if p := e.findProduct(w, r.args[0]); p != nil {
+		writeJSON(w, http.StatusOK, p)
+	}
+}
+
+func (e *exchange) getBook(w http.ResponseWriter, r *request) {
+	p := e.findProduct(w, r.args[0])
+	if p == nil {
+		return
+	}
+
+	level := 1
+	if v := r.URL.Query().Get("level"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > 3 {
+			writeError(w, http.StatusBadRequest, "Invalid level")
+			return
+		}
+		level = l
+	}
+
+	depth := bookDepth
+	if level == 1 {
+		depth = 1
+	}
+
+	side := func(side string) [][]interface{} {
+		var entries [][]interface{}
+		for i := 0; i < depth; i++ {
+			price := formatAmount(e.level(p, side, i), 8)
+			if level == 3 {
+				entries = append(entries, []interface{}{price, "0.50000000", newID()}, []interface{}{price, "0.50000000", newID()})
+				continue
+			}
+			entries = append(entries, []interface{}{price, "1.00000000", 2})
+		}
+
+		return entries
+	}
+
+	writeJSON(w, http.StatusOK, struct {
+		Sequence int64           `json:"sequence"`
+		Bids     [][]interface{} `json:"bids"`
+		Asks     [][]interface{} `json:"asks"`
+	}{e.nextSequence(), side("buy"), side("sell")})
+}
+
+func (e *exchange) getTicker(w http.ResponseWriter, r *request) {
+	p := e.findProduct(w, r.args[0])
+	if p == nil {
+		return
+	}
+
+	bid, ask := e.top(p)
+	last := e.lastTrade(p.ID)
+
+	writeJSON(w, http.StatusOK, struct {
+		TradeID int    `json:"trade_id"`
+		Price   string `json:"price"`
+		Size    string `json:"size"`
+		Time    string `json:"time"`
+		Bid     string `json:"bid"`
+		Ask     string `json:"ask"`
+		Volume  string `json:"volume"`
+	}{last.TradeID, last.Price, last.Size, last.Time, formatAmount(bid, 8), formatAmount(ask, 8), e.volume(p.ID)})
+}
+
+func (e *exchange) volume(productID string) string {
+	volume := new(big.Rat)
+	for _, t := range e.trades[productID] {
+		volume.Add(volume, mustRat(t.Size))
+	}
+
+	return formatAmount(volume, 8)
+}
+
+func (e *exchange) listTrades(w http.ResponseWriter, r *request) {
+	p := e.findProduct(w, r.args[0])
+	if p == nil {
+		return
+	}
+
+	var (
+		trades  []trade
+		cursors []int64
+	)
+	for i := len(e.trades[p.ID]) - 1; i >= 0; i-- {
+		t := e.trades[p.ID][i]
+		trades = append(trades, t)
+		cursors = append(cursors, int64(t.TradeID))
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]trade{}, trades[from:to]...))
+}
+
+// getCandles returns flat candles around the price of the product, newest first.
+func (e *exchange) getCandles(w http.ResponseWriter, r *request) {
+	p := e.findProduct(w, r.args[0])
+	if p == nil {
+		return
+	}
+
+	query := r.URL.Query()
+
+	granularity := 60
+	if v := query.Get("granularity"); v != "" {
+		granularity, _ = strconv.Atoi(v)
+	}
+	switch granularity {
+	case 60, 300, 900, 3600, 21600, 86400:
+	default:
+		writeError(w, http.StatusBadRequest, "Unsupported granularity")
+		return
+	}
+	bucket := time.Duration(granularity) * time.Second
+
+	end := time.Now()
+	if v := query.Get("end"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid end")
+			return
+		}
+		end = t
+	}
+
+	start := end.Add(-300 * bucket)
+	if v := query.Get("start"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "Invalid start")
+			return
+		}
+		start = t
+	}
+
+	if end.Sub(start)/bucket > 300 {
+		writeError(w, http.StatusBadRequest, "granularity too small for the requested time range. Count of aggregations requested exceeds 300")
+		return
+	}
+
+	price, _ := e.prices[p.ID].Float64()
+
+	candles := [][]float64{}
+	for t := end.Truncate(bucket); !t.Before(start) && len(candles) < 300; t = t.Add(-bucket) {
+		candles = append(candles, []float64{float64(t.Unix()), price * 0.99, price * 1.01, price, price, 10})
+	}
+
+	writeJSON(w, http.StatusOK, candles)
+}
+
+func (e *exchange) getStats(w http.ResponseWriter, r *request) {
+	p := e.findProduct(w, r.args[0])
+	if p == nil {
+		return
+	}
+
+	price := e.prices[p.ID]
+
+	writeJSON(w, http.StatusOK, struct {
+		Open        string `json:"open"`
+		High        string `json:"high"`
+		Low         string `json:"low"`
+		Last        string `json:"last"`
+		Volume      string `json:"volume"`
+		Volume30Day string `json:"volume_30day"`
+	}{
+		Open:        formatAmount(price, 8),
+		High:        formatAmount(new(big.Rat).Mul(price, mustRat("1.01")), 8),
+		Low:         formatAmount(new(big.Rat).Mul(price, mustRat("0.99")), 8),
+		Last:        e.lastTrade(p.ID).Price,
+		Volume:      e.volume(p.ID),
+		Volume30Day: e.volume(p.ID),
+	})
+}
@@ -0,0 +1,673 @@
+package coinbaseprotest
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// order is an order of the simulated exchange. Limit orders which cross the simulated book are filled completely
+// as taker at the best price, other limit orders rest until they are cancelled, expire or are filled with
+// Server.FillOrder. Market orders always fill immediately.
+type order struct {
+	id          string
+	clientOID   string
+	profileID   string
+	productID   string
+	side        string
+	orderType   string
+	stp         string
+	stop        string
+	timeInForce string
+	postOnly    bool
+
+	price     *big.Rat
+	size      *big.Rat
+	funds     *big.Rat
+	stopPrice *big.Rat
+
+	status        string
+	doneReason    string
+	rejectReason  string
+	filled        *big.Rat
+	executedValue *big.Rat
+	fees          *big.Rat
+
+	hold        *big.Rat
+	holdAccount *account
+	holdID      string
+
+	createdAt  time.Time
+	updatedAt  time.Time
+	doneAt     time.Time
+	expireTime time.Time
+	sequence   int64
+}
+
+type createOrderRequest struct {
+	Type        string `json:"type"`
+	Side        string `json:"side"`
+	ProductID   string `json:"product_id"`
+	ClientOID   string `json:"client_oid"`
+	Stp         string `json:"stp"`
+	Stop        string `json:"stop"`
+	StopPrice   string `json:"stop_price"`
+	Size        string `json:"size"`
+	Price       string `json:"price"`
+	TimeInForce string `json:"time_in_force"`
+	PostOnly    bool   `json:"post_only"`
+	CancelAfter string `json:"cancel_after"`
+	Funds       string `json:"funds"`
+	ProfileID   string `json:"profile_id"`
+}
+
+type orderResponse struct {
+	ID             string `json:"id"`
+	ClientOID      string `json:"client_oid,omitempty"`
+	ProfileID      string `json:"profile_id"`
+	ProductID      string `json:"product_id"`
+	Side           string `json:"side"`
+	Type           string `json:"type"`
+	Price          string `json:"price,omitempty"`
+	Size           string `json:"size,omitempty"`
+	Funds          string `json:"funds,omitempty"`
+	SpecifiedFunds string `json:"specified_funds,omitempty"`
+	Stp            string `json:"stp,omitempty"`
+	Stop           string `json:"stop,omitempty"`
+	StopPrice      string `json:"stop_price,omitempty"`
+	TimeInForce    string `json:"time_in_force,omitempty"`
+	ExpireTime     string `json:"expire_time,omitempty"`
+	PostOnly       bool   `json:"post_only"`
+	CreatedAt      string `json:"created_at"`
+	DoneAt         string `json:"done_at,omitempty"`
+	DoneReason     string `json:"done_reason,omitempty"`
+	RejectReason   string `json:"reject_reason,omitempty"`
+	FillFees       string `json:"fill_fees"`
+	FilledSize     string `json:"filled_size"`
+	ExecutedValue  string `json:"executed_value"`
+	Status         string `json:"status"`
+	Settled        bool   `json:"settled"`
+}
+
+var cancelAfter = map[string]time.Duration{
+	"min":  time.Minute,
+	"hour": time.Hour,
+	"day":  24 * time.Hour,
+}
+
+func (o *order) response() orderResponse {
+	res := orderResponse{
+		ID:            o.id,
+		ClientOID:     o.clientOID,
+		ProfileID:     o.profileID,
+		ProductID:     o.productID,
+		Side:          o.side,
+		Type:          o.orderType,
+		Stp:           o.stp,
+		Stop:          o.stop,
+		TimeInForce:   o.timeInForce,
+		PostOnly:      o.postOnly,
+		CreatedAt:     stamp(o.createdAt),
+		DoneReason:    o.doneReason,
+		RejectReason:  o.rejectReason,
+		FillFees:      formatAmount(o.fees, 16),
+		FilledSize:    formatAmount(o.filled, 8),
+		ExecutedValue: formatAmount(o.executedValue, 16),
+		Status:        o.status,
+		Settled:       o.status == "done",
+	}
+
+	if o.price != nil {
+		res.Price = formatAmount(o.price, 8)
+	}
+	if o.size != nil {
+		res.Size = formatAmount(o.size, 8)
+	}
+	if o.funds != nil {
+		res.SpecifiedFunds = formatAmount(o.funds, 16)
+		res.Funds = formatAmount(new(big.Rat).Quo(o.funds, new(big.Rat).Add(big.NewRat(1, 1), mustRat(takerFeeRate))), 16)
+	}
+	if o.stopPrice != nil {
+		res.StopPrice = formatAmount(o.stopPrice, 8)
+	}
+	if !o.expireTime.IsZero() {
+		res.ExpireTime = stamp(o.expireTime)
+	}
+	if !o.doneAt.IsZero() {
+		res.DoneAt = stamp(o.doneAt)
+	}
+
+	return res
+}
+
+func (o *order) remaining() *big.Rat {
+	if o.size == nil {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Sub(o.size, o.filled)
+}
+
+func (o *order) active() bool {
+	return o.status == "open" || o.status == "active" || o.status == "pending"
+}
+
+func (e *exchange) placeOrder(w http.ResponseWriter, r *request) {
+	var req createOrderRequest
+	if !decode(w, r, &req) {
+		return
+	}
+
+	o, message := e.newOrder(req)
+	if message != "" {
+		writeError(w, http.StatusBadRequest, message)
+		return
+	}
+
+	e.submit(o)
+	writeJSON(w, http.StatusOK, o.response())
+}
+
+// newOrder validates req and returns the order or the error message of the exchange.
+func (e *exchange) newOrder(req createOrderRequest) (*order, string) {
+	p := e.product(req.ProductID)
+	if p == nil {
+		return nil, "Product not found"
+	}
+	if req.Side != "buy" && req.Side != "sell" {
+		return nil, "Invalid side"
+	}
+	if req.Type == "" {
+		req.Type = "limit"
+	}
+	if req.Type != "limit" && req.Type != "market" {
+		return nil, "Invalid order_type"
+	}
+	if req.Stp == "" {
+		req.Stp = "dc"
+	}
+	if req.ProfileID == "" {
+		req.ProfileID = e.defaultProfile().ID
+	}
+	if p := e.profile(req.ProfileID); p == nil || !p.Active {
+		return nil, "Profile not found"
+	}
+
+	o := &order{
+		id:            newID(),
+		clientOID:     req.ClientOID,
+		profileID:     req.ProfileID,
+		productID:     p.ID,
+		side:          req.Side,
+		orderType:     req.Type,
+		stp:           req.Stp,
+		stop:          req.Stop,
+		postOnly:      req.PostOnly,
+		filled:        new(big.Rat),
+		executedValue: new(big.Rat),
+		fees:          new(big.Rat),
+		holdID:        newID(),
+	}
+
+	for _, field := range []struct {
+		name  string
+		value string
+		dst   **big.Rat
+	}{
+		{"size", req.Size, &o.size},
+		{"price", req.Price, &o.price},
+		{"funds", req.Funds, &o.funds},
+		{"stop_price", req.StopPrice, &o.stopPrice},
+	} {
+		if field.value == "" {
+			continue
+		}
+
+		v, ok := parseAmount(field.value)
+		if !ok || v.Sign() <= 0 {
+			return nil, "Invalid " + field.name
+		}
+		*field.dst = v
+	}
+
+	switch o.orderType {
+	case "limit":
+		switch {
+		case o.price == nil:
+			return nil, "price is required"
+		case o.size == nil:
+			return nil, "size is required"
+		}
+
+		o.timeInForce = req.TimeInForce
+		switch o.timeInForce {
+		case "":
+			o.timeInForce = "GTC"
+		case "GTC", "IOC", "FOK":
+		case "GTT":
+			d, ok := cancelAfter[req.CancelAfter]
+			if !ok {
+				return nil, "Invalid cancel_after"
+			}
+			o.expireTime = time.Now().Add(d)
+		default:
+			return nil, "Invalid time_in_force"
+		}
+
+		if o.postOnly && (o.timeInForce == "IOC" || o.timeInForce == "FOK") {
+			return nil, "Post only is only valid with GTC and GTT"
+		}
+	case "market":
+		if o.size == nil && o.funds == nil {
+			return nil, "size or funds is required"
+		}
+		if o.postOnly {
+			return nil, "Post only is not valid for market orders"
+		}
+	}
+
+	if o.size != nil {
+		if o.size.Cmp(mustRat(p.BaseMinSize)) < 0 {
+			return nil, "size is too small. Minimum size is " + p.BaseMinSize
+		}
+		if o.size.Cmp(mustRat(p.BaseMaxSize)) > 0 {
+			return nil, "size is too large. Maximum size is " + p.BaseMaxSize
+		}
+	}
+
+	switch o.stop {
+	case "":
+	case "loss", "entry":
+		if o.stopPrice == nil {
+			return nil, "stop_price is required"
+		}
+	default:
+		return nil, "Invalid stop"
+	}
+
+	base, quote := e.account(o.profileID, p.BaseCurrency), e.account(o.profileID, p.QuoteCurrency)
+	bid, ask := e.top(p)
+	takerFee := new(big.Rat).Add(big.NewRat(1, 1), mustRat(takerFeeRate))
+
+	var (
+		needed  *big.Rat
+		account = quote
+	)
+	switch {
+	case o.side == "buy" && o.orderType == "limit":
+		needed = new(big.Rat).Mul(new(big.Rat).Mul(o.price, o.size), takerFee)
+	case o.side == "buy" && o.size != nil:
+		needed = new(big.Rat).Mul(new(big.Rat).Mul(ask, o.size), takerFee)
+	case o.side == "buy":
+		needed = o.funds
+	case o.size != nil:
+		needed, account = o.size, base
+	default:
+		needed, account = new(big.Rat).Quo(o.funds, bid), base
+	}
+
+	if e.available(account).Cmp(needed) < 0 {
+		return nil, "Insufficient funds"
+	}
+
+	return o, ""
+}
+
+// submit places a validated order on the simulated book.
+func (e *exchange) submit(o *order) {
+	p := e.product(o.productID)
+	bid, ask := e.top(p)
+
+	price := ask
+	if o.side == "sell" {
+		price = bid
+	}
+
+	o.createdAt = time.Now()
+	o.updatedAt = o.createdAt
+	o.sequence = e.nextSequence()
+
+	crosses := o.orderType == "market" || (o.side == "buy" && o.price.Cmp(ask) >= 0) || (o.side == "sell" && o.price.Cmp(bid) <= 0)
+	if o.stop == "" && crosses && o.postOnly {
+		o.status = "rejected"
+		o.rejectReason = "post only"
+		e.orders = append(e.orders, o)
+		return
+	}
+
+	o.status = "pending"
+	e.orders = append(e.orders, o)
+	e.publishOrder("received", o, "")
+
+	switch {
+	case o.stop != "":
+		o.status = "active"
+		e.rest(o)
+	case crosses:
+		size := o.size
+		if size == nil {
+			takerFee := new(big.Rat).Add(big.NewRat(1, 1), mustRat(takerFeeRate))
+			if o.side == "buy" {
+				size = truncate(new(big.Rat).Quo(o.funds, new(big.Rat).Mul(price, takerFee)), 8)
+			} else {
+				size = truncate(new(big.Rat).Quo(o.funds, price), 8)
+			}
+		}
+
+		e.fill(o, size, price, "T")
+		e.done(o, "filled")
+	case o.timeInForce == "IOC" || o.timeInForce == "FOK":
+		e.done(o, "canceled")
+	default:
+		o.status = "open"
+		e.rest(o)
+		e.publishOrder("open", o, "")
+	}
+}
+
+// rest holds the funds the remainder of an order needs.
+func (e *exchange) rest(o *order) {
+	p := e.product(o.productID)
+
+	if o.side == "buy" {
+		takerFee := new(big.Rat).Add(big.NewRat(1, 1), mustRat(takerFeeRate))
+		o.hold = new(big.Rat).Mul(new(big.Rat).Mul(o.price, o.remaining()), takerFee)
+		o.holdAccount = e.account(o.profileID, p.QuoteCurrency)
+		return
+	}
+
+	o.hold = o.remaining()
+	o.holdAccount = e.account(o.profileID, p.BaseCurrency)
+}
+
+// fill settles a fill of size at price, liquidity is M for maker and T for taker fills.
+func (e *exchange) fill(o *order, size, price *big.Rat, liquidity string) {
+	p := e.product(o.productID)
+	base, quote := e.account(o.profileID, p.BaseCurrency), e.account(o.profileID, p.QuoteCurrency)
+
+	rate := mustRat(takerFeeRate)
+	if liquidity == "M" {
+		rate = mustRat(makerFeeRate)
+	}
+
+	value := new(big.Rat).Mul(size, price)
+	fee := new(big.Rat).Mul(value, rate)
+
+	e.tradeID++
+	details := ledgerDetails{OrderID: o.id, TradeID: strconv.Itoa(e.tradeID), ProductID: p.ID}
+
+	if o.side == "buy" {
+		e.credit(base, size, "match", details)
+		e.credit(quote, new(big.Rat).Neg(value), "match", details)
+	} else {
+		e.credit(base, new(big.Rat).Neg(size), "match", details)
+		e.credit(quote, value, "match", details)
+	}
+	if fee.Sign() > 0 {
+		e.credit(quote, new(big.Rat).Neg(fee), "fee", details)
+	}
+
+	now := time.Now()
+	o.filled.Add(o.filled, size)
+	o.executedValue.Add(o.executedValue, value)
+	o.fees.Add(o.fees, fee)
+	o.updatedAt = now
+
+	e.fills = append(e.fills, fill{
+		TradeID:   e.tradeID,
+		ProductID: p.ID,
+		OrderID:   o.id,
+		UserID:    e.userID,
+		ProfileID: o.profileID,
+		Liquidity: liquidity,
+		Price:     formatAmount(price, 8),
+		Size:      formatAmount(size, 8),
+		Fee:       formatAmount(fee, 16),
+		CreatedAt: stamp(now),
+		Side:      o.side,
+		Settled:   true,
+		USDVolume: formatAmount(value, 16),
+	})
+
+	// The side of a trade is the side of the maker.
+	makerSide, makerOrderID, takerOrderID := o.side, o.id, newID()
+	if liquidity == "T" {
+		makerSide, makerOrderID, takerOrderID = opposite(o.side), newID(), o.id
+	}
+
+	e.trades[p.ID] = append(e.trades[p.ID], trade{
+		Time:    stamp(now),
+		TradeID: e.tradeID,
+		Price:   formatAmount(price, 8),
+		Size:    formatAmount(size, 8),
+		Side:    makerSide,
+	})
+
+	msg := message{
+		Type:         "match",
+		TradeID:      e.tradeID,
+		Sequence:     e.nextSequence(),
+		MakerOrderID: makerOrderID,
+		TakerOrderID: takerOrderID,
+		Time:         stamp(now),
+		ProductID:    p.ID,
+		Size:         formatAmount(size, 8),
+		Price:        formatAmount(price, 8),
+		Side:         makerSide,
+	}
+	e.publish("matches", p.ID, msg)
+
+	msg.UserID, msg.ProfileID = e.userID, o.profileID
+	e.publish("user", p.ID, msg)
+}
+
+// done finishes an order, cancelled orders without fills are removed like the exchange does.
+func (e *exchange) done(o *order, reason string) {
+	o.status = "done"
+	o.doneReason = reason
+	o.doneAt = time.Now()
+	o.updatedAt = o.doneAt
+	o.hold = nil
+
+	e.publishOrder("done", o, reason)
+
+	if reason != "canceled" || o.filled.Sign() > 0 {
+		return
+	}
+
+	for i, other := range e.orders {
+		if other == o {
+			e.orders = append(e.orders[:i], e.orders[i+1:]...)
+			break
+		}
+	}
+}
+
+func (e *exchange) publishOrder(msgType string, o *order, reason string) {
+	msg := message{
+		Type:      msgType,
+		Sequence:  e.nextSequence(),
+		Time:      stamp(time.Now()),
+		ProductID: o.productID,
+		OrderID:   o.id,
+		Side:      o.side,
+		Reason:    reason,
+		UserID:    e.userID,
+		ProfileID: o.profileID,
+	}
+
+	if o.price != nil {
+		msg.Price = formatAmount(o.price, 8)
+	}
+
+	switch msgType {
+	case "received":
+		msg.ClientOID = o.clientOID
+		msg.OrderType = o.orderType
+		if o.size != nil {
+			msg.Size = formatAmount(o.size, 8)
+		}
+		if o.funds != nil {
+			msg.Funds = formatAmount(o.funds, 16)
+		}
+	default:
+		msg.RemainingSize = formatAmount(o.remaining(), 8)
+	}
+
+	e.publish("user", o.productID, msg)
+}
+
+func (e *exchange) expireOrders(now time.Time) {
+	for _, o := range append([]*order(nil), e.orders...) {
+		if o.status == "open" && !o.expireTime.IsZero() && o.expireTime.Before(now) {
+			e.done(o, "canceled")
+		}
+	}
+}
+
+func (e *exchange) fillOrder(id, size string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	o := e.order(id)
+	if o == nil || o.status != "open" {
+		return fmt.Errorf("no open order %s", id)
+	}
+
+	amount := o.remaining()
+	if size != "" {
+		var ok bool
+		if amount, ok = parseAmount(size); !ok || amount.Sign() <= 0 {
+			return fmt.Errorf("invalid size %s", size)
+		}
+		if amount.Cmp(o.remaining()) > 0 {
+			return errors.New("size is larger than the remaining size of the order")
+		}
+	}
+
+	e.fill(o, amount, o.price, "M")
+	if o.remaining().Sign() == 0 {
+		e.done(o, "filled")
+		return nil
+	}
+
+	e.rest(o)
+
+	return nil
+}
+
+// order finds an order by id or, with the client: prefix, by client order id.
+func (e *exchange) order(id string) *order {
+	clientOID := strings.TrimPrefix(id, "client:")
+
+	for _, o := range e.orders {
+		if (clientOID != id && o.clientOID == clientOID) || (clientOID == id && o.id == id) {
+			return o
+		}
+	}
+
+	return nil
+}
+
+func (e *exchange) getOrder(w http.ResponseWriter, r *request) {
+	o := e.order(r.args[0])
+	if o == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, o.response())
+}
+
+func (e *exchange) listOrders(w http.ResponseWriter, r *request) {
+	query := r.URL.Query()
+
+	statuses := query["status"]
+	if len(statuses) == 0 {
+		statuses = []string{"open", "pending", "active"}
+	}
+
+	var (
+		orders  []orderResponse
+		cursors []int64
+	)
+	for i := len(e.orders) - 1; i >= 0; i-- {
+		o := e.orders[i]
+		if productID := query.Get("product_id"); productID != "" && o.productID != productID {
+			continue
+		}
+		if profileID := query.Get("profile_id"); profileID != "" && o.profileID != profileID {
+			continue
+		}
+		if !contains(statuses, "all") && !contains(statuses, o.status) {
+			continue
+		}
+
+		orders = append(orders, o.response())
+		cursors = append(cursors, o.sequence)
+	}
+
+	from, to, ok := paginate(w, r.Request, cursors)
+	if !ok {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, append([]orderResponse{}, orders[from:to]...))
+}
+
+func (e *exchange) cancelOrder(w http.ResponseWriter, r *request) {
+	o := e.order(r.args[0])
+	if o == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+	if productID := r.URL.Query().Get("product_id"); productID != "" && productID != o.productID {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+	if !o.active() {
+		writeError(w, http.StatusBadRequest, "Order already done")
+		return
+	}
+
+	e.done(o, "canceled")
+	writeJSON(w, http.StatusOK, o.id)
+}
+
+func (e *exchange) cancelAllOrders(w http.ResponseWriter, r *request) {
+	productID, profileID := r.URL.Query().Get("product_id"), r.URL.Query().Get("profile_id")
+
+	ids := []string{}
+	for _, o := range append([]*order(nil), e.orders...) {
+		if !o.active() || (productID != "" && o.productID != productID) || (profileID != "" && o.profileID != profileID) {
+			continue
+		}
+
+		e.done(o, "canceled")
+		ids = append(ids, o.id)
+	}
+
+	writeJSON(w, http.StatusOK, ids)
+}
+
+func opposite(side string) string {
+	if side == "buy" {
+		return "sell"
+	}
+
+	return "buy"
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
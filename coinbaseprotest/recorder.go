@@ -0,0 +1,426 @@
+package coinbaseprotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Mode selects whether a Recorder records interactions or replays them from its fixture.
+type Mode int
+
+const (
+	ModeReplay Mode = iota
+	ModeRecord
+)
+
+// Matching selects how replayed requests are matched to recorded interactions.
+type Matching int
+
+const (
+	// MatchStrict replays interactions in recorded order, each request must have the method, path, query and body
+	// of the next interaction.
+	MatchStrict Matching = iota
+	// MatchLenient replays the first unused interaction with the method and path of a request, ignoring query and
+	// body. The last matching interaction is replayed again once all of them are used, which suits polling.
+	MatchLenient
+)
+
+const scrubbed = "[scrubbed]"
+
+type (
+	// Recorder is an http.RoundTripper which records REST interactions to a fixture file and replays them. Use it
+	// with coinbasepro.WithHTTPClient(recorder.Client()).
+	Recorder struct {
+		path           string
+		mode           Mode
+		matching       Matching
+		transport      http.RoundTripper
+		scrubbedHeader map[string]bool
+		scrubbedField  map[string]bool
+
+		mu           sync.Mutex
+		interactions []Interaction
+		used         []bool
+		next         int
+	}
+	RecorderOption func(*Recorder) error
+)
+
+// Interaction is a recorded request and its response.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method string      `json:"method"`
+	Path   string      `json:"path"`
+	Query  string      `json:"query,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body"`
+}
+
+// NewRecorder creates a recorder for the fixture at path, in replay mode the fixture is loaded immediately.
+func NewRecorder(path string, opts ...RecorderOption) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		transport: http.DefaultTransport,
+		scrubbedHeader: map[string]bool{
+			"Cb-Access-Key":        true,
+			"Cb-Access-Passphrase": true,
+			"Cb-Access-Sign":       true,
+			"Cb-Access-Timestamp":  true,
+			"Authorization":        true,
+			"Cookie":               true,
+			"Set-Cookie":           true,
+		},
+		scrubbedField: map[string]bool{
+			"two_factor_code": true,
+			"passphrase":      true,
+			"password":        true,
+			"secret":          true,
+		},
+	}
+
+	for _, opt := range opts {
+		if err := opt(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if r.mode == ModeRecord {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fixture: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.interactions); err != nil {
+		return nil, fmt.Errorf("failed to decode fixture: %w", err)
+	}
+	r.used = make([]bool, len(r.interactions))
+
+	return r, nil
+}
+
+// WithMode sets whether the recorder records or replays, defaults to ModeReplay.
+func WithMode(mode Mode) RecorderOption {
+	return func(r *Recorder) error {
+		if mode != ModeReplay && mode != ModeRecord {
+			return fmt.Errorf("invalid mode %d", mode)
+		}
+		r.mode = mode
+
+		return nil
+	}
+}
+
+// WithMatching sets how requests are matched in replay mode, defaults to MatchStrict.
+func WithMatching(matching Matching) RecorderOption {
+	return func(r *Recorder) error {
+		if matching != MatchStrict && matching != MatchLenient {
+			return fmt.Errorf("invalid matching %d", matching)
+		}
+		r.matching = matching
+
+		return nil
+	}
+}
+
+// WithTransport sets the transport requests are sent with in record mode, defaults to http.DefaultTransport.
+func WithTransport(transport http.RoundTripper) RecorderOption {
+	return func(r *Recorder) error {
+		if transport == nil {
+			return errors.New("transport cannot be nil")
+		}
+		r.transport = transport
+
+		return nil
+	}
+}
+
+// WithScrubbedHeaders scrubs headers in addition to the credential, signature and cookie headers.
+func WithScrubbedHeaders(names ...string) RecorderOption {
+	return func(r *Recorder) error {
+		for _, name := range names {
+			r.scrubbedHeader[http.CanonicalHeaderKey(name)] = true
+		}
+
+		return nil
+	}
+}
+
+// WithScrubbedFields scrubs the values of fields of JSON request bodies in addition to two_factor_code, passphrase,
+// password and secret. Fields are scrubbed at any depth.
+func WithScrubbedFields(names ...string) RecorderOption {
+	return func(r *Recorder) error {
+		for _, name := range names {
+			r.scrubbedField[name] = true
+		}
+
+		return nil
+	}
+}
+
+// Client returns an http.Client using the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip records or replays req.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+	}
+
+	recorded := RecordedRequest{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.RawQuery,
+		Header: r.scrub(req.Header),
+		Body:   r.scrubBody(body),
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, recorded, body)
+	}
+
+	interaction, err := r.match(recorded)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+		StatusCode:    interaction.Response.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        interaction.Response.Header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(interaction.Response.Body)),
+		ContentLength: int64(len(interaction.Response.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Close saves the fixture in record mode. In strict replay mode it returns an error when interactions were not
+// replayed.
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.mode == ModeRecord {
+		return r.save()
+	}
+
+	if r.matching == MatchStrict && r.next < len(r.interactions) {
+		return fmt.Errorf("%d of %d interactions were not replayed", len(r.interactions)-r.next, len(r.interactions))
+	}
+
+	return nil
+}
+
+func (r *Recorder) record(req *http.Request, recorded RecordedRequest, body []byte) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	res, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resBody, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	res.Body = io.NopCloser(bytes.NewReader(resBody))
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			Status: res.StatusCode,
+			Header: r.scrub(res.Header),
+			Body:   string(resBody),
+		},
+	})
+	r.mu.Unlock()
+
+	return res, nil
+}
+
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	if err := os.WriteFile(r.path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Recorder) match(req RecordedRequest) (Interaction, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.matching == MatchStrict {
+		if r.next >= len(r.interactions) {
+			return Interaction{}, fmt.Errorf("unexpected request %s %s, all %d interactions were replayed", req.Method, req.Path, len(r.interactions))
+		}
+
+		interaction := r.interactions[r.next]
+		if err := compare(interaction.Request, req); err != nil {
+			return Interaction{}, fmt.Errorf("request %d %s %s does not match fixture: %w", r.next, req.Method, req.Path, err)
+		}
+		r.next++
+
+		return interaction, nil
+	}
+
+	last := -1
+	for i, interaction := range r.interactions {
+		if interaction.Request.Method != req.Method || interaction.Request.Path != req.Path {
+			continue
+		}
+		if !r.used[i] {
+			r.used[i] = true
+			return interaction, nil
+		}
+		last = i
+	}
+
+	if last < 0 {
+		return Interaction{}, fmt.Errorf("no interaction for request %s %s", req.Method, req.Path)
+	}
+
+	return r.interactions[last], nil
+}
+
+func (r *Recorder) scrub(header http.Header) http.Header {
+	if len(header) == 0 {
+		return nil
+	}
+
+	scrubbedHeader := make(http.Header, len(header))
+	for name, values := range header {
+		if r.scrubbedHeader[http.CanonicalHeaderKey(name)] {
+			scrubbedHeader[name] = []string{scrubbed}
+			continue
+		}
+		scrubbedHeader[name] = append([]string(nil), values...)
+	}
+
+	return scrubbedHeader
+}
+
+// scrubBody replaces the values of scrubbed fields of a JSON body, other bodies are returned as they are.
+func (r *Recorder) scrubBody(body []byte) string {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil || !r.scrubValue(v) {
+		return string(body)
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return string(body)
+	}
+
+	return string(data)
+}
+
+// scrubValue scrubs the fields of v in place and reports whether any field was scrubbed.
+func (r *Recorder) scrubValue(v interface{}) bool {
+	changed := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for name, value := range v {
+			if r.scrubbedField[name] {
+				v[name] = scrubbed
+				changed = true
+				continue
+			}
+			if r.scrubValue(value) {
+				changed = true
+			}
+		}
+	case []interface{}:
+		for _, value := range v {
+			if r.scrubValue(value) {
+				changed = true
+			}
+		}
+	}
+
+	return changed
+}
+
+func compare(recorded, req RecordedRequest) error {
+	if recorded.Method != req.Method || recorded.Path != req.Path {
+		return fmt.Errorf("expected %s %s", recorded.Method, recorded.Path)
+	}
+
+	recordedQuery, err := url.ParseQuery(recorded.Query)
+	if err != nil {
+		return fmt.Errorf("invalid recorded query: %w", err)
+	}
+	query, err := url.ParseQuery(req.Query)
+	if err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	if recordedQuery.Encode() != query.Encode() {
+		return fmt.Errorf("expected query %q, got %q", recorded.Query, req.Query)
+	}
+
+	if !equalBodies(recorded.Body, req.Body) {
+		return fmt.Errorf("expected body %s, got %s", recorded.Body, req.Body)
+	}
+
+	return nil
+}
+
+// equalBodies compares JSON bodies by value so field order does not matter.
+func equalBodies(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var av, bv interface{}
+	if json.Unmarshal([]byte(a), &av) != nil || json.Unmarshal([]byte(b), &bv) != nil {
+		return false
+	}
+
+	return reflect.DeepEqual(av, bv)
+}
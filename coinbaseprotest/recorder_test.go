@@ -0,0 +1,175 @@
+package coinbaseprotest_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/coinbaseprotest"
+)
+
+func TestRecorder(t *testing.T) {
+	server := newServer(t)
+	fixture := filepath.Join(t.TempDir(), "fixtures", "orders.json")
+	ctx := context.Background()
+
+	recorder, err := coinbaseprotest.NewRecorder(fixture, coinbaseprotest.WithMode(coinbaseprotest.ModeRecord))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := coinbasepro.NewClient(server.Key, server.Passphrase, server.Secret,
+		coinbasepro.WithBaseURL(server.URL),
+		coinbasepro.WithHTTPClient(recorder.Client()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "1.00", nil)
+	recorded, err := client.PlaceOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.GetOrderDetail(ctx, recorded.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{server.Key, server.Passphrase, server.Secret} {
+		if strings.Contains(string(data), `"`+secret+`"`) {
+			t.Errorf("fixture contains credential %s", secret)
+		}
+	}
+
+	// The fixture replays without the server and with other credentials.
+	server.Close()
+
+	replay := func(matching coinbaseprotest.Matching) (*coinbaseprotest.Recorder, coinbasepro.Trader) {
+		recorder, err := coinbaseprotest.NewRecorder(fixture, coinbaseprotest.WithMatching(matching))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		client, err := coinbasepro.NewClient("other", "other", "b3RoZXI=",
+			coinbasepro.WithBaseURL(server.URL),
+			coinbasepro.WithHTTPClient(recorder.Client()),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return recorder, client
+	}
+
+	recorder, strict := replay(coinbaseprotest.MatchStrict)
+	replayed, err := strict.PlaceOrder(ctx, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if replayed.ID != recorded.ID {
+		t.Errorf("expected order %s, got %s", recorded.ID, replayed.ID)
+	}
+	if err := recorder.Close(); err == nil {
+		t.Error("expected error for interactions which were not replayed")
+	}
+
+	_, strict = replay(coinbaseprotest.MatchStrict)
+	if _, err := strict.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "101.00", "1.00", nil)); err == nil {
+		t.Error("expected strict matching to reject a different body")
+	}
+
+	recorder, lenient := replay(coinbaseprotest.MatchLenient)
+	for i := 0; i < 2; i++ {
+		if _, err := lenient.GetOrderDetail(ctx, recorded.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRecorderScrubsBodies(t *testing.T) {
+	server := newServer(t)
+	fixture := filepath.Join(t.TempDir(), "withdrawals.json")
+	ctx := context.Background()
+
+	withdraw := func(recorder *coinbaseprotest.Recorder, withdrawal coinbasepro.WithdrawalCrypto) error {
+		client, err := coinbasepro.NewClient(server.Key, server.Passphrase, server.Secret,
+			coinbasepro.WithBaseURL(server.URL),
+			coinbasepro.WithHTTPClient(recorder.Client()),
+		)
+		if err != nil {
+			return err
+		}
+
+		_, err = client.CreateWithdrawalCrypto(ctx, withdrawal)
+		return err
+	}
+
+	recorder, err := coinbaseprotest.NewRecorder(fixture,
+		coinbaseprotest.WithMode(coinbaseprotest.ModeRecord),
+		coinbaseprotest.WithScrubbedFields("crypto_address"),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+	withdrawal := coinbasepro.WithdrawalCrypto{
+		Currency:      "BTC",
+		Amount:        "0.01",
+		CryptoAddress: address,
+		TwoFactorCode: "987654",
+	}
+	if err := withdraw(recorder, withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(fixture)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var interactions []coinbaseprotest.Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		t.Fatal(err)
+	}
+	if len(interactions) != 1 {
+		t.Fatalf("expected 1 interaction, got %d", len(interactions))
+	}
+	body := interactions[0].Request.Body
+	if strings.Contains(body, withdrawal.TwoFactorCode) || strings.Contains(body, address) {
+		t.Errorf("expected the two factor code and address to be scrubbed, got %s", body)
+	}
+	if !strings.Contains(body, `"amount":"0.01"`) {
+		t.Errorf("expected other fields to be kept, got %s", body)
+	}
+
+	// Replayed requests are scrubbed before they are compared, so another code matches.
+	recorder, err = coinbaseprotest.NewRecorder(fixture, coinbaseprotest.WithScrubbedFields("crypto_address"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withdrawal.TwoFactorCode = "123456"
+	if err := withdraw(recorder, withdrawal); err != nil {
+		t.Fatal(err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
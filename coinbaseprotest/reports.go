@@ -0,0 +1,290 @@
+package coinbaseprotest
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math/big"
+	"net/http"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reportFilesPath is the path of the report files, like the presigned URLs of the exchange it is public.
+const reportFilesPath = "/files/reports/"
+
+type (
+	report struct {
+		ID          string       `json:"id"`
+		Type        string       `json:"type"`
+		Status      string       `json:"status"`
+		CreatedAt   string       `json:"created_at"`
+		CompletedAt string       `json:"completed_at,omitempty"`
+		ExpiresAt   string       `json:"expires_at,omitempty"`
+		FileURL     string       `json:"file_url,omitempty"`
+		Params      reportParams `json:"params"`
+
+		start, end time.Time
+		file       []byte
+		sequence   int64
+	}
+
+	reportParams struct {
+		StartDate string `json:"start_date,omitempty"`
+		EndDate   string `json:"end_date,omitempty"`
+		ProductID string `json:"product_id,omitempty"`
+		AccountID string `json:"account_id,omitempty"`
+		ProfileID string `json:"profile_id,omitempty"`
+		Format    string `json:"format,omitempty"`
+		Email     string `json:"email,omitempty"`
+	}
+
+	reportRange struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		ProductID string `json:"product_id"`
+		AccountID string `json:"account_id"`
+	}
+)
+
+// createReport accepts the nested fills and account ranges of the exchange as well as the legacy flat fields.
+func (e *exchange) createReport(w http.ResponseWriter, r *request) {
+	var req struct {
+		reportRange
+		Type      string       `json:"type"`
+		Format    string       `json:"format"`
+		Email     string       `json:"email"`
+		ProfileID string       `json:"profile_id"`
+		Fills     *reportRange `json:"fills"`
+		Account   *reportRange `json:"account"`
+	}
+	if !decode(w, r, &req) {
+		return
+	}
+
+	dates := req.reportRange
+	switch {
+	case req.Type == "fills" && req.Fills != nil:
+		dates = *req.Fills
+	case req.Type == "account" && req.Account != nil:
+		dates = *req.Account
+	}
+
+	if req.Format == "" {
+		req.Format = "pdf"
+	}
+	if req.ProfileID == "" {
+		req.ProfileID = e.defaultProfile().ID
+	}
+
+	switch {
+	case req.Type != "fills" && req.Type != "account":
+		writeError(w, http.StatusBadRequest, "Invalid report type")
+		return
+	case req.Format != "pdf" && req.Format != "csv":
+		writeError(w, http.StatusBadRequest, "Invalid report format")
+		return
+	case e.profile(req.ProfileID) == nil:
+		writeError(w, http.StatusBadRequest, "Profile not found")
+		return
+	case req.Type == "fills" && dates.ProductID == "":
+		writeError(w, http.StatusBadRequest, "product_id is required for fills reports")
+		return
+	case req.Type == "account" && dates.AccountID == "":
+		writeError(w, http.StatusBadRequest, "account_id is required for account reports")
+		return
+	case req.Type == "account" && e.profileAccount(req.ProfileID, dates.AccountID) == nil:
+		writeError(w, http.StatusBadRequest, "Account not found")
+		return
+	}
+
+	rep := &report{
+		ID:        newID(),
+		Type:      req.Type,
+		Status:    "pending",
+		sequence:  e.nextSequence(),
+		CreatedAt: stamp(time.Now()),
+		Params: reportParams{
+			StartDate: dates.StartDate,
+			EndDate:   dates.EndDate,
+			ProductID: dates.ProductID,
+			AccountID: dates.AccountID,
+			ProfileID: req.ProfileID,
+			Format:    req.Format,
+			Email:     req.Email,
+		},
+	}
+
+	var err error
+	if rep.start, err = parseDate(dates.StartDate); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid start_date")
+		return
+	}
+	if rep.end, err = parseDate(dates.EndDate); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid end_date")
+		return
+	}
+
+	e.reports[rep.ID] = rep
+
+	writeJSON(w, http.StatusOK, rep)
+}
+
+// getReport returns a report, a pending report is ready once its status has been requested. The file is generated
+// when the report becomes ready.
+func (e *exchange) getReport(w http.ResponseWriter, r *request) {
+	rep, ok := e.reports[r.args[0]]
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	response := *rep
+
+	if rep.Status == "pending" {
+		now := time.Now()
+		rep.Status = "ready"
+		rep.CompletedAt = stamp(now)
+		rep.ExpiresAt = stamp(now.Add(7 * 24 * time.Hour))
+		rep.FileURL = "http://" + r.Host + reportFilesPath + rep.ID + "." + rep.Params.Format
+		rep.file = e.reportFile(rep)
+	}
+
+	writeJSON(w, http.StatusOK, response)
+}
+
+// listReports returns the reports in the order they were created, after lists the reports created at or after the
+// time.
+func (e *exchange) listReports(w http.ResponseWriter, r *request) {
+	query := r.URL.Query()
+
+	limit := 100
+	if v := query.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 100 {
+			writeError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		limit = n
+	}
+
+	after, err := parseDate(query.Get("after"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid after")
+		return
+	}
+
+	now := time.Now()
+	reports := []*report{}
+	for _, rep := range e.reports {
+		created, _ := time.Parse(timeLayout, rep.CreatedAt)
+		expires, _ := time.Parse(timeLayout, rep.ExpiresAt)
+
+		switch {
+		case query.Get("type") != "" && rep.Type != query.Get("type"):
+		case query.Get("portfolio_id") != "" && rep.Params.ProfileID != query.Get("portfolio_id"):
+		case created.Before(after):
+		case query.Get("ignore_expired") == "true" && rep.ExpiresAt != "" && expires.Before(now):
+		default:
+			reports = append(reports, rep)
+		}
+	}
+
+	// Like a before cursor of the other list endpoints, after returns the page of reports closest to it, newest
+	// first.
+	sort.Slice(reports, func(i, j int) bool { return reports[i].sequence < reports[j].sequence })
+	if len(reports) > limit {
+		reports = reports[:limit]
+	}
+	for i, j := 0, len(reports)-1; i < j; i, j = i+1, j-1 {
+		reports[i], reports[j] = reports[j], reports[i]
+	}
+
+	writeJSON(w, http.StatusOK, reports)
+}
+
+func (e *exchange) getReportFile(w http.ResponseWriter, r *request) {
+	name := r.args[0]
+	rep, ok := e.reports[strings.TrimSuffix(name, path.Ext(name))]
+	if !ok || rep.file == nil {
+		writeError(w, http.StatusNotFound, "NotFound")
+		return
+	}
+
+	if rep.Params.Format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+	} else {
+		w.Header().Set("Content-Type", "application/pdf")
+	}
+	w.Write(rep.file)
+}
+
+// reportFile renders the fills or ledger entries of a report, pdf reports only get a placeholder document.
+func (e *exchange) reportFile(rep *report) []byte {
+	if rep.Params.Format != "csv" {
+		return []byte("%PDF-1.4\n% " + rep.Type + " report " + rep.ID + "\n%%EOF\n")
+	}
+
+	portfolio := e.profile(rep.Params.ProfileID).Name
+
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+
+	if rep.Type == "fills" {
+		out.Write([]string{"portfolio", "trade id", "product", "side", "created at", "size", "size unit", "price", "fee", "total", "price/fee/total unit"})
+		for _, f := range e.fills {
+			if f.ProfileID != rep.Params.ProfileID || (rep.Params.ProductID != "ALL" && f.ProductID != rep.Params.ProductID) || !rep.covers(f.CreatedAt) {
+				continue
+			}
+
+			p := e.product(f.ProductID)
+			total := new(big.Rat).Mul(mustRat(f.Price), mustRat(f.Size))
+			if f.Side == "buy" {
+				total.Neg(total)
+			}
+			total.Sub(total, mustRat(f.Fee))
+
+			out.Write([]string{portfolio, strconv.Itoa(f.TradeID), f.ProductID, strings.ToUpper(f.Side), f.CreatedAt, f.Size, p.BaseCurrency, f.Price, f.Fee, formatAmount(total, 16), p.QuoteCurrency})
+		}
+	} else {
+		a := e.profileAccount(rep.Params.ProfileID, rep.Params.AccountID)
+
+		out.Write([]string{"portfolio", "type", "time", "amount", "balance", "amount/balance unit", "transfer id", "trade id", "order id"})
+		// the ledger is kept newest first, reports list the entries in the order they were made
+		entries := e.ledger[a.id]
+		for i := len(entries) - 1; i >= 0; i-- {
+			entry := entries[i]
+			if !rep.covers(entry.CreatedAt) {
+				continue
+			}
+
+			out.Write([]string{portfolio, entry.Type, entry.CreatedAt, entry.Amount, entry.Balance, a.currency, entry.Details.TransferID, entry.Details.TradeID, entry.Details.OrderID})
+		}
+	}
+
+	out.Flush()
+
+	return buf.Bytes()
+}
+
+// covers reports whether the time of an entry is within the dates of the report.
+func (rep *report) covers(created string) bool {
+	t, err := time.Parse(timeLayout, created)
+	if err != nil {
+		return false
+	}
+
+	return !t.Before(rep.start) && (rep.end.IsZero() || t.Before(rep.end))
+}
+
+func (e *exchange) profileAccount(profileID, id string) *account {
+	for _, a := range e.accounts {
+		if a.id == id && a.profileID == profileID {
+			return a
+		}
+	}
+
+	return nil
+}
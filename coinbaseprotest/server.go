@@ -0,0 +1,357 @@
+// Package coinbaseprotest provides an in-process Coinbase Pro exchange for tests. The server implements the REST
+// endpoints used by coinbasepro and the websocket feed, verifies request signatures the same way the exchange does
+// and can script responses to simulate rate limits, server errors and malformed bodies.
+package coinbaseprotest
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultKey, DefaultPassphrase and DefaultSecret are the credentials accepted by a server created without
+	// WithCredentials.
+	DefaultKey        = "key"
+	DefaultPassphrase = "passphrase"
+	DefaultSecret     = "c2VjcmV0"
+
+	feedPath = "/feed"
+)
+
+type (
+	// Server is a fake exchange listening on a local address. The zero value is not usable, create servers with
+	// NewServer.
+	Server struct {
+		// URL is the base URL of the REST API, WebsocketURL the URL of the websocket feed.
+		URL          string
+		WebsocketURL string
+
+		Key        string
+		Passphrase string
+		Secret     string
+
+		server        *httptest.Server
+		timestampSkew time.Duration
+		exchange      *exchange
+		feed          *feed
+
+		mu       sync.Mutex
+		scripts  []*script
+		handlers map[string]http.HandlerFunc
+		requests []Request
+	}
+	ServerOption func(*Server) error
+)
+
+// Response is a scripted reply to a request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   string
+}
+
+// Request is a request received by the server.
+type Request struct {
+	Method string
+	Path   string
+	Query  string
+	Header http.Header
+	Body   string
+}
+
+type script struct {
+	method    string
+	path      string
+	responses []Response
+}
+
+// NewServer starts a server seeded with products, currencies, two profiles and funded accounts. Close the server
+// when the test is done.
+func NewServer(opts ...ServerOption) (*Server, error) {
+	s := &Server{
+		Key:           DefaultKey,
+		Passphrase:    DefaultPassphrase,
+		Secret:        DefaultSecret,
+		timestampSkew: 30 * time.Second,
+		exchange:      newExchange(),
+		handlers:      make(map[string]http.HandlerFunc),
+	}
+
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+
+	s.feed = newFeed(s)
+	s.exchange.publish = s.feed.publish
+
+	s.server = httptest.NewServer(s)
+	s.URL = s.server.URL
+	s.WebsocketURL = "ws" + strings.TrimPrefix(s.server.URL, "http") + feedPath
+
+	return s, nil
+}
+
+// WithCredentials sets the key, passphrase and base64 encoded secret requests must be signed with.
+func WithCredentials(key, passphrase, secret string) ServerOption {
+	return func(s *Server) error {
+		if _, err := base64.StdEncoding.DecodeString(secret); err != nil {
+			return fmt.Errorf("secret must be base64 encoded: %w", err)
+		}
+
+		s.Key = key
+		s.Passphrase = passphrase
+		s.Secret = secret
+
+		return nil
+	}
+}
+
+// WithBalances replaces the balances of the default profile, keyed by currency.
+func WithBalances(balances map[string]string) ServerOption {
+	return func(s *Server) error {
+		return s.exchange.setBalances(balances)
+	}
+}
+
+// WithPrice sets the price the book and ticker of a product are generated around.
+func WithPrice(productID, price string) ServerOption {
+	return func(s *Server) error {
+		return s.exchange.setPrice(productID, price)
+	}
+}
+
+// WithTimestampSkew sets how far the timestamp of a request may be from the server time, defaults to 30 seconds.
+func WithTimestampSkew(skew time.Duration) ServerOption {
+	return func(s *Server) error {
+		if skew < 0 {
+			return errors.New("skew cannot be less than 0")
+		}
+		s.timestampSkew = skew
+
+		return nil
+	}
+}
+
+// Close disconnects all feed connections and shuts down the server.
+func (s *Server) Close() {
+	s.feed.close()
+	s.server.Close()
+}
+
+// Script queues responses which are served, in order, instead of the simulated exchange to requests matching
+// method and path. An empty method or path matches every request.
+func (s *Server) Script(method, path string, responses ...Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.scripts = append(s.scripts, &script{method: method, path: path, responses: responses})
+}
+
+// Handle replaces the simulated endpoint of method and path with handler.
+func (s *Server) Handle(method, path string, handler http.HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.handlers[method+" "+path] = handler
+}
+
+// Requests returns the requests received so far, including the ones which failed authentication.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]Request(nil), s.requests...)
+}
+
+// FillOrder fills size of a resting order at its limit price as maker.
+func (s *Server) FillOrder(id, size string) error {
+	return s.exchange.fillOrder(id, size)
+}
+
+// Publish sends msg to every feed connection subscribed to channel.
+func (s *Server) Publish(channel string, msg interface{}) {
+	s.feed.publish(channel, "", msg)
+}
+
+// DisconnectFeed closes all websocket connections.
+func (s *Server) DisconnectFeed() {
+	s.feed.disconnect()
+}
+
+// RateLimited is the response of the exchange when the rate limit is exceeded.
+func RateLimited() Response {
+	return Response{Status: http.StatusTooManyRequests, Body: `{"message":"Rate limit exceeded"}`}
+}
+
+// ServerError is an error response with status.
+func ServerError(status int) Response {
+	return Response{Status: status, Body: fmt.Sprintf(`{"message":%q}`, http.StatusText(status))}
+}
+
+// MalformedJSON is a response with status and a truncated JSON body.
+func MalformedJSON(status int) Response {
+	return Response{Status: status, Body: `{"message":"`}
+}
+
+// Signature returns the signature of a request, it is computed the same way as the exchange: the base64 encoded
+// HMAC-SHA256 of timestamp, method, request path and body using the base64 decoded secret.
+func Signature(secret, timestamp, method, requestPath, body string) (string, error) {
+	key, err := base64.StdEncoding.DecodeString(secret)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret: %w", err)
+	}
+
+	signature := hmac.New(sha256.New, key)
+	signature.Write([]byte(timestamp + method + requestPath + body))
+
+	return base64.StdEncoding.EncodeToString(signature.Sum(nil)), nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == feedPath {
+		s.feed.serve(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "failed to read body")
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	s.record(r, body)
+
+	if !isPublic(r.URL.Path) || r.Header.Get("CB-ACCESS-KEY") != "" {
+		if message := s.authenticate(r.Header, r.Method, r.RequestURI, string(body)); message != "" {
+			writeError(w, http.StatusUnauthorized, message)
+			return
+		}
+	}
+
+	if res, ok := s.scripted(r); ok {
+		for k, v := range res.Header {
+			w.Header()[k] = v
+		}
+		if res.Status == 0 {
+			res.Status = http.StatusOK
+		}
+		w.WriteHeader(res.Status)
+		io.WriteString(w, res.Body)
+		return
+	}
+
+	s.mu.Lock()
+	handler, ok := s.handlers[r.Method+" "+r.URL.Path]
+	s.mu.Unlock()
+	if ok {
+		handler(w, r)
+		return
+	}
+
+	s.exchange.serve(w, r, body)
+}
+
+func (s *Server) record(r *http.Request, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, Request{
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Query:  r.URL.RawQuery,
+		Header: r.Header.Clone(),
+		Body:   string(body),
+	})
+}
+
+func (s *Server) scripted(r *http.Request) (Response, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sc := range s.scripts {
+		if len(sc.responses) == 0 {
+			continue
+		}
+		if (sc.method != "" && sc.method != r.Method) || (sc.path != "" && sc.path != r.URL.Path) {
+			continue
+		}
+
+		res := sc.responses[0]
+		sc.responses = sc.responses[1:]
+
+		return res, true
+	}
+
+	return Response{}, false
+}
+
+// authenticate verifies the access headers of a request and returns the error message of the exchange when they
+// are invalid.
+func (s *Server) authenticate(header http.Header, method, requestPath, body string) string {
+	return s.verify(
+		header.Get("CB-ACCESS-KEY"),
+		header.Get("CB-ACCESS-PASSPHRASE"),
+		header.Get("CB-ACCESS-TIMESTAMP"),
+		header.Get("CB-ACCESS-SIGN"),
+		method,
+		requestPath,
+		body,
+	)
+}
+
+func (s *Server) verify(key, passphrase, timestamp, sign, method, requestPath, body string) string {
+	switch {
+	case key == "":
+		return "Unauthorized."
+	case key != s.Key:
+		return "Invalid API Key"
+	case passphrase != s.Passphrase:
+		return "Invalid Passphrase"
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "invalid timestamp"
+	}
+	if skew := time.Since(time.Unix(seconds, 0)); skew > s.timestampSkew || skew < -s.timestampSkew {
+		return "request timestamp expired"
+	}
+
+	expected, err := Signature(s.Secret, timestamp, method, requestPath, body)
+	if err != nil || !hmac.Equal([]byte(expected), []byte(sign)) {
+		return "invalid signature"
+	}
+
+	return ""
+}
+
+func isPublic(path string) bool {
+	return path == "/time" || path == "/currencies" || path == "/products" || strings.HasPrefix(path, "/products/") ||
+		strings.HasPrefix(path, reportFilesPath)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, struct {
+		Message string `json:"message"`
+	}{message})
+}
@@ -0,0 +1,193 @@
+package coinbaseprotest_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/coinbaseprotest"
+)
+
+func newServer(t *testing.T) *coinbaseprotest.Server {
+	t.Helper()
+
+	server, err := coinbaseprotest.NewServer(coinbaseprotest.WithBalances(map[string]string{"USD": "1000", "BTC": "1"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestServerAuthentication(t *testing.T) {
+	server := newServer(t)
+	ctx := context.Background()
+
+	client, err := coinbasepro.NewClient(server.Key, server.Passphrase, "d3Jvbmc=", coinbasepro.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := client.GetAccounts(ctx); err == nil || err.Error() != "invalid signature" {
+		t.Errorf("expected invalid signature, got %v", err)
+	}
+
+	anonymous, err := coinbasepro.NewAnonymousClient(coinbasepro.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := anonymous.GetProducts(ctx); err != nil {
+		t.Errorf("expected public endpoint to work without credentials, got %v", err)
+	}
+	if _, err := anonymous.GetAccounts(ctx); !errors.Is(err, coinbasepro.ErrUnauthorized) {
+		t.Errorf("expected unauthorized, got %v", err)
+	}
+
+	signature, err := coinbaseprotest.Signature(server.Secret, "1610000000", http.MethodGet, "/accounts", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signature != "Qzw5S2KUx+DLzjbkhAQpGEpi3rqG7sGFqtQ5JPUi8ec=" {
+		t.Errorf("unexpected signature %s", signature)
+	}
+}
+
+func TestServerScript(t *testing.T) {
+	server := newServer(t)
+	ctx := context.Background()
+
+	client, err := coinbasepro.NewClient(server.Key, server.Passphrase, server.Secret, coinbasepro.WithBaseURL(server.URL), coinbasepro.WithRetryCount(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.Script(http.MethodGet, "/time", coinbaseprotest.RateLimited())
+	if _, err := client.GetTime(ctx); err != nil {
+		t.Fatalf("expected rate limited request to be retried, got %v", err)
+	}
+	if requests := server.Requests(); len(requests) != 2 {
+		t.Errorf("expected 2 requests, got %d", len(requests))
+	}
+
+	server.Script("", "", coinbaseprotest.ServerError(http.StatusServiceUnavailable), coinbaseprotest.MalformedJSON(http.StatusOK))
+
+	if _, err := client.GetFees(ctx); err == nil || err.Error() != "Service Unavailable" {
+		t.Errorf("expected service unavailable, got %v", err)
+	}
+	if _, err := client.GetFees(ctx); err == nil || !strings.HasPrefix(err.Error(), "failed to decode response body") {
+		t.Errorf("expected decode error, got %v", err)
+	}
+	if _, err := client.GetFees(ctx); err != nil {
+		t.Errorf("expected script to be exhausted, got %v", err)
+	}
+}
+
+func TestServerOrders(t *testing.T) {
+	server := newServer(t)
+
+	client, err := coinbasepro.NewClient(server.Key, server.Passphrase, server.Secret,
+		coinbasepro.WithBaseURL(server.URL),
+		coinbasepro.WithWebsocketURL(server.WebsocketURL),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	messages := make(chan coinbasepro.Message, 100)
+	go client.Subscribe(ctx, coinbasepro.Message{
+		Type:     "subscribe",
+		Channels: []coinbasepro.MessageChannel{{Name: "user", ProductIds: []string{"BTC-USD"}}},
+	}, func(msg coinbasepro.Message) error {
+		messages <- msg
+		return nil
+	})
+
+	select {
+	case msg := <-messages:
+		if msg.Type != "subscriptions" {
+			t.Fatalf("expected subscriptions, got %s", msg.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected subscriptions")
+	}
+
+	if _, err := client.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "20.00", nil)); !errors.Is(err, coinbasepro.Error{Message: "Insufficient funds"}) {
+		t.Errorf("expected insufficient funds, got %v", err)
+	}
+
+	order, err := client.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "2.00", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != coinbasepro.OrderStatusOpen {
+		t.Errorf("expected open order, got %s", order.Status)
+	}
+
+	if err := server.FillOrder(order.ID, "0.5"); err != nil {
+		t.Fatal(err)
+	}
+
+	var holds []coinbasepro.Hold
+	usd := account(t, client, "USD")
+	if err := client.ListHolds(usd.ID).NextPage(ctx, &holds); err != nil {
+		t.Fatal(err)
+	}
+	if len(holds) != 1 || holds[0].Amount != "150.9000000000000000" || holds[0].Ref != order.ID {
+		t.Errorf("unexpected holds %+v", holds)
+	}
+	if usd.Balance != "949.8000000000000000" || usd.Hold != "150.9000000000000000" {
+		t.Errorf("unexpected USD account %+v", usd)
+	}
+
+	if err := client.CancelOrder(ctx, order.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err = client.GetOrderDetail(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != coinbasepro.OrderStatusDone || order.DoneReason != "canceled" || order.FilledSize != "0.50000000" {
+		t.Errorf("unexpected order %+v", order)
+	}
+
+	var types []string
+	for len(types) < 4 {
+		select {
+		case msg := <-messages:
+			types = append(types, msg.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("expected more messages, got %v", types)
+		}
+	}
+	if strings.Join(types, ",") != "received,open,match,done" {
+		t.Errorf("unexpected messages %v", types)
+	}
+}
+
+func account(t *testing.T, client coinbasepro.Trader, currency string) coinbasepro.Account {
+	t.Helper()
+
+	accounts, err := client.GetAccounts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range accounts {
+		if a.Currency == currency {
+			return a
+		}
+	}
+
+	t.Fatalf("no %s account", currency)
+	return coinbasepro.Account{}
+}
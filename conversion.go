@@ -0,0 +1,45 @@
+package coinbasepro
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// Conversion moves funds between a fiat currency and its stablecoin, like USD and USDC, at a rate of one.
+type Conversion struct {
+	ID            string `json:"id"`
+	Amount        string `json:"amount"`
+	FromAccountID string `json:"from_account_id"`
+	ToAccountID   string `json:"to_account_id"`
+	From          string `json:"from"`
+	To            string `json:"to"`
+	CreatedAt     Time   `json:"created_at,string"`
+}
+
+type createConversionRequest struct {
+	ProfileID string `json:"profile_id,omitempty"`
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Amount    string `json:"amount"`
+}
+
+// CreateConversion converts amount of currency from to currency to, an empty profileID converts in the profile of
+// a client scoped with ForProfile, or the default profile
+func (c *client) CreateConversion(ctx context.Context, from, to, amount, profileID string) (Conversion, error) {
+	var conversion Conversion
+
+	requestURL := fmt.Sprintf("/conversions")
+	_, err := c.Request(ctx, http.MethodPost, requestURL, createConversionRequest{ProfileID: c.profile(profileID), From: from, To: to, Amount: amount}, &conversion)
+	return conversion, err
+}
+
+// GetConversion retrieves a single conversion
+func (c *client) GetConversion(ctx context.Context, id string) (Conversion, error) {
+	var conversion Conversion
+
+	requestURL := fmt.Sprintf("/conversions/%s", url.PathEscape(id))
+	_, err := c.Request(ctx, http.MethodGet, requestURL, nil, &conversion)
+	return conversion, err
+}
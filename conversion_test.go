@@ -0,0 +1,37 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestCreateConversion(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	conversion, err := client.CreateConversion(ctx, "USD", "USDC", "10.00", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conversion.ID == "" || conversion.FromAccountID == "" || conversion.ToAccountID == "" || conversion.CreatedAt.Time().IsZero() {
+		t.Errorf("unexpected conversion %+v", conversion)
+	}
+
+	saved, err := client.GetConversion(ctx, conversion.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID != conversion.ID || saved.Amount != conversion.Amount {
+		t.Errorf("expected conversion %+v, got %+v", conversion, saved)
+	}
+
+	var entries []coinbasepro.LedgerEntry
+	if err := client.ListAccountLedger(conversion.ToAccountID).NextPage(ctx, &entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || !entries[0].IsConversion() || entries[0].Details.ConversionID != conversion.ID {
+		t.Errorf("expected conversion in ledger, got %+v", entries)
+	}
+}
@@ -11,9 +11,13 @@ type Cursor struct {
 	method     string
 	params     interface{}
 	url        string
+	pager      pager
 	HasMore    bool
 }
 
+// pager serves pages without a request, it is used by the paper client. It updates the cursors of p.
+type pager func(ctx context.Context, i interface{}, p *PaginationParams, direction string) error
+
 func (c *client) newCursor(method, url string, paginationParams PaginationParams) *Cursor {
 	return &Cursor{
 		client:     c,
@@ -24,7 +28,28 @@ func (c *client) newCursor(method, url string, paginationParams PaginationParams
 	}
 }
 
+func newPagerCursor(paginationParams PaginationParams, pager pager) *Cursor {
+	return &Cursor{
+		pagination: paginationParams,
+		pager:      pager,
+		HasMore:    true,
+	}
+}
+
 func (c *Cursor) page(ctx context.Context, i interface{}, direction string) error {
+	if c.pager != nil {
+		if err := c.pager(ctx, i, &c.pagination, direction); err != nil {
+			c.HasMore = false
+			return err
+		}
+
+		if c.pagination.Done(direction) {
+			c.HasMore = false
+		}
+
+		return nil
+	}
+
 	url := c.url
 	if c.pagination.Encode(direction) != "" {
 		url = fmt.Sprintf("%s?%s", c.url, c.pagination.Encode(direction))
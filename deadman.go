@@ -0,0 +1,341 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// ErrDeadManSignal is returned by DeadManSwitch.Run after it fired on a signal, so the caller can exit.
+var ErrDeadManSignal = errors.New("dead man switch received signal")
+
+// clock allows tests to control time.
+type clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type DeadManTrigger string
+
+const (
+	TriggerHeartbeat  DeadManTrigger = "heartbeat"
+	TriggerDisconnect DeadManTrigger = "disconnect"
+	TriggerSignal     DeadManTrigger = "signal"
+)
+
+// DeadManSwitch cancels all orders when the application stops sending heartbeats, the websocket feed stays
+// disconnected or the process receives SIGTERM. It only fires while armed and disarms itself after firing.
+type DeadManSwitch struct {
+	client            *client
+	products          []string
+	heartbeatTimeout  time.Duration
+	disconnectTimeout time.Duration
+	retryInterval     time.Duration
+	signals           []os.Signal
+	clock             clock
+	logger            *log.Logger
+
+	mu             sync.Mutex
+	armed          bool
+	lastHeartbeat  time.Time
+	disconnectedAt time.Time
+	retryAt        time.Time
+	changed        chan struct{}
+	handlers       []func(DeadManTrigger, []string)
+}
+
+type DeadManOption func(*DeadManSwitch) error
+
+// WithDeadManProducts limits the cancels to the orders of the products, by default the orders of all products are
+// cancelled.
+func WithDeadManProducts(productIDs ...string) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		d.products = productIDs
+		return nil
+	}
+}
+
+// WithHeartbeatTimeout sets how long the switch waits for a heartbeat before it fires, defaults to thirty seconds.
+// A timeout of zero disables the heartbeat trigger.
+func WithHeartbeatTimeout(timeout time.Duration) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		if timeout < 0 {
+			return errors.New("timeout cannot be negative")
+		}
+		d.heartbeatTimeout = timeout
+
+		return nil
+	}
+}
+
+// WithDisconnectTimeout sets how long the feed can be disconnected before the switch fires, defaults to ten
+// seconds.
+func WithDisconnectTimeout(timeout time.Duration) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be greater than 0")
+		}
+		d.disconnectTimeout = timeout
+
+		return nil
+	}
+}
+
+// WithDeadManSignals sets the signals which fire the switch, defaults to SIGTERM. No signals disables the signal
+// trigger.
+func WithDeadManSignals(signals ...os.Signal) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		d.signals = signals
+		return nil
+	}
+}
+
+// WithDeadManLogger sets the logger of fired switches and cancelled orders, defaults to the standard logger.
+func WithDeadManLogger(logger *log.Logger) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		if logger == nil {
+			return errors.New("logger cannot be nil")
+		}
+		d.logger = logger
+
+		return nil
+	}
+}
+
+// NewDeadManSwitch creates a disarmed switch, call Arm and Run to activate it.
+func NewDeadManSwitch(c *client, opts ...DeadManOption) (*DeadManSwitch, error) {
+	d := &DeadManSwitch{
+		client:            c,
+		heartbeatTimeout:  30 * time.Second,
+		disconnectTimeout: 10 * time.Second,
+		retryInterval:     time.Second,
+		signals:           []os.Signal{syscall.SIGTERM},
+		clock:             systemClock{},
+		logger:            log.Default(),
+		changed:           make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		if err := opt(d); err != nil {
+			return nil, err
+		}
+	}
+
+	return d, nil
+}
+
+// OnFire registers a handler which is called with the trigger and the cancelled order ids every time the switch
+// fires.
+func (d *DeadManSwitch) OnFire(handler func(DeadManTrigger, []string)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.handlers = append(d.handlers, handler)
+}
+
+// Arm activates the switch, the heartbeat timeout starts from now.
+func (d *DeadManSwitch) Arm() {
+	d.update(func() {
+		d.armed = true
+		d.lastHeartbeat = d.clock.Now()
+		d.retryAt = time.Time{}
+	})
+}
+
+// Disarm deactivates the switch, for example while the application has no resting orders.
+func (d *DeadManSwitch) Disarm() {
+	d.update(func() {
+		d.armed = false
+	})
+}
+
+func (d *DeadManSwitch) Armed() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.armed
+}
+
+// Heartbeat signals that the application is alive. It only moves the deadline later, so Run is not woken up.
+func (d *DeadManSwitch) Heartbeat() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lastHeartbeat = d.clock.Now()
+}
+
+// Disconnected marks the feed as disconnected, the switch fires unless Connected is called within the disconnect
+// timeout.
+func (d *DeadManSwitch) Disconnected() {
+	d.update(func() {
+		if d.disconnectedAt.IsZero() {
+			d.disconnectedAt = d.clock.Now()
+		}
+	})
+}
+
+// Connected marks the feed as connected.
+func (d *DeadManSwitch) Connected() {
+	d.update(func() {
+		d.disconnectedAt = time.Time{}
+	})
+}
+
+// HandleMessage marks the feed as connected on every message. It can be used as, or called from, the handler
+// passed to Subscribe.
+func (d *DeadManSwitch) HandleMessage(_ Message) error {
+	d.mu.Lock()
+	connected := d.disconnectedAt.IsZero()
+	d.mu.Unlock()
+
+	if !connected {
+		d.Connected()
+	}
+
+	return nil
+}
+
+func (d *DeadManSwitch) update(f func()) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	f()
+	close(d.changed)
+	d.changed = make(chan struct{})
+}
+
+// Run watches the triggers until ctx is done. After firing on a signal it returns ErrDeadManSignal, the signal is
+// not passed on so the caller is responsible for exiting.
+func (d *DeadManSwitch) Run(ctx context.Context) error {
+	signals := make(chan os.Signal, 1)
+	if len(d.signals) > 0 {
+		signal.Notify(signals, d.signals...)
+		defer signal.Stop(signals)
+	}
+
+	for {
+		d.mu.Lock()
+		trigger, deadline := d.check(d.clock.Now())
+		changed := d.changed
+		d.mu.Unlock()
+
+		if trigger != "" {
+			d.fire(trigger)
+			continue
+		}
+
+		var timer <-chan time.Time
+		if !deadline.IsZero() {
+			timer = d.clock.After(deadline.Sub(d.clock.Now()))
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case s := <-signals:
+			d.fire(TriggerSignal)
+			return fmt.Errorf("%w: %s", ErrDeadManSignal, s)
+		case <-changed:
+		case <-timer:
+		}
+	}
+}
+
+// check returns the trigger which is due at now, or the time the next one is due. It must be called with the lock
+// held.
+func (d *DeadManSwitch) check(now time.Time) (DeadManTrigger, time.Time) {
+	if !d.armed {
+		return "", time.Time{}
+	}
+
+	if now.Before(d.retryAt) {
+		return "", d.retryAt
+	}
+
+	var deadline time.Time
+	if d.heartbeatTimeout > 0 {
+		deadline = d.lastHeartbeat.Add(d.heartbeatTimeout)
+		if !now.Before(deadline) {
+			return TriggerHeartbeat, time.Time{}
+		}
+	}
+
+	if !d.disconnectedAt.IsZero() {
+		disconnectDeadline := d.disconnectedAt.Add(d.disconnectTimeout)
+		if !now.Before(disconnectDeadline) {
+			return TriggerDisconnect, time.Time{}
+		}
+		if deadline.IsZero() || disconnectDeadline.Before(deadline) {
+			deadline = disconnectDeadline
+		}
+	}
+
+	return "", deadline
+}
+
+// fire cancels the orders when the switch is armed. When a cancel fails the switch stays armed and retries after
+// the retry interval.
+func (d *DeadManSwitch) fire(trigger DeadManTrigger) {
+	d.mu.Lock()
+	armed := d.armed
+	d.mu.Unlock()
+
+	if !armed {
+		return
+	}
+
+	// The context of Run may already be done, the orders must be cancelled anyway.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	products := d.products
+	if len(products) == 0 {
+		products = []string{""}
+	}
+
+	var (
+		cancelled []string
+		failed    bool
+	)
+	for _, product := range products {
+		ids, err := d.client.CancelAllOrders(ctx, CancelAllOrdersParams{ProductID: product})
+		if err != nil {
+			d.logger.Printf("dead man switch failed to cancel orders of %q: %s", product, err)
+			failed = true
+			continue
+		}
+		cancelled = append(cancelled, ids...)
+	}
+
+	d.logger.Printf("dead man switch fired on %s, cancelled orders: [%s]", trigger, strings.Join(cancelled, ", "))
+
+	d.mu.Lock()
+	if failed {
+		d.retryAt = d.clock.Now().Add(d.retryInterval)
+	} else {
+		d.armed = false
+	}
+	handlers := d.handlers
+	d.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(trigger, cancelled)
+	}
+}
@@ -0,0 +1,227 @@
+package coinbasepro_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+// manualClock only moves when Advance is called. Like time.After, a timer which is already due fires at once.
+type manualClock struct {
+	mu         sync.Mutex
+	now        time.Time
+	timers     []manualTimer
+	registered chan struct{}
+}
+
+type manualTimer struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- c.now
+		return ch
+	}
+	c.timers = append(c.timers, manualTimer{at: c.now.Add(d), ch: ch})
+
+	select {
+	case c.registered <- struct{}{}:
+	default:
+	}
+
+	return ch
+}
+
+// AwaitTimer waits until a timer is pending, that is until the switch is waiting for its next deadline.
+func (c *manualClock) AwaitTimer(t *testing.T) {
+	t.Helper()
+
+	for {
+		c.mu.Lock()
+		pending := len(c.timers)
+		c.mu.Unlock()
+
+		if pending > 0 {
+			return
+		}
+
+		select {
+		case <-c.registered:
+		case <-time.After(time.Second):
+			t.Fatal("expected a timer to be registered")
+		}
+	}
+}
+
+func (c *manualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.timers[:0]
+	for _, timer := range c.timers {
+		if timer.at.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.timers = pending
+}
+
+// syncBuffer is written by the logger of the switch while the test reads it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+func TestDeadManSwitch(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		products []string
+	)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("expected delete, got %s", r.Method)
+		}
+
+		mu.Lock()
+		products = append(products, r.URL.Query().Get("product_id"))
+		mu.Unlock()
+
+		w.Write([]byte(`["order-1", "order-2"]`))
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	clock := &manualClock{
+		now:        time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC),
+		registered: make(chan struct{}, 1),
+	}
+	logged := &syncBuffer{}
+
+	d, err := coinbasepro.NewDeadManSwitch(client,
+		coinbasepro.WithDeadManProducts("BTC-USD"),
+		coinbasepro.WithHeartbeatTimeout(30*time.Second),
+		coinbasepro.WithDisconnectTimeout(10*time.Second),
+		coinbasepro.WithDeadManSignals(),
+		coinbasepro.WithDeadManClock(clock),
+		coinbasepro.WithDeadManLogger(log.New(logged, "", 0)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fired := make(chan coinbasepro.DeadManTrigger, 1)
+	d.OnFire(func(trigger coinbasepro.DeadManTrigger, ids []string) {
+		if len(ids) != 2 {
+			t.Errorf("expected 2 cancelled orders, got %v", ids)
+		}
+		fired <- trigger
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go d.Run(ctx)
+
+	// A disarmed switch never fires.
+	clock.Advance(time.Minute)
+
+	d.Arm()
+	for i := 0; i < 5; i++ {
+		clock.Advance(20 * time.Second)
+		d.Heartbeat()
+	}
+
+	// The last advance fired the pending timer, once the next one is registered the switch has checked the
+	// heartbeat without firing.
+	clock.AwaitTimer(t)
+	select {
+	case trigger := <-fired:
+		t.Fatalf("unexpected fire on %s", trigger)
+	default:
+	}
+
+	d.Disconnected()
+	clock.Advance(5 * time.Second)
+	d.HandleMessage(coinbasepro.Message{Type: "heartbeat"})
+	d.Heartbeat()
+
+	d.Disconnected()
+	clock.Advance(10 * time.Second)
+
+	select {
+	case trigger := <-fired:
+		if trigger != coinbasepro.TriggerDisconnect {
+			t.Errorf("expected disconnect trigger, got %s", trigger)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected switch to fire")
+	}
+
+	if d.Armed() {
+		t.Error("expected switch to disarm after firing")
+	}
+
+	d.Connected()
+	d.Arm()
+	clock.Advance(30 * time.Second)
+
+	select {
+	case trigger := <-fired:
+		if trigger != coinbasepro.TriggerHeartbeat {
+			t.Errorf("expected heartbeat trigger, got %s", trigger)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected switch to fire")
+	}
+
+	mu.Lock()
+	if fmt.Sprint(products) != "[BTC-USD BTC-USD]" {
+		t.Errorf("expected cancels for BTC-USD, got %v", products)
+	}
+	mu.Unlock()
+
+	lines := strings.Split(strings.TrimSuffix(logged.String(), "\n"), "\n")
+	if len(lines) != 2 || lines[0] != "dead man switch fired on disconnect, cancelled orders: [order-1, order-2]" {
+		t.Errorf("unexpected log %q", lines)
+	}
+}
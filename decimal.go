@@ -0,0 +1,53 @@
+package coinbasepro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// Decimal is an exact decimal amount such as a size or a fee. The API sends amounts as strings, numbers are also
+// accepted and kept as they were sent.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*d = ""
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return fmt.Errorf("not a decimal: %s", data)
+	}
+	*d = Decimal(n)
+
+	return nil
+}
+
+// Rat returns the exact value, ok is false when the amount is empty or not a decimal.
+func (d Decimal) Rat() (r *big.Rat, ok bool) {
+	return decimal.Parse(string(d))
+}
+
+// RatOrZero returns the exact value, an empty or invalid amount is zero.
+func (d Decimal) RatOrZero() *big.Rat {
+	return decimal.OrZero(string(d))
+}
+
+func (d Decimal) String() string {
+	return string(d)
+}
@@ -1,6 +1,9 @@
 package coinbasepro
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
 
 var (
 	ErrNotFound     = Error{Message: "Route not found"}
@@ -16,3 +19,14 @@ type Error struct {
 func (e Error) Error() string {
 	return e.Message
 }
+
+// requestRejected reports whether err is the error response of a request the exchange refused with a 4xx status
+// other than 429. The exchange did not act on such a request, after any other error it may have.
+func requestRejected(res *http.Response, err error) bool {
+	var coinbaseErr Error
+	if res == nil || !errors.As(err, &coinbaseErr) {
+		return false
+	}
+
+	return res.StatusCode >= 400 && res.StatusCode < 500 && res.StatusCode != http.StatusTooManyRequests
+}
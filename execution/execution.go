@@ -0,0 +1,707 @@
+// Package execution slices large parent orders into child orders over time: TWAP and VWAP follow a schedule, an
+// iceberg only shows part of its size on the book at once.
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+var (
+	// ErrCancelled is returned by Run when the execution was cancelled.
+	ErrCancelled = errors.New("execution cancelled")
+	// ErrIncomplete is returned by Run when the execution ended before its size was filled, for example because the
+	// last child timed out, the market was beyond the limit price or the participation cap was reached.
+	ErrIncomplete = errors.New("execution incomplete")
+)
+
+// Exchange is the subset of the client used by the executors.
+type Exchange interface {
+	PlaceOrder(ctx context.Context, newOrder coinbasepro.CreateOrderRequest) (coinbasepro.OrderDetail, error)
+	CancelOrder(ctx context.Context, id string, p ...coinbasepro.CancelOrderParams) error
+	GetOrderDetail(ctx context.Context, id string) (coinbasepro.OrderDetail, error)
+	GetTicker(ctx context.Context, product string) (coinbasepro.Ticker, error)
+	GetHistoricRates(ctx context.Context, product string, p coinbasepro.GetHistoricRatesParams) ([]coinbasepro.HistoricRate, error)
+}
+
+// Clock allows tests to control time.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (systemClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type State string
+
+const (
+	StatePending   State = "pending"
+	StateRunning   State = "running"
+	StatePaused    State = "paused"
+	StateCancelled State = "cancelled"
+	StateCompleted State = "completed"
+	// StateIncomplete executions ended without an error before their size was filled.
+	StateIncomplete State = "incomplete"
+	StateFailed     State = "failed"
+)
+
+// Params are shared by all executors.
+type Params struct {
+	Product coinbasepro.Product
+	Side    coinbasepro.Side
+	// Size is the parent quantity in base currency.
+	Size string
+	// OrderType of the child orders, limit or market, defaults to limit.
+	OrderType coinbasepro.OrderType
+	// LimitPrice is the worst acceptable price: buys are not placed above it and sells not below it. Limit children
+	// are capped at it, market children are skipped while the market is beyond it.
+	LimitPrice string
+	// Passive places limit children at the own side of the book instead of crossing the spread.
+	Passive bool
+	// ParticipationRate caps the executed size to a fraction of the market volume seen by HandleMessage since the
+	// start, zero disables the cap.
+	ParticipationRate float64
+	// ChildTimeout is how long a limit child rests before its remainder is cancelled and carried over. Defaults to
+	// the slice interval for scheduled executors, iceberg children rest until filled.
+	ChildTimeout time.Duration
+	// PollInterval is the interval between order status checks, defaults to one second.
+	PollInterval time.Duration
+}
+
+// Progress is reported after every child order.
+type Progress struct {
+	State     State
+	Size      string
+	Filled    string
+	Remaining string
+	// Scheduled is the size which should have been executed by now according to the schedule.
+	Scheduled    string
+	AveragePrice string
+	Fees         string
+	Orders       int
+}
+
+// Report is the final result of an execution.
+type Report struct {
+	State        State
+	Side         coinbasepro.Side
+	Size         string
+	Filled       string
+	AveragePrice string
+	// ArrivalMid is the mid price when the execution started.
+	ArrivalMid string
+	// Slippage is the difference between the average price and the arrival mid per unit, positive when the
+	// execution was worse than the arrival mid.
+	Slippage    string
+	SlippageBps float64
+	Fees        string
+	Orders      []coinbasepro.OrderDetail
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+type Option func(*Execution) error
+
+func WithClock(clock Clock) Option {
+	return func(e *Execution) error {
+		if clock == nil {
+			return errors.New("clock cannot be nil")
+		}
+		e.clock = clock
+
+		return nil
+	}
+}
+
+// WithProgressHandler registers a handler which is called after every change in progress.
+func WithProgressHandler(handler func(Progress)) Option {
+	return func(e *Execution) error {
+		e.handlers = append(e.handlers, handler)
+		return nil
+	}
+}
+
+// slice is a point of a schedule, by offset the cumulative fraction of the size should be executed.
+type slice struct {
+	offset     time.Duration
+	cumulative *big.Rat
+}
+
+// Execution runs a parent order. Create it with NewTWAP, NewVWAP or NewIceberg and start it with Run.
+type Execution struct {
+	exchange Exchange
+	params   Params
+	clock    Clock
+	handlers []func(Progress)
+
+	// plan returns the schedule of TWAP and VWAP executions, display is the visible size of an iceberg.
+	plan    func(ctx context.Context, start time.Time) ([]slice, time.Duration, error)
+	display *big.Rat
+
+	size      *big.Rat
+	increment *big.Rat
+	minSize   *big.Rat
+	limit     *big.Rat
+
+	mu        sync.Mutex
+	state     State
+	changed   chan struct{}
+	startedAt time.Time
+	volume    *big.Rat
+	scheduled *big.Rat
+	filled    *big.Rat
+	value     *big.Rat
+	fees      *big.Rat
+	orders    []coinbasepro.OrderDetail
+}
+
+func newExecution(exchange Exchange, p Params, opts []Option) (*Execution, error) {
+	if exchange == nil {
+		return nil, errors.New("exchange cannot be nil")
+	}
+
+	if p.OrderType == "" {
+		p.OrderType = coinbasepro.OrderTypeLimit
+	}
+	if p.PollInterval <= 0 {
+		p.PollInterval = time.Second
+	}
+
+	e := &Execution{
+		exchange:  exchange,
+		params:    p,
+		clock:     systemClock{},
+		state:     StatePending,
+		changed:   make(chan struct{}),
+		volume:    new(big.Rat),
+		scheduled: new(big.Rat),
+		filled:    new(big.Rat),
+		value:     new(big.Rat),
+		fees:      new(big.Rat),
+	}
+
+	var ok bool
+	switch {
+	case p.Product.ID == "":
+		return nil, errors.New("product is required")
+	case p.Side != coinbasepro.SideBuy && p.Side != coinbasepro.SideSell:
+		return nil, fmt.Errorf("invalid side %q", p.Side)
+	case p.OrderType != coinbasepro.OrderTypeLimit && p.OrderType != coinbasepro.OrderTypeMarket:
+		return nil, fmt.Errorf("child orders must be limit or market orders, got %s", p.OrderType)
+	case p.ParticipationRate < 0 || p.ParticipationRate > 1:
+		return nil, errors.New("participation rate must be between 0 and 1")
+	}
+
+	if e.size, ok = decimal.Parse(p.Size); !ok || e.size.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid size %q", p.Size)
+	}
+	if p.LimitPrice != "" {
+		if e.limit, ok = decimal.Parse(p.LimitPrice); !ok || e.limit.Sign() <= 0 {
+			return nil, fmt.Errorf("invalid limit price %q", p.LimitPrice)
+		}
+	}
+
+	e.increment, _ = decimal.Parse(p.Product.BaseIncrement)
+	e.minSize, _ = decimal.Parse(p.Product.BaseMinSize)
+
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// HandleMessage counts the traded volume of the product for the participation cap. It can be used as, or called
+// from, the handler passed to Subscribe with the matches channel.
+func (e *Execution) HandleMessage(msg coinbasepro.Message) error {
+	if msg.Type != "match" || msg.ProductID != e.params.Product.ID {
+		return nil
+	}
+
+	size, ok := decimal.Parse(msg.Size)
+	if !ok {
+		return nil
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.state != StatePending {
+		e.volume.Add(e.volume, size)
+	}
+
+	return nil
+}
+
+// Pause cancels the working child order and stops placing new ones until Resume is called.
+func (e *Execution) Pause() {
+	e.setState(StatePaused, StateRunning)
+}
+
+// Resume continues a paused execution, slices which were due while paused are caught up.
+func (e *Execution) Resume() {
+	e.setState(StateRunning, StatePaused)
+}
+
+// Cancel cancels the working child order and stops the execution, Run returns ErrCancelled.
+func (e *Execution) Cancel() {
+	e.setState(StateCancelled, StatePending, StateRunning, StatePaused)
+}
+
+func (e *Execution) setState(state State, from ...State) {
+	e.mu.Lock()
+	changed := false
+	for _, s := range from {
+		if e.state == s {
+			e.state = state
+			close(e.changed)
+			e.changed = make(chan struct{})
+			changed = true
+			break
+		}
+	}
+	e.mu.Unlock()
+
+	if changed {
+		e.emit()
+	}
+}
+
+// Progress returns the current progress of the execution.
+func (e *Execution) Progress() Progress {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return e.progress()
+}
+
+func (e *Execution) progress() Progress {
+	p := Progress{
+		State:     e.state,
+		Size:      decimal.String(e.size),
+		Filled:    decimal.String(e.filled),
+		Remaining: decimal.String(new(big.Rat).Sub(e.size, e.filled)),
+		Scheduled: decimal.String(e.scheduled),
+		Fees:      decimal.String(e.fees),
+		Orders:    len(e.orders),
+	}
+
+	if e.filled.Sign() > 0 {
+		p.AveragePrice = decimal.String(new(big.Rat).Quo(e.value, e.filled))
+	}
+
+	return p
+}
+
+func (e *Execution) emit() {
+	p := e.Progress()
+	for _, handler := range e.handlers {
+		handler(p)
+	}
+}
+
+// Run executes the parent order and blocks until it is completed, cancelled or fails. An execution which ends with a
+// remainder of at least the size increment returns ErrIncomplete. The report is returned in every case and contains
+// the child orders placed so far.
+func (e *Execution) Run(ctx context.Context) (Report, error) {
+	e.mu.Lock()
+	if e.state != StatePending {
+		e.mu.Unlock()
+		return Report{}, errors.New("execution has already been started")
+	}
+	e.state = StateRunning
+	e.startedAt = e.clock.Now()
+	e.mu.Unlock()
+
+	e.emit()
+
+	report := Report{Side: e.params.Side, Size: decimal.String(e.size), StartedAt: e.startedAt}
+
+	ticker, err := e.exchange.GetTicker(ctx, e.params.Product.ID)
+	if err == nil {
+		report.ArrivalMid = decimal.String(mid(ticker))
+		if e.plan != nil {
+			err = e.runSchedule(ctx)
+		} else {
+			err = e.runIceberg(ctx)
+		}
+	}
+
+	e.mu.Lock()
+	switch {
+	case e.state == StateCancelled || errors.Is(err, context.Canceled):
+		e.state = StateCancelled
+		err = ErrCancelled
+	case err != nil:
+		e.state = StateFailed
+	case decimal.Floor(new(big.Rat).Sub(e.size, e.filled), e.increment).Sign() > 0:
+		e.state = StateIncomplete
+		err = fmt.Errorf("%w: filled %s of %s", ErrIncomplete, decimal.String(e.filled), decimal.String(e.size))
+	default:
+		e.state = StateCompleted
+	}
+	e.fillReport(&report)
+	e.mu.Unlock()
+
+	e.emit()
+
+	return report, err
+}
+
+func (e *Execution) fillReport(r *Report) {
+	p := e.progress()
+
+	r.State = e.state
+	r.Filled = p.Filled
+	r.AveragePrice = p.AveragePrice
+	r.Fees = p.Fees
+	r.Orders = append([]coinbasepro.OrderDetail(nil), e.orders...)
+	r.FinishedAt = e.clock.Now()
+
+	arrival, ok := decimal.Parse(r.ArrivalMid)
+	if !ok || arrival.Sign() == 0 || e.filled.Sign() == 0 {
+		return
+	}
+
+	slippage := new(big.Rat).Sub(new(big.Rat).Quo(e.value, e.filled), arrival)
+	if e.params.Side == coinbasepro.SideSell {
+		slippage.Neg(slippage)
+	}
+
+	r.Slippage = decimal.String(slippage)
+	r.SlippageBps, _ = new(big.Rat).Mul(new(big.Rat).Quo(slippage, arrival), big.NewRat(10000, 1)).Float64()
+}
+
+func (e *Execution) runSchedule(ctx context.Context) error {
+	slices, interval, err := e.plan(ctx, e.startedAt)
+	if err != nil {
+		return err
+	}
+
+	timeout := e.params.ChildTimeout
+	if timeout <= 0 {
+		timeout = interval
+	}
+
+	for _, s := range slices {
+		if err := e.waitUntil(ctx, e.startedAt.Add(s.offset)); err != nil {
+			return err
+		}
+
+		target := new(big.Rat).Mul(e.size, s.cumulative)
+
+		e.mu.Lock()
+		e.scheduled.Set(target)
+		qty := e.allowed(new(big.Rat).Sub(target, e.filled))
+		e.mu.Unlock()
+
+		if qty.Sign() <= 0 || (e.minSize != nil && qty.Cmp(e.minSize) < 0) {
+			// Too small for the exchange, carried over to the next slice.
+			continue
+		}
+
+		if err := e.child(ctx, qty, timeout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *Execution) runIceberg(ctx context.Context) error {
+	for {
+		if err := e.waitUntil(ctx, e.clock.Now()); err != nil {
+			return err
+		}
+
+		e.mu.Lock()
+		remaining := new(big.Rat).Sub(e.size, e.filled)
+		e.scheduled.Set(e.size)
+		qty := remaining
+		if qty.Cmp(e.display) > 0 {
+			qty = e.display
+		}
+		qty = e.allowed(qty)
+		e.mu.Unlock()
+
+		remaining = decimal.Floor(remaining, e.increment)
+		if remaining.Sign() <= 0 || (e.minSize != nil && remaining.Cmp(e.minSize) < 0) {
+			return nil
+		}
+
+		if qty.Sign() <= 0 || (e.minSize != nil && qty.Cmp(e.minSize) < 0) {
+			// Wait for more market volume before showing the next slice.
+			if err := e.waitUntil(ctx, e.clock.Now().Add(e.params.PollInterval)); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := e.child(ctx, qty, e.params.ChildTimeout); err != nil {
+			return err
+		}
+	}
+}
+
+// allowed applies the participation cap and the size increment to qty, it must be called with the lock held.
+func (e *Execution) allowed(qty *big.Rat) *big.Rat {
+	if e.params.ParticipationRate > 0 {
+		rate := new(big.Rat)
+		rate.SetFloat64(e.params.ParticipationRate)
+
+		capacity := new(big.Rat).Sub(new(big.Rat).Mul(e.volume, rate), e.filled)
+		if qty.Cmp(capacity) > 0 {
+			qty = capacity
+		}
+	}
+
+	return decimal.Floor(qty, e.increment)
+}
+
+// waitUntil blocks until t while the execution is running, it returns immediately when t has passed.
+func (e *Execution) waitUntil(ctx context.Context, t time.Time) error {
+	for {
+		e.mu.Lock()
+		state, changed := e.state, e.changed
+		e.mu.Unlock()
+
+		var timer <-chan time.Time
+		switch state {
+		case StateCancelled:
+			return ErrCancelled
+		case StateRunning:
+			d := t.Sub(e.clock.Now())
+			if d <= 0 {
+				return nil
+			}
+			timer = e.clock.After(d)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-changed:
+		case <-timer:
+		}
+	}
+}
+
+// child places a child order for qty and waits until it is done, its remainder is cancelled after timeout or when
+// the execution is paused or cancelled.
+func (e *Execution) child(ctx context.Context, qty *big.Rat, timeout time.Duration) error {
+	p := e.params
+
+	size, err := p.Product.RoundSize(decimal.String(qty))
+	if err != nil {
+		return err
+	}
+
+	var newOrder coinbasepro.CreateOrderRequest
+	if p.OrderType == coinbasepro.OrderTypeMarket {
+		ok, err := e.withinLimit(ctx)
+		if err != nil || !ok {
+			return err
+		}
+		newOrder = coinbasepro.NewMarketOrderBySize(p.Product.ID, p.Side, size)
+	} else {
+		price, err := e.price(ctx)
+		if err != nil {
+			return err
+		}
+		newOrder = coinbasepro.NewLimitOrder(p.Product.ID, p.Side, price, size, nil)
+	}
+
+	order, err := e.exchange.PlaceOrder(ctx, newOrder)
+	if err != nil {
+		return fmt.Errorf("failed to place child order: %w", err)
+	}
+
+	e.mu.Lock()
+	index := len(e.orders)
+	e.orders = append(e.orders, order)
+	e.mu.Unlock()
+
+	order, err = e.waitForChild(ctx, order, timeout)
+
+	e.mu.Lock()
+	e.orders[index] = order
+	e.filled.Add(e.filled, order.FilledSize.RatOrZero())
+	e.value.Add(e.value, order.ExecutedValue.RatOrZero())
+	e.fees.Add(e.fees, order.FillFees.RatOrZero())
+	e.mu.Unlock()
+
+	e.emit()
+
+	return err
+}
+
+func (e *Execution) waitForChild(ctx context.Context, order coinbasepro.OrderDetail, timeout time.Duration) (coinbasepro.OrderDetail, error) {
+	var deadline time.Time
+	if timeout > 0 {
+		deadline = e.clock.Now().Add(timeout)
+	}
+
+	for {
+		detail, err := e.exchange.GetOrderDetail(ctx, order.ID)
+		switch {
+		case errors.Is(err, coinbasepro.ErrOrderNotFound):
+			// Orders cancelled without fills are removed by the exchange.
+			order.Status = coinbasepro.OrderStatusDone
+			return order, nil
+		case err != nil && ctx.Err() == nil:
+			return order, err
+		case err == nil:
+			order = detail
+			if order.Status == coinbasepro.OrderStatusDone {
+				return order, nil
+			}
+		}
+
+		e.mu.Lock()
+		state, changed := e.state, e.changed
+		e.mu.Unlock()
+
+		if ctx.Err() != nil || state != StateRunning || (!deadline.IsZero() && !e.clock.Now().Before(deadline)) {
+			return e.cancelChild(order)
+		}
+
+		wait := e.params.PollInterval
+		if !deadline.IsZero() && deadline.Sub(e.clock.Now()) < wait {
+			wait = deadline.Sub(e.clock.Now())
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-changed:
+		case <-e.clock.After(wait):
+		}
+	}
+}
+
+// cancelChild cancels the remainder of a child order and returns its final state. It uses its own context so the
+// order is also cancelled when the context of Run is done.
+func (e *Execution) cancelChild(order coinbasepro.OrderDetail) (coinbasepro.OrderDetail, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err := e.exchange.CancelOrder(ctx, order.ID, coinbasepro.CancelOrderParams{ProductID: order.ProductID})
+	if err != nil && !errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		return order, fmt.Errorf("failed to cancel child order: %w", err)
+	}
+
+	for {
+		detail, err := e.exchange.GetOrderDetail(ctx, order.ID)
+		switch {
+		case errors.Is(err, coinbasepro.ErrOrderNotFound):
+			order.Status = coinbasepro.OrderStatusDone
+			return order, nil
+		case err != nil:
+			return order, err
+		case detail.Status == coinbasepro.OrderStatusDone:
+			return detail, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return detail, ctx.Err()
+		case <-e.clock.After(e.params.PollInterval):
+		}
+	}
+}
+
+// price returns the price of a limit child: the opposite side of the book, or the own side when passive, capped at
+// the limit price. Iceberg children always rest at the limit price.
+func (e *Execution) price(ctx context.Context) (string, error) {
+	p := e.params
+
+	if e.display != nil {
+		return p.Product.RoundPrice(p.LimitPrice)
+	}
+
+	ticker, err := e.exchange.GetTicker(ctx, p.Product.ID)
+	if err != nil {
+		return "", err
+	}
+
+	quote := ticker.Ask
+	if (p.Side == coinbasepro.SideSell) != p.Passive {
+		quote = ticker.Bid
+	}
+
+	price, ok := decimal.Parse(quote)
+	if !ok {
+		price, ok = decimal.Parse(ticker.Price)
+	}
+	if !ok {
+		if e.limit == nil {
+			return "", fmt.Errorf("no price for %s", p.Product.ID)
+		}
+		price = e.limit
+	}
+
+	if e.limit != nil && beyond(p.Side, price, e.limit) {
+		price = e.limit
+	}
+
+	return p.Product.RoundPrice(decimal.String(price))
+}
+
+// withinLimit reports whether a market child can be placed without trading beyond the limit price.
+func (e *Execution) withinLimit(ctx context.Context) (bool, error) {
+	if e.limit == nil {
+		return true, nil
+	}
+
+	ticker, err := e.exchange.GetTicker(ctx, e.params.Product.ID)
+	if err != nil {
+		return false, err
+	}
+
+	quote := ticker.Ask
+	if e.params.Side == coinbasepro.SideSell {
+		quote = ticker.Bid
+	}
+
+	price, ok := decimal.Parse(quote)
+	if !ok {
+		return false, nil
+	}
+
+	return !beyond(e.params.Side, price, e.limit), nil
+}
+
+// beyond reports whether price is worse than limit for side.
+func beyond(side coinbasepro.Side, price, limit *big.Rat) bool {
+	if side == coinbasepro.SideBuy {
+		return price.Cmp(limit) > 0
+	}
+
+	return price.Cmp(limit) < 0
+}
+
+func mid(ticker coinbasepro.Ticker) *big.Rat {
+	bid, bidOK := decimal.Parse(ticker.Bid)
+	ask, askOK := decimal.Parse(ticker.Ask)
+	if bidOK && askOK {
+		return new(big.Rat).Quo(new(big.Rat).Add(bid, ask), big.NewRat(2, 1))
+	}
+
+	return decimal.OrZero(ticker.Price)
+}
@@ -0,0 +1,311 @@
+package execution_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/execution"
+)
+
+var testProduct = coinbasepro.Product{
+	ID:             "BTC-USD",
+	BaseIncrement:  "0.01",
+	BaseMinSize:    "0.01",
+	QuoteIncrement: "0.01",
+}
+
+// fakeClock advances instantly to every requested time.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakeClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+
+	return ch
+}
+
+// fakeExchange fills every order completely at its price, or at the ask for market orders, with a fee of 0.5%.
+// Orders whose number is in resting stay open without fills until they are cancelled.
+type fakeExchange struct {
+	mu      sync.Mutex
+	rates   []coinbasepro.HistoricRate
+	orders  []coinbasepro.OrderDetail
+	resting map[int]bool
+}
+
+func (f *fakeExchange) PlaceOrder(_ context.Context, newOrder coinbasepro.CreateOrderRequest) (coinbasepro.OrderDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	price := newOrder.Price
+	if price == "" {
+		price = "101"
+	}
+
+	size, _ := new(big.Rat).SetString(newOrder.Size)
+	p, _ := new(big.Rat).SetString(price)
+	value := new(big.Rat).Mul(size, p)
+	fee := new(big.Rat).Mul(value, big.NewRat(5, 1000))
+
+	order := coinbasepro.OrderDetail{
+		ID:            fmt.Sprintf("order-%d", len(f.orders)+1),
+		ProductID:     newOrder.ProductID,
+		Side:          newOrder.Side,
+		Type:          newOrder.Type,
+		Price:         newOrder.Price,
+		Size:          newOrder.Size,
+		Status:        coinbasepro.OrderStatusDone,
+		DoneReason:    "filled",
+		FilledSize:    coinbasepro.Decimal(newOrder.Size),
+		ExecutedValue: coinbasepro.Decimal(value.FloatString(8)),
+		FillFees:      coinbasepro.Decimal(fee.FloatString(8)),
+	}
+	if f.resting[len(f.orders)+1] {
+		order.Status, order.DoneReason = coinbasepro.OrderStatusOpen, ""
+		order.FilledSize, order.ExecutedValue, order.FillFees = "", "", ""
+	}
+	f.orders = append(f.orders, order)
+
+	return order, nil
+}
+
+func (f *fakeExchange) CancelOrder(_ context.Context, id string, _ ...coinbasepro.CancelOrderParams) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, order := range f.orders {
+		if order.ID == id && order.Status != coinbasepro.OrderStatusDone {
+			f.orders[i].Status, f.orders[i].DoneReason = coinbasepro.OrderStatusDone, "canceled"
+		}
+	}
+
+	return nil
+}
+
+func (f *fakeExchange) GetOrderDetail(_ context.Context, id string) (coinbasepro.OrderDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, order := range f.orders {
+		if order.ID == id {
+			return order, nil
+		}
+	}
+
+	return coinbasepro.OrderDetail{}, coinbasepro.ErrOrderNotFound
+}
+
+func (f *fakeExchange) GetTicker(context.Context, string) (coinbasepro.Ticker, error) {
+	return coinbasepro.Ticker{Bid: "99", Ask: "101", Price: "100"}, nil
+}
+
+func (f *fakeExchange) GetHistoricRates(context.Context, string, coinbasepro.GetHistoricRatesParams) ([]coinbasepro.HistoricRate, error) {
+	return f.rates, nil
+}
+
+func (f *fakeExchange) sizes() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sizes := make([]string, len(f.orders))
+	for i, order := range f.orders {
+		sizes[i] = order.Size
+	}
+
+	return sizes
+}
+
+func TestTWAP(t *testing.T) {
+	exchange := &fakeExchange{}
+	clock := &fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+
+	var progress []execution.Progress
+	twap, err := execution.NewTWAP(exchange, execution.TWAPParams{
+		Params: execution.Params{
+			Product:    testProduct,
+			Side:       coinbasepro.SideBuy,
+			Size:       "1",
+			LimitPrice: "100.50",
+		},
+		Duration: time.Hour,
+		Slices:   3,
+	}, execution.WithClock(clock), execution.WithProgressHandler(func(p execution.Progress) {
+		progress = append(progress, p)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := twap.Run(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sizes := fmt.Sprint(exchange.sizes()); sizes != "[0.33 0.33 0.34]" {
+		t.Errorf("expected sizes respecting the increment, got %s", sizes)
+	}
+
+	for _, order := range exchange.orders {
+		if order.Price != "100.50" {
+			t.Errorf("expected child price capped at the limit, got %s", order.Price)
+		}
+	}
+
+	if report.State != execution.StateCompleted || report.Filled != "1" || report.AveragePrice != "100.5" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if report.ArrivalMid != "100" || report.Slippage != "0.5" || report.SlippageBps != 50 {
+		t.Errorf("unexpected slippage %s %s %f", report.ArrivalMid, report.Slippage, report.SlippageBps)
+	}
+	if report.Fees != "0.5025" {
+		t.Errorf("expected fees 0.5025, got %s", report.Fees)
+	}
+	if elapsed := report.FinishedAt.Sub(report.StartedAt); elapsed != 40*time.Minute {
+		t.Errorf("expected last slice after 40 minutes, got %s", elapsed)
+	}
+
+	if len(progress) == 0 || progress[len(progress)-1].State != execution.StateCompleted {
+		t.Errorf("expected final progress to be completed, got %+v", progress)
+	}
+}
+
+func TestTWAPUnfilledLastSlice(t *testing.T) {
+	exchange := &fakeExchange{resting: map[int]bool{3: true}}
+
+	twap, err := execution.NewTWAP(exchange, execution.TWAPParams{
+		Params: execution.Params{
+			Product: testProduct,
+			Side:    coinbasepro.SideBuy,
+			Size:    "1",
+		},
+		Duration: time.Hour,
+		Slices:   3,
+	}, execution.WithClock(&fakeClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := twap.Run(context.Background())
+	if !errors.Is(err, execution.ErrIncomplete) {
+		t.Fatalf("expected the execution to be incomplete, got %v", err)
+	}
+	if report.State != execution.StateIncomplete || report.Filled != "0.66" {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if state := twap.Progress().State; state != execution.StateIncomplete {
+		t.Errorf("expected incomplete state, got %s", state)
+	}
+}
+
+func TestVWAP(t *testing.T) {
+	start := time.Date(2021, 1, 8, 0, 0, 0, 0, time.UTC)
+	exchange := &fakeExchange{}
+
+	// The first hour of the day trades three times the volume of the second.
+	for day := 1; day <= 7; day++ {
+		at := start.AddDate(0, 0, -day)
+		exchange.rates = append(exchange.rates,
+			coinbasepro.HistoricRate{Time: at, Volume: 30},
+			coinbasepro.HistoricRate{Time: at.Add(time.Hour), Volume: 10},
+		)
+	}
+
+	vwap, err := execution.NewVWAP(exchange, execution.VWAPParams{
+		Params: execution.Params{
+			Product:   testProduct,
+			Side:      coinbasepro.SideSell,
+			Size:      "2",
+			OrderType: coinbasepro.OrderTypeMarket,
+		},
+		Duration: 2 * time.Hour,
+		Slices:   2,
+	}, execution.WithClock(&fakeClock{now: start}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := vwap.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if sizes := fmt.Sprint(exchange.sizes()); sizes != "[1.50 0.50]" {
+		t.Errorf("expected sizes following the volume profile, got %s", sizes)
+	}
+}
+
+func TestIcebergParticipation(t *testing.T) {
+	exchange := &fakeExchange{}
+	progress := make(chan execution.Progress, 100)
+	awaitProgress := func(ok func(execution.Progress) bool) {
+		t.Helper()
+		for {
+			select {
+			case p := <-progress:
+				if ok(p) {
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatal("expected progress")
+			}
+		}
+	}
+
+	iceberg, err := execution.NewIceberg(exchange, execution.IcebergParams{
+		Params: execution.Params{
+			Product:           testProduct,
+			Side:              coinbasepro.SideBuy,
+			Size:              "1",
+			LimitPrice:        "100",
+			ParticipationRate: 0.5,
+		},
+		DisplaySize: "0.3",
+	}, execution.WithClock(&fakeClock{}), execution.WithProgressHandler(func(p execution.Progress) {
+		progress <- p
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := iceberg.Run(context.Background())
+		done <- err
+	}()
+
+	// Without market volume the cap allows nothing, with 1.5 traded it allows 0.75.
+	awaitProgress(func(p execution.Progress) bool { return p.State == execution.StateRunning })
+	iceberg.HandleMessage(coinbasepro.Message{Type: "match", ProductID: "BTC-USD", Size: "1.5"})
+
+	awaitProgress(func(p execution.Progress) bool { return p.Filled == "0.75" })
+	if sizes := fmt.Sprint(exchange.sizes()); sizes != "[0.30 0.30 0.15]" {
+		t.Errorf("expected display sized children within the cap, got %s", sizes)
+	}
+
+	iceberg.Cancel()
+	if err := <-done; err != execution.ErrCancelled {
+		t.Errorf("expected cancelled, got %v", err)
+	}
+	if state := iceberg.Progress().State; state != execution.StateCancelled {
+		t.Errorf("expected cancelled state, got %s", state)
+	}
+}
@@ -0,0 +1,40 @@
+package execution
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// IcebergParams show at most DisplaySize of the size on the book at LimitPrice, a new child is placed as soon as
+// the previous one is done.
+type IcebergParams struct {
+	Params
+	DisplaySize string
+}
+
+func NewIceberg(exchange Exchange, p IcebergParams, opts ...Option) (*Execution, error) {
+	switch {
+	case p.LimitPrice == "":
+		return nil, errors.New("limit price is required")
+	case p.OrderType != "" && p.OrderType != coinbasepro.OrderTypeLimit:
+		return nil, errors.New("iceberg child orders must be limit orders")
+	}
+
+	e, err := newExecution(exchange, p.Params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var ok bool
+	if e.display, ok = decimal.Parse(p.DisplaySize); !ok || e.display.Sign() <= 0 {
+		return nil, fmt.Errorf("invalid display size %q", p.DisplaySize)
+	}
+	if e.minSize != nil && e.display.Cmp(e.minSize) < 0 {
+		return nil, fmt.Errorf("display size must be at least %s", p.Product.BaseMinSize)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,160 @@
+package execution
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+// TWAPParams spread the size evenly over Duration in Slices child orders.
+type TWAPParams struct {
+	Params
+	Duration time.Duration
+	Slices   int
+}
+
+// VWAPParams spread the size over Duration in Slices child orders following the volume profile of the product by
+// time of day, taken from the historic rates of the last Lookback.
+type VWAPParams struct {
+	Params
+	Duration time.Duration
+	Slices   int
+	// Lookback defaults to seven days, at most 300 candles of Granularity can be requested.
+	Lookback time.Duration
+	// Granularity of the candles in seconds, defaults to one hour.
+	Granularity int
+}
+
+func NewTWAP(exchange Exchange, p TWAPParams, opts ...Option) (*Execution, error) {
+	if err := validateSchedule(p.Duration, p.Slices); err != nil {
+		return nil, err
+	}
+
+	e, err := newExecution(exchange, p.Params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	e.plan = func(context.Context, time.Time) ([]slice, time.Duration, error) {
+		weights := make([]float64, p.Slices)
+		for i := range weights {
+			weights[i] = 1
+		}
+
+		return schedule(weights, p.Duration), p.Duration / time.Duration(p.Slices), nil
+	}
+
+	return e, nil
+}
+
+func NewVWAP(exchange Exchange, p VWAPParams, opts ...Option) (*Execution, error) {
+	if err := validateSchedule(p.Duration, p.Slices); err != nil {
+		return nil, err
+	}
+
+	if p.Lookback == 0 {
+		p.Lookback = 7 * 24 * time.Hour
+	}
+	if p.Granularity == 0 {
+		p.Granularity = 3600
+	}
+
+	granularity := time.Duration(p.Granularity) * time.Second
+	if p.Lookback < granularity || p.Lookback/granularity > 300 {
+		return nil, errors.New("lookback must be between 1 and 300 candles")
+	}
+
+	e, err := newExecution(exchange, p.Params, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	e.plan = func(ctx context.Context, start time.Time) ([]slice, time.Duration, error) {
+		rates, err := exchange.GetHistoricRates(ctx, p.Product.ID, coinbasepro.GetHistoricRatesParams{
+			Start:       start.Add(-p.Lookback),
+			End:         start,
+			Granularity: p.Granularity,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get volume profile: %w", err)
+		}
+
+		interval := p.Duration / time.Duration(p.Slices)
+		profile := volumeProfile(rates, granularity)
+
+		weights := make([]float64, p.Slices)
+		for i := range weights {
+			weights[i] = profile[timeOfDay(start.Add(time.Duration(i)*interval), granularity)]
+		}
+
+		return schedule(weights, p.Duration), interval, nil
+	}
+
+	return e, nil
+}
+
+func validateSchedule(duration time.Duration, slices int) error {
+	switch {
+	case duration <= 0:
+		return errors.New("duration must be greater than 0")
+	case slices <= 0:
+		return errors.New("slices must be greater than 0")
+	}
+
+	return nil
+}
+
+// volumeProfile returns the average volume by time of day bucket.
+func volumeProfile(rates []coinbasepro.HistoricRate, granularity time.Duration) map[time.Duration]float64 {
+	sums := make(map[time.Duration]float64)
+	counts := make(map[time.Duration]int)
+
+	for _, rate := range rates {
+		bucket := timeOfDay(rate.Time, granularity)
+		sums[bucket] += rate.Volume
+		counts[bucket]++
+	}
+
+	for bucket, n := range counts {
+		sums[bucket] /= float64(n)
+	}
+
+	return sums
+}
+
+func timeOfDay(t time.Time, granularity time.Duration) time.Duration {
+	t = t.UTC()
+	return t.Sub(t.Truncate(24 * time.Hour)).Truncate(granularity)
+}
+
+// schedule converts slice weights into cumulative fractions, slices are spread evenly over duration. Without any
+// weight the schedule is even.
+func schedule(weights []float64, duration time.Duration) []slice {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+
+	interval := duration / time.Duration(len(weights))
+	slices := make([]slice, len(weights))
+
+	sum := new(big.Rat)
+	for i, w := range weights {
+		if total > 0 {
+			sum.Add(sum, new(big.Rat).SetFloat64(w/total))
+		} else {
+			sum.SetFrac64(int64(i+1), int64(len(weights)))
+		}
+
+		slices[i] = slice{offset: time.Duration(i) * interval, cumulative: new(big.Rat).Set(sum)}
+	}
+
+	// Float rounding must not leave a remainder after the last slice.
+	slices[len(slices)-1].cumulative.SetInt64(1)
+
+	return slices
+}
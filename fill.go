@@ -21,6 +21,7 @@ type Fill struct {
 type ListFillsParams struct {
 	OrderID    string
 	ProductID  string
+	ProfileID  string
 	Pagination PaginationParams
 }
 
@@ -32,6 +33,9 @@ func (c *client) ListFills(p ListFillsParams) *Cursor {
 	if p.ProductID != "" {
 		paginationParams.AddExtraParam("product_id", p.ProductID)
 	}
+	if profileID := c.profile(p.ProfileID); profileID != "" {
+		paginationParams.AddExtraParam("profile_id", profileID)
+	}
 
 	return c.newCursor(http.MethodGet, fmt.Sprintf("/fills"), paginationParams)
 }
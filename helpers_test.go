@@ -2,20 +2,32 @@ package coinbasepro
 
 import (
 	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 
 	ws "github.com/gorilla/websocket"
+
+	"github.com/moonr-app/go-coinbasepro/coinbaseprotest"
 )
 
-func NewTestClient(t *testing.T) *client {
+// NewTestClient returns a client for the public sandbox when COINBASE_PRO_KEY is set, otherwise a client for an
+// in-process fake exchange so the tests also run offline. opts are applied after the test options.
+func NewTestClient(t *testing.T, opts ...ClientOption) *client {
+	if os.Getenv("COINBASE_PRO_KEY") == "" {
+		c, _ := NewFakeClient(t, opts...)
+		return c
+	}
+
 	c, err := NewClient(
 		os.Getenv("COINBASE_PRO_KEY"),
 		os.Getenv("COINBASE_PRO_PASSPHRASE"),
 		os.Getenv("COINBASE_PRO_SECRET"),
-		WithSandboxEnvironment(),
-		WithRetryCount(2),
+		append([]ClientOption{WithSandboxEnvironment(), WithRetryCount(2)}, opts...)...,
 	)
 	if err != nil {
 		t.Fatal(err)
@@ -24,6 +36,69 @@ func NewTestClient(t *testing.T) *client {
 	return c
 }
 
+// NewFakeClient returns a client for an in-process fake exchange and the fake, for tests which inspect the requests
+// it received.
+func NewFakeClient(t *testing.T, opts ...ClientOption) (*client, *coinbaseprotest.Server) {
+	server, err := coinbaseprotest.NewServer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(server.Close)
+
+	c, err := NewClient(
+		server.Key,
+		server.Passphrase,
+		server.Secret,
+		append([]ClientOption{
+			WithBaseURL(server.URL),
+			WithWebsocketURL(server.WebsocketURL),
+			WithRetryCount(2),
+		}, opts...)...,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c, server
+}
+
+// WithDeadManClock replaces the clock of a dead man switch.
+func WithDeadManClock(c clock) DeadManOption {
+	return func(d *DeadManSwitch) error {
+		d.clock = c
+		return nil
+	}
+}
+
+// WithWithdrawalGuardClock replaces the clock of the rolling limits of a withdrawal guard.
+func WithWithdrawalGuardClock(c clock) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		g.clock = c
+		return nil
+	}
+}
+
+// WithPaperClock replaces the clock of the timestamps of a paper client.
+func WithPaperClock(c clock) PaperOption {
+	return func(p *PaperClient) error {
+		p.clock = c
+		return nil
+	}
+}
+
+// NewTestServerClient returns a client which sends all requests to an in-process server using handler.
+func NewTestServerClient(t *testing.T, handler http.Handler) *client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	c, err := NewClient("key", "passphrase", "c2VjcmV0", WithBaseURL(server.URL), WithRetryCount(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return c
+}
+
 func NewTestWebsocketClient() (*ws.Conn, error) {
 	var wsDialer ws.Dialer
 	wsConn, _, err := wsDialer.Dial("wss://ws-feed-public.sandbox.pro.coinbase.com", nil)
@@ -90,3 +165,23 @@ func EnsureProperties(a interface{}, properties []string) error {
 
 	return nil
 }
+
+// DirSize returns the total size of the files under dir.
+func DirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
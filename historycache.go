@@ -0,0 +1,420 @@
+package coinbasepro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// candlesPerBucket is the maximum number of candles returned by a single historic rates request.
+	candlesPerBucket = 300
+	tradesPageLimit  = 1000
+)
+
+// HistoryCache serves historic rates and trades from a local directory. Data is stored in buckets keyed by
+// product, granularity and start time. Buckets which are closed are immutable and served from disk, only buckets
+// which are missing or still open are requested from the API.
+type HistoryCache struct {
+	client      *client
+	dir         string
+	maxBytes    int64
+	tradeBucket time.Duration
+	now         func() time.Time
+
+	mu sync.Mutex
+}
+
+type HistoryCacheOption func(*HistoryCache) error
+
+// cachedTrades is the on-disk format of a trades bucket.
+type cachedTrades struct {
+	// Boundary is the lowest trade id at or after the start of the bucket, it can be used as an after cursor to
+	// fetch the trades preceding the bucket.
+	Boundary int     `json:"boundary"`
+	Trades   []Trade `json:"trades"`
+}
+
+// WithCacheMaxBytes limits the size of the cache directory, the least recently used buckets are removed first.
+func WithCacheMaxBytes(maxBytes int64) HistoryCacheOption {
+	return func(h *HistoryCache) error {
+		if maxBytes < 0 {
+			return errors.New("maxBytes cannot be less than 0")
+		}
+		h.maxBytes = maxBytes
+
+		return nil
+	}
+}
+
+// WithCacheTradeBucket sets the time span covered by a single trades bucket, defaults to one hour.
+func WithCacheTradeBucket(bucket time.Duration) HistoryCacheOption {
+	return func(h *HistoryCache) error {
+		if bucket < time.Minute {
+			return errors.New("bucket cannot be less than a minute")
+		}
+		h.tradeBucket = bucket
+
+		return nil
+	}
+}
+
+// NewHistoryCache creates a cache for historic rates and trades stored under dir.
+func NewHistoryCache(c *client, dir string, opts ...HistoryCacheOption) (*HistoryCache, error) {
+	if dir == "" {
+		return nil, errors.New("dir cannot be empty")
+	}
+
+	h := &HistoryCache{
+		client:      c,
+		dir:         dir,
+		tradeBucket: time.Hour,
+		now:         time.Now,
+	}
+
+	for _, opt := range opts {
+		if err := opt(h); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	return h, nil
+}
+
+// GetHistoricRates returns the candles between p.Start and p.End, newest first, in the same way as the client.
+// Start, End and Granularity are all required.
+func (h *HistoryCache) GetHistoricRates(ctx context.Context, product string, p GetHistoricRatesParams) ([]HistoricRate, error) {
+	switch {
+	case p.Granularity <= 0:
+		return nil, errors.New("granularity must be greater than 0")
+	case p.Start.IsZero() || p.End.IsZero():
+		return nil, errors.New("start and end cannot be empty")
+	case p.End.Before(p.Start):
+		return nil, errors.New("end cannot be before start")
+	}
+
+	granularity := time.Duration(p.Granularity) * time.Second
+	span := granularity * candlesPerBucket
+	now := h.now()
+
+	var rates []HistoricRate
+	for start := p.Start.Truncate(span); !start.After(p.End); start = start.Add(span) {
+		end := start.Add(span)
+		// The last candle of a bucket is only final once its interval has passed.
+		closed := !end.After(now.Add(-granularity))
+		path := h.candlesPath(product, p.Granularity, start)
+
+		var bucket []HistoricRate
+		if closed && h.load(path, &bucket) {
+			rates = append(rates, bucket...)
+			continue
+		}
+
+		bucket, err := h.client.GetHistoricRates(ctx, product, GetHistoricRatesParams{
+			Start:       start,
+			End:         end.Add(-granularity),
+			Granularity: p.Granularity,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if closed {
+			if err := h.store(path, bucket); err != nil {
+				return nil, err
+			}
+		}
+
+		rates = append(rates, bucket...)
+	}
+
+	filtered := rates[:0]
+	for _, r := range rates {
+		if r.Time.Before(p.Start) || r.Time.After(p.End) {
+			continue
+		}
+		filtered = append(filtered, r)
+	}
+	sort.Slice(filtered, func(i, j int) bool {
+		return filtered[i].Time.After(filtered[j].Time)
+	})
+
+	return filtered, nil
+}
+
+// ListTrades returns the trades between start and end, newest first.
+func (h *HistoryCache) ListTrades(ctx context.Context, product string, start, end time.Time) ([]Trade, error) {
+	if end.Before(start) {
+		return nil, errors.New("end cannot be before start")
+	}
+
+	now := h.now()
+	first := start.Truncate(h.tradeBucket)
+	last := end.Truncate(h.tradeBucket)
+
+	buckets := make(map[time.Time]*cachedTrades)
+	var missing []time.Time
+	for b := last; !b.Before(first); b = b.Add(-h.tradeBucket) {
+		closed := !b.Add(h.tradeBucket).After(now)
+
+		var bucket cachedTrades
+		if closed && h.load(h.tradesPath(product, b), &bucket) {
+			buckets[b] = &bucket
+			continue
+		}
+		missing = append(missing, b)
+	}
+
+	// Missing buckets are fetched in contiguous runs, newest first, so pages are only requested once per run.
+	for len(missing) > 0 {
+		run := 1
+		for run < len(missing) && missing[run].Equal(missing[run-1].Add(-h.tradeBucket)) {
+			run++
+		}
+
+		if err := h.fetchTrades(ctx, product, missing[:run], buckets); err != nil {
+			return nil, err
+		}
+		missing = missing[run:]
+	}
+
+	var trades []Trade
+	for b := last; !b.Before(first); b = b.Add(-h.tradeBucket) {
+		for _, t := range buckets[b].Trades {
+			if t.Time.Time().Before(start) || t.Time.Time().After(end) {
+				continue
+			}
+			trades = append(trades, t)
+		}
+	}
+
+	return trades, nil
+}
+
+// fetchTrades pages through the trades of a contiguous run of buckets, ordered newest first.
+func (h *HistoryCache) fetchTrades(ctx context.Context, product string, run []time.Time, buckets map[time.Time]*cachedTrades) error {
+	newest, oldest := run[0], run[len(run)-1]
+	now := h.now()
+
+	pagination := PaginationParams{Limit: tradesPageLimit}
+	if next, ok := buckets[newest.Add(h.tradeBucket)]; ok && next.Boundary != 0 {
+		pagination.After = strconv.Itoa(next.Boundary)
+	}
+
+	fetched := make(map[time.Time]*cachedTrades, len(run))
+	for _, b := range run {
+		fetched[b] = &cachedTrades{}
+	}
+
+	// The boundary of a bucket is the lowest trade id seen before its start was crossed.
+	boundary := 0
+	if pagination.After != "" {
+		boundary, _ = strconv.Atoi(pagination.After)
+	}
+
+	cursor := h.client.ListTrades(product, ListTradesParams{Pagination: &pagination})
+	for reachedStart := false; cursor.HasMore && !reachedStart; {
+		var page []Trade
+		if err := cursor.NextPage(ctx, &page); err != nil {
+			return err
+		}
+
+		reachedStart = len(page) == 0
+		for _, t := range page {
+			b := t.Time.Time().Truncate(h.tradeBucket)
+			if b.Before(oldest) {
+				reachedStart = true
+				break
+			}
+
+			if bucket, ok := fetched[b]; ok {
+				bucket.Trades = append(bucket.Trades, t)
+				continue
+			}
+			boundary = t.TradeID
+		}
+	}
+
+	for _, b := range run {
+		if n := len(fetched[b].Trades); n > 0 {
+			boundary = fetched[b].Trades[n-1].TradeID
+		}
+		fetched[b].Boundary = boundary
+	}
+
+	for _, b := range run {
+		bucket := fetched[b]
+		buckets[b] = bucket
+
+		if b.Add(h.tradeBucket).After(now) {
+			continue
+		}
+		if err := h.store(h.tradesPath(product, b), bucket); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Invalidate removes every cached bucket of product.
+func (h *HistoryCache) Invalidate(product string) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, kind := range []string{"candles", "trades"} {
+		if err := os.RemoveAll(filepath.Join(h.dir, kind, product)); err != nil {
+			return fmt.Errorf("failed to invalidate %s: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+// Clear removes every cached bucket.
+func (h *HistoryCache) Clear() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, kind := range []string{"candles", "trades"} {
+		if err := os.RemoveAll(filepath.Join(h.dir, kind)); err != nil {
+			return fmt.Errorf("failed to clear %s: %w", kind, err)
+		}
+	}
+
+	return nil
+}
+
+func (h *HistoryCache) candlesPath(product string, granularity int, start time.Time) string {
+	return filepath.Join(h.dir, "candles", product, strconv.Itoa(granularity), fmt.Sprintf("%d.json", start.Unix()))
+}
+
+func (h *HistoryCache) tradesPath(product string, start time.Time) string {
+	return filepath.Join(h.dir, "trades", product, strconv.Itoa(int(h.tradeBucket.Seconds())), fmt.Sprintf("%d.json", start.Unix()))
+}
+
+// load decodes the bucket at path into v, a bucket which cannot be decoded is removed and treated as missing.
+func (h *HistoryCache) load(path string, v interface{}) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		os.Remove(path)
+		return false
+	}
+
+	// The modification time is used to find the least recently used buckets.
+	now := time.Now()
+	os.Chtimes(path, now, now)
+
+	return true
+}
+
+func (h *HistoryCache) store(path string, v interface{}) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var data []byte
+	var err error
+	if rates, ok := v.([]HistoricRate); ok {
+		data, err = marshalRates(rates)
+	} else {
+		data, err = json.Marshal(v)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to marshal bucket: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create bucket directory: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write bucket: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write bucket: %w", err)
+	}
+
+	return h.evict()
+}
+
+// evict removes the least recently used buckets until the cache is within maxBytes.
+func (h *HistoryCache) evict() error {
+	if h.maxBytes == 0 {
+		return nil
+	}
+
+	type entry struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var (
+		entries []entry
+		total   int64
+	)
+	err := filepath.WalkDir(h.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+		total += info.Size()
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to read cache directory: %w", err)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	for _, e := range entries {
+		if total <= h.maxBytes {
+			break
+		}
+		if err := os.Remove(e.path); err != nil {
+			return fmt.Errorf("failed to evict bucket: %w", err)
+		}
+		total -= e.size
+	}
+
+	return nil
+}
+
+// marshalRates encodes candles in the same array format the API uses, so they can be decoded with HistoricRate.UnmarshalJSON.
+func marshalRates(rates []HistoricRate) ([]byte, error) {
+	entries := make([][6]float64, len(rates))
+	for i, r := range rates {
+		entries[i] = [6]float64{float64(r.Time.Unix()), r.Low, r.High, r.Open, r.Close, r.Volume}
+	}
+
+	return json.Marshal(entries)
+}
@@ -0,0 +1,201 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestHistoryCacheGetHistoricRates(t *testing.T) {
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		end, _ := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+
+		var candles [][]float64
+		for c := end; !c.Before(start); c = c.Add(-time.Minute) {
+			candles = append(candles, []float64{float64(c.Unix()), 1, 2, 1.5, 1.6, 10})
+		}
+		json.NewEncoder(w).Encode(candles)
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	cache, err := coinbasepro.NewHistoryCache(client, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	params := coinbasepro.GetHistoricRatesParams{
+		Start:       time.Now().Add(-72 * time.Hour),
+		End:         time.Now().Add(-48 * time.Hour),
+		Granularity: 60,
+	}
+
+	rates, err := cache.GetHistoricRates(ctx, "BTC-USD", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(rates) < 24*60 {
+		t.Fatalf("expected a full day of candles, got %d", len(rates))
+	}
+
+	if rates[0].Time.Before(rates[len(rates)-1].Time) {
+		t.Fatal("candles should be ordered newest first")
+	}
+
+	fetched := atomic.LoadInt32(&requests)
+	cached, err := cache.GetHistoricRates(ctx, "BTC-USD", params)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&requests) != fetched {
+		t.Fatal("closed buckets should be served from the cache")
+	}
+
+	if len(cached) != len(rates) {
+		t.Fatalf("cached candles (%d) do not match fetched candles (%d)", len(cached), len(rates))
+	}
+
+	if err := cache.Invalidate("BTC-USD"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := cache.GetHistoricRates(ctx, "BTC-USD", params); err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&requests) == fetched {
+		t.Fatal("invalidated buckets should be fetched again")
+	}
+}
+
+func TestHistoryCacheListTrades(t *testing.T) {
+	// One trade every ten minutes over the last two days, trade ids increase with time.
+	now := time.Now().UTC()
+	first := now.Add(-48 * time.Hour)
+	var trades []coinbasepro.Trade
+	for i, at := 1, first; at.Before(now); i, at = i+1, at.Add(10*time.Minute) {
+		trades = append(trades, coinbasepro.Trade{TradeID: i, Price: "1.00", Size: "1.00", Side: "buy", Time: coinbasepro.Time(at)})
+	}
+
+	var requests int32
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		after := len(trades) + 1
+		if a := r.URL.Query().Get("after"); a != "" {
+			after, _ = strconv.Atoi(a)
+		}
+
+		var page []coinbasepro.Trade
+		for i := after - 2; i >= 0 && len(page) < 20; i-- {
+			page = append(page, trades[i])
+		}
+
+		if len(page) > 0 && page[len(page)-1].TradeID > 1 {
+			w.Header().Set("CB-AFTER", strconv.Itoa(page[len(page)-1].TradeID))
+		}
+		json.NewEncoder(w).Encode(page)
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	cache, err := coinbasepro.NewHistoryCache(client, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start, end := now.Add(-30*time.Hour), now.Add(-20*time.Hour)
+
+	result, err := cache.ListTrades(ctx, "BTC-USD", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := countTrades(trades, start, end); len(result) != expected {
+		t.Fatalf("expected %d trades, got %d", expected, len(result))
+	}
+
+	fetched := atomic.LoadInt32(&requests)
+	cached, err := cache.ListTrades(ctx, "BTC-USD", start, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if atomic.LoadInt32(&requests) != fetched {
+		t.Fatal("closed buckets should be served from the cache")
+	}
+
+	for i := range cached {
+		if cached[i].TradeID != result[i].TradeID {
+			t.Fatal("cached trades do not match fetched trades")
+		}
+	}
+
+	// Older buckets are fetched starting from the boundary of the cached buckets.
+	older, err := cache.ListTrades(ctx, "BTC-USD", now.Add(-40*time.Hour), end)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := countTrades(trades, now.Add(-40*time.Hour), end); len(older) != expected {
+		t.Fatalf("expected %d trades, got %d", expected, len(older))
+	}
+
+	if atomic.LoadInt32(&requests)-fetched > 4 {
+		t.Fatal("older trades should be fetched from the cached boundary")
+	}
+}
+
+func countTrades(trades []coinbasepro.Trade, start, end time.Time) int {
+	n := 0
+	for _, t := range trades {
+		if !t.Time.Time().Before(start) && !t.Time.Time().After(end) {
+			n++
+		}
+	}
+
+	return n
+}
+
+func TestHistoryCacheMaxBytes(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+		json.NewEncoder(w).Encode([][]float64{{float64(start.Unix()), 1, 2, 1.5, 1.6, 10}})
+	})
+
+	dir := t.TempDir()
+	client := coinbasepro.NewTestServerClient(t, handler)
+	cache, err := coinbasepro.NewHistoryCache(client, dir, coinbasepro.WithCacheMaxBytes(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	params := coinbasepro.GetHistoricRatesParams{
+		Start:       time.Now().Add(-30 * 24 * time.Hour),
+		End:         time.Now().Add(-24 * time.Hour),
+		Granularity: 3600,
+	}
+
+	if _, err := cache.GetHistoricRates(context.Background(), "BTC-USD", params); err != nil {
+		t.Fatal(err)
+	}
+
+	size, err := coinbasepro.DirSize(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if size > 100 {
+		t.Fatalf("cache exceeds max bytes: %d", size)
+	}
+}
@@ -0,0 +1,99 @@
+// Package decimal implements the exact decimal arithmetic shared by the client, the paper exchange and the order
+// engines. Amounts are kept as big.Rat values and formatted back into the decimal strings used by the API.
+package decimal
+
+import (
+	"math/big"
+	"strings"
+)
+
+// Parse parses a decimal string returned by the API without losing precision.
+func Parse(s string) (*big.Rat, bool) {
+	if s == "" {
+		return nil, false
+	}
+
+	return new(big.Rat).SetString(s)
+}
+
+// OrZero parses a decimal string, an empty or invalid string is zero.
+func OrZero(s string) *big.Rat {
+	if r, ok := Parse(s); ok {
+		return r
+	}
+
+	return new(big.Rat)
+}
+
+// Places returns the number of significant decimal places of an increment such as "0.01000000".
+func Places(increment string) int {
+	i := strings.IndexByte(increment, '.')
+	if i < 0 {
+		return 0
+	}
+
+	return len(strings.TrimRight(increment[i+1:], "0"))
+}
+
+// IsMultiple reports whether v is an exact multiple of increment.
+func IsMultiple(v, increment *big.Rat) bool {
+	if increment.Sign() == 0 {
+		return true
+	}
+
+	return new(big.Rat).Quo(v, increment).IsInt()
+}
+
+// Round rounds v to the nearest multiple of increment, halves are rounded away from zero.
+func Round(v, increment *big.Rat) *big.Rat {
+	if increment.Sign() == 0 {
+		return new(big.Rat).Set(v)
+	}
+
+	q := new(big.Rat).Quo(v, increment)
+	n, rem := new(big.Int).QuoRem(q.Num(), q.Denom(), new(big.Int))
+
+	// Compare the remainder with half of the denominator to decide the rounding direction.
+	rem.Abs(rem).Lsh(rem, 1)
+	if rem.Cmp(q.Denom()) >= 0 {
+		if q.Sign() < 0 {
+			n.Sub(n, big.NewInt(1))
+		} else {
+			n.Add(n, big.NewInt(1))
+		}
+	}
+
+	return new(big.Rat).Mul(new(big.Rat).SetInt(n), increment)
+}
+
+// Floor rounds v down to a multiple of increment, a nil or zero increment leaves v unchanged.
+func Floor(v, increment *big.Rat) *big.Rat {
+	if increment == nil || increment.Sign() == 0 {
+		return new(big.Rat).Set(v)
+	}
+
+	q := new(big.Rat).Quo(v, increment)
+	n := new(big.Int).Div(q.Num(), q.Denom())
+
+	return new(big.Rat).Mul(new(big.Rat).SetInt(n), increment)
+}
+
+// Format formats v with the number of decimal places used by increment.
+func Format(v *big.Rat, increment string) string {
+	return v.FloatString(Places(increment))
+}
+
+// Trim formats v with at most places decimal places and without trailing zeros.
+func Trim(v *big.Rat, places int) string {
+	s := v.FloatString(places)
+	if strings.IndexByte(s, '.') < 0 {
+		return s
+	}
+
+	return strings.TrimRight(strings.TrimRight(s, "0"), ".")
+}
+
+// String formats v without trailing zeros, with up to 16 decimal places.
+func String(v *big.Rat) string {
+	return Trim(v, 16)
+}
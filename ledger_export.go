@@ -0,0 +1,383 @@
+package coinbasepro
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+type LedgerExportFormat string
+
+const (
+	LedgerExportCSV   LedgerExportFormat = "csv"
+	LedgerExportJSONL LedgerExportFormat = "jsonl"
+)
+
+// LedgerExporter exports the ledger entries of all accounts. The position of every account is kept in a state
+// file, so an interrupted export resumes where it stopped and later exports only append the entries made since.
+//
+// The entries of every account are written oldest first. The ledger is listed newest first, so the first export of an
+// account walks it back to the oldest page without writing, then writes that page and pages towards the newest entry.
+// Every entry is requested twice by the first export but none is kept in memory, and an interrupted walk back resumes
+// at the oldest page it found. Later exports only page through the entries made since.
+//
+// The state is saved after every page, a page can be written twice when the process stops between writing it and
+// saving the state.
+type LedgerExporter struct {
+	client    *client
+	statePath string
+	format    LedgerExportFormat
+	limit     int
+
+	mu sync.Mutex
+}
+
+type LedgerExporterOption func(*LedgerExporter) error
+
+// LedgerExportRecord is a normalised ledger entry, it is a line of a JSON Lines export and its fields are the
+// columns of a CSV export.
+type LedgerExportRecord struct {
+	ID           string `json:"id"`
+	Time         string `json:"time"`
+	AccountID    string `json:"account_id"`
+	Currency     string `json:"currency"`
+	Type         string `json:"type"`
+	Amount       string `json:"amount"`
+	Balance      string `json:"balance"`
+	OrderID      string `json:"order_id"`
+	TradeID      string `json:"trade_id"`
+	ProductID    string `json:"product_id"`
+	TransferID   string `json:"transfer_id"`
+	TransferType string `json:"transfer_type"`
+	ConversionID string `json:"conversion_id"`
+}
+
+var ledgerExportColumns = []string{
+	"id", "time", "account_id", "currency", "type", "amount", "balance",
+	"order_id", "trade_id", "product_id", "transfer_id", "transfer_type", "conversion_id",
+}
+
+type (
+	ledgerExportState struct {
+		// Header is set once the header of a CSV export has been written.
+		Header   bool                             `json:"header"`
+		Accounts map[string]*ledgerExportPosition `json:"accounts"`
+	}
+	ledgerExportPosition struct {
+		// Newest is the id of the newest exported entry.
+		Newest string `json:"newest"`
+		// After is the cursor of the oldest page found while the first export walks the ledger back, Complete is set
+		// once that page has been written.
+		After    string `json:"after,omitempty"`
+		Complete bool   `json:"complete"`
+	}
+)
+
+// ledgerRecordWriter writes records in the format of the export, flush is called before the state is saved.
+type ledgerRecordWriter interface {
+	header() error
+	write(LedgerExportRecord) error
+	flush() error
+}
+
+// WithLedgerExportFormat sets the format of the export, defaults to CSV.
+func WithLedgerExportFormat(format LedgerExportFormat) LedgerExporterOption {
+	return func(x *LedgerExporter) error {
+		if format != LedgerExportCSV && format != LedgerExportJSONL {
+			return fmt.Errorf("unsupported format %q", format)
+		}
+		x.format = format
+
+		return nil
+	}
+}
+
+// WithLedgerExportPageLimit sets the number of entries requested per page, defaults to 100.
+func WithLedgerExportPageLimit(limit int) LedgerExporterOption {
+	return func(x *LedgerExporter) error {
+		if limit < 1 {
+			return errors.New("limit must be positive")
+		}
+		x.limit = limit
+
+		return nil
+	}
+}
+
+// NewLedgerExporter creates an exporter which keeps its state in the file at statePath.
+func NewLedgerExporter(c *client, statePath string, opts ...LedgerExporterOption) (*LedgerExporter, error) {
+	if statePath == "" {
+		return nil, errors.New("statePath cannot be empty")
+	}
+
+	x := &LedgerExporter{
+		client:    c,
+		statePath: statePath,
+		format:    LedgerExportCSV,
+		limit:     100,
+	}
+
+	for _, opt := range opts {
+		if err := opt(x); err != nil {
+			return nil, err
+		}
+	}
+
+	return x, nil
+}
+
+// Export writes the entries which have not been exported yet to w and returns the number of written entries. The
+// header of a CSV export is only written by the first export, w should append to the output of earlier exports.
+func (x *LedgerExporter) Export(ctx context.Context, w io.Writer) (int, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	state, err := x.load()
+	if err != nil {
+		return 0, err
+	}
+
+	accounts, err := x.client.GetAccounts(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get accounts: %w", err)
+	}
+
+	out := x.newWriter(w)
+	if !state.Header {
+		if err := out.header(); err != nil {
+			return 0, err
+		}
+		state.Header = true
+		if err := x.save(out, state); err != nil {
+			return 0, err
+		}
+	}
+
+	var n int
+	for _, a := range accounts {
+		position, ok := state.Accounts[a.ID]
+		if !ok {
+			position = &ledgerExportPosition{}
+			state.Accounts[a.ID] = position
+		}
+
+		written, err := x.exportAccount(ctx, a, position, state, out)
+		n += written
+		if err != nil {
+			return n, fmt.Errorf("failed to export ledger of account %s: %w", a.ID, err)
+		}
+	}
+
+	return n, nil
+}
+
+// exportAccount walks the ledger of the account back to its oldest page until the first export is complete, then
+// pages through the entries newer than the newest exported one.
+func (x *LedgerExporter) exportAccount(ctx context.Context, a Account, position *ledgerExportPosition, state *ledgerExportState, out ledgerRecordWriter) (int, error) {
+	// An account which had no entries during its first export has no position for the newer entries yet.
+	if position.Newest == "" {
+		position.Complete = false
+	}
+
+	var n int
+	if !position.Complete {
+		cursor := x.client.ListAccountLedger(a.ID, GetAccountLedgerParams{
+			Pagination: PaginationParams{Limit: x.limit, After: position.After},
+		})
+
+		// Nothing is written until the oldest page is found, the saved cursor requests the oldest page seen so far
+		// so an interrupted walk resumes without losing it.
+		var oldest []LedgerEntry
+		for cursor.HasMore {
+			after := cursor.pagination.After
+
+			var entries []LedgerEntry
+			if err := cursor.NextPage(ctx, &entries); err != nil {
+				return n, err
+			}
+			if len(entries) == 0 {
+				continue
+			}
+
+			oldest = entries
+			if cursor.HasMore {
+				position.After = after
+				if err := x.save(out, state); err != nil {
+					return n, err
+				}
+			}
+		}
+
+		for i := len(oldest) - 1; i >= 0; i-- {
+			if err := out.write(ledgerExportRecord(a, oldest[i])); err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		if len(oldest) > 0 {
+			position.Newest = oldest[0].ID
+		}
+		position.After = ""
+		position.Complete = true
+		if err := x.save(out, state); err != nil {
+			return n, err
+		}
+
+		if position.Newest == "" {
+			return n, nil
+		}
+	}
+
+	cursor := x.client.ListAccountLedger(a.ID, GetAccountLedgerParams{
+		Pagination: PaginationParams{Limit: x.limit, Before: position.Newest},
+	})
+	for cursor.HasMore {
+		var entries []LedgerEntry
+		if err := cursor.PrevPage(ctx, &entries); err != nil {
+			return n, err
+		}
+		if len(entries) == 0 {
+			break
+		}
+
+		// pages are newest first, the entries are appended in the order they were made
+		for i := len(entries) - 1; i >= 0; i-- {
+			if err := out.write(ledgerExportRecord(a, entries[i])); err != nil {
+				return n, err
+			}
+			n++
+		}
+
+		position.Newest = entries[0].ID
+		if err := x.save(out, state); err != nil {
+			return n, err
+		}
+	}
+
+	return n, nil
+}
+
+func (x *LedgerExporter) load() (*ledgerExportState, error) {
+	state := &ledgerExportState{Accounts: make(map[string]*ledgerExportPosition)}
+
+	data, err := os.ReadFile(x.statePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state: %w", err)
+	}
+
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to decode state: %w", err)
+	}
+	if state.Accounts == nil {
+		state.Accounts = make(map[string]*ledgerExportPosition)
+	}
+
+	return state, nil
+}
+
+// save flushes the written records before the state is replaced, so the state never points past the output.
+func (x *LedgerExporter) save(out ledgerRecordWriter, state *ledgerExportState) error {
+	if err := out.flush(); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(x.statePath), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	tmp := x.statePath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+	if err := os.Rename(tmp, x.statePath); err != nil {
+		return fmt.Errorf("failed to write state: %w", err)
+	}
+
+	return nil
+}
+
+func (x *LedgerExporter) newWriter(w io.Writer) ledgerRecordWriter {
+	if x.format == LedgerExportJSONL {
+		return jsonlRecordWriter{encoder: json.NewEncoder(w)}
+	}
+
+	return csvRecordWriter{writer: csv.NewWriter(w)}
+}
+
+func ledgerExportRecord(a Account, e LedgerEntry) LedgerExportRecord {
+	return LedgerExportRecord{
+		ID:           e.ID,
+		Time:         e.CreatedAt.Time().UTC().Format(time.RFC3339Nano),
+		AccountID:    a.ID,
+		Currency:     a.Currency,
+		Type:         string(e.Type),
+		Amount:       e.Amount.String(),
+		Balance:      e.Balance.String(),
+		OrderID:      e.Details.OrderID,
+		TradeID:      e.Details.TradeID,
+		ProductID:    e.Details.ProductID,
+		TransferID:   e.Details.TransferID,
+		TransferType: e.Details.TransferType,
+		ConversionID: e.Details.ConversionID,
+	}
+}
+
+type csvRecordWriter struct {
+	writer *csv.Writer
+}
+
+func (c csvRecordWriter) header() error {
+	return c.writer.Write(ledgerExportColumns)
+}
+
+func (c csvRecordWriter) write(r LedgerExportRecord) error {
+	return c.writer.Write([]string{
+		r.ID, r.Time, r.AccountID, r.Currency, r.Type, r.Amount, r.Balance,
+		r.OrderID, r.TradeID, r.ProductID, r.TransferID, r.TransferType, r.ConversionID,
+	})
+}
+
+func (c csvRecordWriter) flush() error {
+	c.writer.Flush()
+	if err := c.writer.Error(); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return nil
+}
+
+type jsonlRecordWriter struct {
+	encoder *json.Encoder
+}
+
+func (j jsonlRecordWriter) header() error {
+	return nil
+}
+
+func (j jsonlRecordWriter) write(r LedgerExportRecord) error {
+	if err := j.encoder.Encode(r); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	return nil
+}
+
+func (j jsonlRecordWriter) flush() error {
+	return nil
+}
@@ -0,0 +1,282 @@
+package coinbasepro_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestLedgerExporter(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	// The orders add entries spanning several pages to the ledgers of the first export.
+	for i := 0; i < 2; i++ {
+		if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	total := countLedgerEntries(t, client)
+
+	exporter, err := coinbasepro.NewLedgerExporter(client, filepath.Join(t.TempDir(), "state.json"), coinbasepro.WithLedgerExportPageLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	n, err := exporter.Export(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != total {
+		t.Fatalf("expected %d entries, got %d", total, n)
+	}
+
+	if n, err := exporter.Export(ctx, &out); err != nil || n != 0 {
+		t.Fatalf("expected nothing to export, got %d entries and %v", n, err)
+	}
+
+	// The new entries span several pages, they are paged from the newest exported entry towards the newest one.
+	for i := 0; i < 3; i++ {
+		if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	n, err = exporter.Export(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added := countLedgerEntries(t, client) - total; n != added {
+		t.Fatalf("expected %d new entries, got %d", added, n)
+	}
+
+	records, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != total+n+1 || records[0][0] != "id" {
+		t.Fatalf("expected a header and %d records, got %d rows", total+n, len(records))
+	}
+
+	// Every account is exported oldest first, also across exports.
+	latest := make(map[string]time.Time)
+	for _, record := range records[1:] {
+		at, err := time.Parse(time.RFC3339Nano, record[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if at.Before(latest[record[2]]) {
+			t.Fatalf("expected the entries of account %s in the order they were made, got %s after %s", record[2], at, latest[record[2]])
+		}
+		latest[record[2]] = at
+	}
+
+	last := records[len(records)-1]
+	if last[4] != string(coinbasepro.LedgerEntryTypeFee) && last[4] != string(coinbasepro.LedgerEntryTypeMatch) {
+		t.Errorf("expected the entries of the order last, got %v", last)
+	}
+}
+
+func TestLedgerExporterResume(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	total := countLedgerEntries(t, client)
+	state := filepath.Join(t.TempDir(), "state.json")
+
+	exporter, err := coinbasepro.NewLedgerExporter(client, state, coinbasepro.WithLedgerExportFormat(coinbasepro.LedgerExportJSONL), coinbasepro.WithLedgerExportPageLimit(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	failing := &failingWriter{remaining: 3}
+	if _, err := exporter.Export(ctx, failing); err == nil {
+		t.Fatal("expected the export to fail")
+	}
+
+	var out bytes.Buffer
+	n, err := exporter.Export(ctx, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n >= total {
+		t.Errorf("expected the export to resume, got %d of %d entries", n, total)
+	}
+
+	ids := make(map[string]bool)
+	for _, data := range [][]byte{failing.Bytes(), out.Bytes()} {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var record coinbasepro.LedgerExportRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			ids[record.ID] = true
+		}
+	}
+	if len(ids) != total {
+		t.Errorf("expected %d exported entries, got %d", total, len(ids))
+	}
+}
+
+func TestLedgerExporterResumeFirstWalk(t *testing.T) {
+	client, server := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+			t.Fatal(err)
+		}
+	}
+	total := countLedgerEntries(t, client)
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var usd coinbasepro.Account
+	for _, a := range accounts {
+		if a.Currency == "USD" {
+			usd = a
+		}
+	}
+	ledgerPath := "/accounts/" + usd.ID + "/ledger"
+
+	// The USD ledger spans four pages, its third request fails while the export walks back to the oldest page.
+	transport := &failingTransport{path: ledgerPath, remaining: 2}
+	failing, err := coinbasepro.NewClient(server.Key, server.Passphrase, server.Secret,
+		coinbasepro.WithBaseURL(server.URL),
+		coinbasepro.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state := filepath.Join(t.TempDir(), "state.json")
+	exporter, err := coinbasepro.NewLedgerExporter(failing, state, coinbasepro.WithLedgerExportFormat(coinbasepro.LedgerExportJSONL), coinbasepro.WithLedgerExportPageLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first bytes.Buffer
+	if _, err := exporter.Export(ctx, &first); err == nil {
+		t.Fatal("expected the export to fail")
+	}
+	requests := len(server.Requests())
+
+	exporter, err = coinbasepro.NewLedgerExporter(client, state, coinbasepro.WithLedgerExportFormat(coinbasepro.LedgerExportJSONL), coinbasepro.WithLedgerExportPageLimit(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var second bytes.Buffer
+	if _, err := exporter.Export(ctx, &second); err != nil {
+		t.Fatal(err)
+	}
+
+	// The walk resumes at the oldest page found before the failure instead of the newest entry.
+	for _, r := range server.Requests()[requests:] {
+		if r.Path == ledgerPath && !strings.Contains(r.Query, "after=") && !strings.Contains(r.Query, "before=") {
+			t.Errorf("expected the walk to resume from its cursor, got request %s?%s", r.Path, r.Query)
+		}
+	}
+
+	ids := make(map[string]bool)
+	var latest time.Time
+	for _, data := range [][]byte{first.Bytes(), second.Bytes()} {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			var record coinbasepro.LedgerExportRecord
+			if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+				t.Fatal(err)
+			}
+			if ids[record.ID] {
+				t.Errorf("entry %s exported twice", record.ID)
+			}
+			ids[record.ID] = true
+
+			if record.AccountID != usd.ID {
+				continue
+			}
+			at, err := time.Parse(time.RFC3339Nano, record.Time)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if at.Before(latest) {
+				t.Errorf("expected the USD entries in the order they were made, got %s after %s", at, latest)
+			}
+			latest = at
+		}
+	}
+	if len(ids) != total {
+		t.Errorf("expected %d exported entries, got %d", total, len(ids))
+	}
+}
+
+// failingTransport fails the requests to path once remaining of them have been made.
+type failingTransport struct {
+	path      string
+	remaining int
+}
+
+func (f *failingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Path == f.path {
+		if f.remaining == 0 {
+			return nil, errors.New("connection reset")
+		}
+		f.remaining--
+	}
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+// failingWriter fails once remaining writes have been made.
+type failingWriter struct {
+	bytes.Buffer
+	remaining int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.remaining == 0 {
+		return 0, errors.New("disk full")
+	}
+	w.remaining--
+
+	return w.Buffer.Write(p)
+}
+
+func countLedgerEntries(t *testing.T, client coinbasepro.Trader) int {
+	t.Helper()
+	ctx := context.Background()
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var total int
+	for _, a := range accounts {
+		cursor := client.ListAccountLedger(a.ID)
+		for cursor.HasMore {
+			var entries []coinbasepro.LedgerEntry
+			if err := cursor.NextPage(ctx, &entries); err != nil {
+				t.Fatal(err)
+			}
+			total += len(entries)
+		}
+	}
+
+	return total
+}
@@ -44,6 +44,13 @@ type Message struct {
 	UserID        string           `json:"user_id"`
 	ProfileID     string           `json:"profile_id"`
 	LastTradeID   int              `json:"last_trade_id"`
+	// Authenticated match messages
+	TakerUserID    string `json:"taker_user_id"`
+	TakerProfileID string `json:"taker_profile_id"`
+	TakerFeeRate   string `json:"taker_fee_rate"`
+	MakerUserID    string `json:"maker_user_id"`
+	MakerProfileID string `json:"maker_profile_id"`
+	MakerFeeRate   string `json:"maker_fee_rate"`
 }
 
 type MessageChannel struct {
@@ -88,6 +95,10 @@ func (c *client) Subscribe(ctx context.Context, message Message, handler func(Me
 			return fmt.Errorf("failed to read message: %w", err)
 		}
 
+		if !receivedMessage.forProfile(c.profileID) {
+			continue
+		}
+
 		err := handler(receivedMessage)
 		if err == nil {
 			continue
@@ -101,6 +112,15 @@ func (c *client) Subscribe(ctx context.Context, message Message, handler func(Me
 	}
 }
 
+// forProfile reports whether the message belongs to profileID, messages without a profile belong to every profile.
+func (m Message) forProfile(profileID string) bool {
+	if profileID == "" || (m.ProfileID == "" && m.TakerProfileID == "" && m.MakerProfileID == "") {
+		return true
+	}
+
+	return m.ProfileID == profileID || m.TakerProfileID == profileID || m.MakerProfileID == profileID
+}
+
 func (e *SnapshotEntry) UnmarshalJSON(data []byte) error {
 	var entry []string
 
@@ -0,0 +1,674 @@
+// Package oco manages client-side linked orders, one-cancels-other pairs and entry orders with a take profit and a
+// stop loss, on top of the order endpoints and the authenticated user channel.
+package oco
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// Exchange is the subset of the client used by the engine.
+type Exchange interface {
+	PlaceOrder(ctx context.Context, newOrder coinbasepro.CreateOrderRequest) (coinbasepro.OrderDetail, error)
+	CancelOrder(ctx context.Context, id string, p ...coinbasepro.CancelOrderParams) error
+	GetOrderDetail(ctx context.Context, id string) (coinbasepro.OrderDetail, error)
+}
+
+type EventType string
+
+const (
+	EventLegPlaced    EventType = "leg_placed"
+	EventLegFilled    EventType = "leg_filled"
+	EventLegResized   EventType = "leg_resized"
+	EventLegCancelled EventType = "leg_cancelled"
+	EventGroupDone    EventType = "group_done"
+)
+
+// Event is emitted for every change the engine makes to a group.
+type Event struct {
+	Type  EventType
+	Role  LegRole
+	Group Group
+}
+
+// Engine keeps the legs of every group consistent: a fill of one exit leg reduces its siblings to the remaining
+// position and cancels them once nothing remains. Every user channel message must be passed to HandleMessage.
+//
+// Changes to a group are serialised by a lock of the group, which is held while its orders are placed and cancelled.
+// Groups are handled independently of each other.
+type Engine struct {
+	exchange Exchange
+	store    Store
+	timeout  time.Duration
+
+	mu       sync.Mutex
+	groups   map[string]*managedGroup
+	orders   map[string]string
+	handlers []func(Event)
+
+	// placing is read locked while an order is placed until its id is known, messages for unknown orders wait for
+	// the placements in flight.
+	placing sync.RWMutex
+}
+
+type managedGroup struct {
+	mu    sync.Mutex
+	group *Group
+}
+
+type EngineOption func(*Engine) error
+
+// WithActionTimeout limits the time of the orders placed and cancelled while handling a message, defaults to ten
+// seconds.
+func WithActionTimeout(timeout time.Duration) EngineOption {
+	return func(e *Engine) error {
+		if timeout <= 0 {
+			return errors.New("timeout must be greater than 0")
+		}
+		e.timeout = timeout
+
+		return nil
+	}
+}
+
+func NewEngine(exchange Exchange, store Store, opts ...EngineOption) (*Engine, error) {
+	if exchange == nil {
+		return nil, errors.New("exchange cannot be nil")
+	}
+	if store == nil {
+		return nil, errors.New("store cannot be nil")
+	}
+
+	e := &Engine{
+		exchange: exchange,
+		store:    store,
+		timeout:  10 * time.Second,
+		groups:   make(map[string]*managedGroup),
+		orders:   make(map[string]string),
+	}
+
+	for _, opt := range opts {
+		if err := opt(e); err != nil {
+			return nil, err
+		}
+	}
+
+	return e, nil
+}
+
+// OnEvent registers a handler which is called after every change to a group.
+func (e *Engine) OnEvent(handler func(Event)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.handlers = append(e.handlers, handler)
+}
+
+// Group returns a copy of the group with id, it waits for the changes to the group which are in progress.
+func (e *Engine) Group(id string) (Group, bool) {
+	m, ok := e.lock(id)
+	if !ok {
+		return Group{}, false
+	}
+	defer m.mu.Unlock()
+
+	return m.group.clone(), true
+}
+
+// PlaceOCO places two exit orders for the same position, for example a take profit limit order and a stop loss.
+// Both orders must be for the same product, side and size.
+func (e *Engine) PlaceOCO(ctx context.Context, first, second coinbasepro.CreateOrderRequest) (Group, error) {
+	switch {
+	case first.ProductID != second.ProductID:
+		return Group{}, errors.New("orders must be for the same product")
+	case first.Side != second.Side:
+		return Group{}, errors.New("orders must be for the same side")
+	case first.Size == "" || decimal.OrZero(first.Size).Cmp(decimal.OrZero(second.Size)) != 0:
+		return Group{}, errors.New("orders must have the same size")
+	}
+
+	g := newGroup(GroupOCO)
+	g.Size = first.Size
+	g.Legs = []Leg{
+		{Role: LegExit, Order: first, Status: LegPending},
+		{Role: LegExit, Order: second, Status: LegPending},
+	}
+
+	return e.start(ctx, g)
+}
+
+// PlaceBracket places entry and, as it fills, a take profit and a stop loss for the filled size. The exits must be
+// on the opposite side of the entry, a fill of either exit cancels the rest of the entry.
+func (e *Engine) PlaceBracket(ctx context.Context, entry, takeProfit, stopLoss coinbasepro.CreateOrderRequest) (Group, error) {
+	switch {
+	case entry.ProductID != takeProfit.ProductID || entry.ProductID != stopLoss.ProductID:
+		return Group{}, errors.New("orders must be for the same product")
+	case takeProfit.Side == entry.Side || stopLoss.Side == entry.Side:
+		return Group{}, errors.New("exits must be on the opposite side of the entry")
+	case entry.Size == "":
+		return Group{}, errors.New("entry must have a size")
+	}
+
+	g := newGroup(GroupBracket)
+	g.Legs = []Leg{
+		{Role: LegEntry, Order: entry, Status: LegPending},
+		{Role: LegTakeProfit, Order: takeProfit, Status: LegPending},
+		{Role: LegStopLoss, Order: stopLoss, Status: LegPending},
+	}
+
+	return e.start(ctx, g)
+}
+
+func (e *Engine) start(ctx context.Context, g *Group) (Group, error) {
+	// The group is registered before its orders are placed, so their messages wait until it has been started.
+	m := &managedGroup{group: g}
+	m.mu.Lock()
+	e.mu.Lock()
+	e.groups[g.ID] = m
+	e.mu.Unlock()
+
+	var events []Event
+
+	var err error
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if g.Type == GroupBracket && leg.Role != LegEntry {
+			continue
+		}
+
+		if err = e.place(ctx, g, leg, leg.Order.Size, &events); err != nil {
+			break
+		}
+	}
+
+	if err != nil {
+		// Don't leave half a group on the book.
+		for i := range g.Legs {
+			if g.Legs[i].Status == LegOpen {
+				_ = e.cancel(ctx, g, &g.Legs[i], &events)
+			}
+		}
+		g.Done = true
+		e.mu.Lock()
+		delete(e.groups, g.ID)
+		e.mu.Unlock()
+		m.mu.Unlock()
+
+		return Group{}, err
+	}
+
+	saveErr := e.save(ctx, g)
+	result := g.clone()
+	m.mu.Unlock()
+
+	e.emit(events)
+
+	return result, saveErr
+}
+
+// Cancel cancels every open order of the group and marks it as done.
+func (e *Engine) Cancel(ctx context.Context, id string) error {
+	m, ok := e.lock(id)
+	if !ok {
+		return fmt.Errorf("group %s not found", id)
+	}
+	g := m.group
+
+	var (
+		events []Event
+		err    error
+	)
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if leg.Status == LegOpen {
+			if cancelErr := e.cancel(ctx, g, leg, &events); cancelErr != nil && err == nil {
+				err = cancelErr
+			}
+		}
+		if leg.Status == LegPending {
+			leg.Status = LegCancelled
+		}
+	}
+
+	if err == nil {
+		e.finish(g, &events)
+	}
+	if saveErr := e.save(ctx, g); saveErr != nil && err == nil {
+		err = saveErr
+	}
+	m.mu.Unlock()
+
+	e.emit(events)
+
+	return err
+}
+
+// Restore loads the groups which aren't done from the store and reconciles their legs with the exchange, so fills
+// which happened while the engine was not running are taken into account.
+func (e *Engine) Restore(ctx context.Context) error {
+	groups, err := e.store.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		events  []Event
+		managed []*managedGroup
+	)
+
+	// All groups are registered first, messages for their orders wait until the group has been reconciled.
+	e.mu.Lock()
+	for i := range groups {
+		g := &groups[i]
+		if g.Done {
+			continue
+		}
+
+		m := &managedGroup{group: g}
+		m.mu.Lock()
+		managed = append(managed, m)
+
+		e.groups[g.ID] = m
+		for _, leg := range g.Legs {
+			for _, id := range append([]string{leg.OrderID}, leg.PreviousOrderIDs...) {
+				if id != "" {
+					e.orders[id] = g.ID
+				}
+			}
+		}
+	}
+	e.mu.Unlock()
+
+	for _, m := range managed {
+		if err == nil {
+			err = e.restore(ctx, m.group, &events)
+		}
+		m.mu.Unlock()
+	}
+
+	e.emit(events)
+
+	return err
+}
+
+func (e *Engine) restore(ctx context.Context, g *Group, events *[]Event) error {
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if leg.Status == LegOpen {
+			if err := e.reconcileLeg(ctx, leg); err != nil {
+				return err
+			}
+		}
+	}
+
+	err := e.rebalance(ctx, g, events)
+	if saveErr := e.save(ctx, g); saveErr != nil && err == nil {
+		err = saveErr
+	}
+
+	return err
+}
+
+func (e *Engine) reconcileLeg(ctx context.Context, leg *Leg) error {
+	order, err := e.exchange.GetOrderDetail(ctx, leg.OrderID)
+	if errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		leg.Status = LegCancelled
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	missed := new(big.Rat).Sub(order.FilledSize.RatOrZero(), decimal.OrZero(leg.OrderFilledSize))
+	if missed.Sign() > 0 {
+		leg.FilledSize = addSize(leg.FilledSize, decimal.String(missed))
+		leg.OrderFilledSize = order.FilledSize.String()
+	}
+
+	if order.Status == coinbasepro.OrderStatusDone {
+		leg.Status = LegCancelled
+		if order.DoneReason == "filled" {
+			leg.Status = LegFilled
+		}
+	}
+
+	return nil
+}
+
+// HandleMessage applies a user channel message to the group it belongs to. It can be used as, or called from, the
+// handler passed to Subscribe. Errors placing or cancelling orders and errors saving the group are returned, the
+// group keeps its state.
+func (e *Engine) HandleMessage(msg coinbasepro.Message) error {
+	var ids []string
+	switch msg.Type {
+	case "match":
+		ids = []string{msg.MakerOrderID, msg.TakerOrderID}
+	case "done":
+		ids = []string{msg.OrderID}
+	default:
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.timeout)
+	defer cancel()
+
+	var (
+		events []Event
+		err    error
+	)
+
+	for _, id := range ids {
+		m, ok := e.lockOrder(id)
+		if !ok {
+			continue
+		}
+
+		g := m.group
+		if g.Done {
+			m.mu.Unlock()
+			continue
+		}
+
+		for i := range g.Legs {
+			leg := &g.Legs[i]
+			if !leg.ownsOrder(id) {
+				continue
+			}
+
+			if msg.Type == "match" {
+				e.match(g, leg, id, msg, &events)
+			} else {
+				e.done(leg, id, msg)
+			}
+		}
+
+		if rebalanceErr := e.rebalance(ctx, g, &events); rebalanceErr != nil && err == nil {
+			err = rebalanceErr
+		}
+		if saveErr := e.save(ctx, g); saveErr != nil && err == nil {
+			err = saveErr
+		}
+		m.mu.Unlock()
+	}
+
+	e.emit(events)
+
+	return err
+}
+
+func (e *Engine) match(g *Group, leg *Leg, id string, msg coinbasepro.Message, events *[]Event) {
+	if leg.seen(msg.TradeID) {
+		return
+	}
+
+	leg.TradeIDs = append(leg.TradeIDs, msg.TradeID)
+	leg.FilledSize = addSize(leg.FilledSize, msg.Size)
+	if leg.OrderID == id {
+		leg.OrderFilledSize = addSize(leg.OrderFilledSize, msg.Size)
+	}
+	g.UpdatedAt = time.Now()
+
+	*events = append(*events, Event{Type: EventLegFilled, Role: leg.Role, Group: g.clone()})
+}
+
+func (e *Engine) done(leg *Leg, id string, msg coinbasepro.Message) {
+	// Done messages of replaced orders and of orders cancelled by the engine don't change the leg.
+	if leg.OrderID != id || leg.final() {
+		return
+	}
+
+	if msg.Reason == "filled" {
+		leg.Status = LegFilled
+		return
+	}
+
+	leg.Status = LegCancelled
+}
+
+// rebalance sizes the exit legs to the remaining position and completes the group once nothing remains.
+func (e *Engine) rebalance(ctx context.Context, g *Group, events *[]Event) error {
+	position := g.position()
+
+	entry, isBracket := g.Leg(LegEntry)
+	entryFinal := !isBracket || entry.final()
+
+	exitFilled := new(big.Rat)
+	for _, leg := range g.Legs {
+		if leg.Role != LegEntry {
+			exitFilled.Add(exitFilled, decimal.OrZero(leg.FilledSize))
+		}
+	}
+
+	// Once an exit fills the trade is being closed, the rest of the entry is not needed anymore.
+	if isBracket && !entryFinal && exitFilled.Sign() > 0 {
+		if err := e.cancel(ctx, g, entry, events); err != nil {
+			return err
+		}
+		entryFinal = true
+	}
+
+	for i := range g.Legs {
+		leg := &g.Legs[i]
+		if leg.Role == LegEntry || leg.final() {
+			continue
+		}
+
+		switch {
+		case position.Sign() == 0 && !entryFinal:
+			// Bracket exits wait for the entry to fill.
+		case position.Sign() == 0 && leg.Status == LegOpen && leg.remaining().Sign() <= 0:
+			// The done message of a fully matched order can arrive after the match.
+			leg.Status = LegFilled
+		case position.Sign() == 0:
+			if leg.Status == LegOpen {
+				if err := e.cancel(ctx, g, leg, events); err != nil {
+					return err
+				}
+			}
+			leg.Status = LegCancelled
+		case leg.Status == LegPending:
+			if err := e.place(ctx, g, leg, decimal.String(position), events); err != nil {
+				return err
+			}
+		case leg.remaining().Cmp(position) != 0:
+			if err := e.resize(ctx, g, leg, position, events); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !entryFinal {
+		return nil
+	}
+
+	for _, leg := range g.Legs {
+		if leg.Role != LegEntry && leg.Status == LegOpen && position.Sign() > 0 {
+			return nil
+		}
+	}
+
+	e.finish(g, events)
+
+	return nil
+}
+
+func (e *Engine) place(ctx context.Context, g *Group, leg *Leg, size string, events *[]Event) error {
+	leg.Order.Size = size
+
+	e.placing.RLock()
+	order, err := e.exchange.PlaceOrder(ctx, leg.Order)
+	if err == nil {
+		e.mu.Lock()
+		e.orders[order.ID] = g.ID
+		e.mu.Unlock()
+	}
+	e.placing.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to place %s: %w", leg.Role, err)
+	}
+
+	if leg.OrderID != "" {
+		leg.PreviousOrderIDs = append(leg.PreviousOrderIDs, leg.OrderID)
+	}
+	leg.OrderID = order.ID
+	leg.OrderFilledSize = ""
+	leg.Status = LegOpen
+	g.UpdatedAt = time.Now()
+
+	*events = append(*events, Event{Type: EventLegPlaced, Role: leg.Role, Group: g.clone()})
+
+	return nil
+}
+
+func (e *Engine) cancel(ctx context.Context, g *Group, leg *Leg, events *[]Event) error {
+	err := e.exchange.CancelOrder(ctx, leg.OrderID, coinbasepro.CancelOrderParams{ProductID: leg.Order.ProductID})
+	if err != nil && !errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		return fmt.Errorf("failed to cancel %s: %w", leg.Role, err)
+	}
+
+	leg.Status = LegCancelled
+	g.UpdatedAt = time.Now()
+
+	*events = append(*events, Event{Type: EventLegCancelled, Role: leg.Role, Group: g.clone()})
+
+	return nil
+}
+
+// resize replaces the current order of leg with an order for size, the exchange has no native amend. The order can
+// fill while it is cancelled, the replacement is reduced by the fills which have not been handled yet. Their match
+// messages still reduce the position of the group when they arrive.
+func (e *Engine) resize(ctx context.Context, g *Group, leg *Leg, size *big.Rat, events *[]Event) error {
+	err := e.exchange.CancelOrder(ctx, leg.OrderID, coinbasepro.CancelOrderParams{ProductID: leg.Order.ProductID})
+	if err != nil && !errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		return fmt.Errorf("failed to cancel %s: %w", leg.Role, err)
+	}
+
+	// Orders cancelled without fills are removed by the exchange.
+	cancelled, err := e.exchange.GetOrderDetail(ctx, leg.OrderID)
+	switch {
+	case errors.Is(err, coinbasepro.ErrOrderNotFound):
+	case err != nil:
+		leg.Status = LegPending
+		return fmt.Errorf("failed to get cancelled %s: %w", leg.Role, err)
+	default:
+		missed := new(big.Rat).Sub(cancelled.FilledSize.RatOrZero(), decimal.OrZero(leg.OrderFilledSize))
+		if missed.Sign() > 0 {
+			size = new(big.Rat).Sub(size, missed)
+		}
+	}
+
+	if size.Sign() <= 0 {
+		leg.Status = LegCancelled
+		if cancelled.DoneReason == "filled" {
+			leg.Status = LegFilled
+		}
+		g.UpdatedAt = time.Now()
+
+		*events = append(*events, Event{Type: EventLegCancelled, Role: leg.Role, Group: g.clone()})
+
+		return nil
+	}
+
+	if err := e.place(ctx, g, leg, decimal.String(size), events); err != nil {
+		leg.Status = LegPending
+		return err
+	}
+
+	(*events)[len(*events)-1].Type = EventLegResized
+
+	return nil
+}
+
+func (e *Engine) finish(g *Group, events *[]Event) {
+	g.Done = true
+	g.UpdatedAt = time.Now()
+
+	*events = append(*events, Event{Type: EventGroupDone, Group: g.clone()})
+}
+
+// save persists the group, done groups are removed from the store. The in-memory state remains authoritative when
+// the store fails, the error is returned to the caller.
+func (e *Engine) save(ctx context.Context, g *Group) error {
+	if g.Done {
+		if err := e.store.Delete(ctx, g.ID); err != nil {
+			return fmt.Errorf("failed to delete group %s: %w", g.ID, err)
+		}
+		return nil
+	}
+
+	if err := e.store.Save(ctx, g.clone()); err != nil {
+		return fmt.Errorf("failed to save group %s: %w", g.ID, err)
+	}
+
+	return nil
+}
+
+// lock locks and returns the group with id.
+func (e *Engine) lock(id string) (*managedGroup, bool) {
+	e.mu.Lock()
+	m, ok := e.groups[id]
+	e.mu.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	m.mu.Lock()
+
+	return m, true
+}
+
+// lockOrder locks and returns the group of an order. An unknown order can be one which is being placed, the
+// placements in flight are waited for before it is looked up again.
+func (e *Engine) lockOrder(id string) (*managedGroup, bool) {
+	e.mu.Lock()
+	groupID, ok := e.orders[id]
+	e.mu.Unlock()
+
+	if !ok {
+		e.placing.Lock()
+		e.placing.Unlock()
+
+		e.mu.Lock()
+		groupID, ok = e.orders[id]
+		e.mu.Unlock()
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return e.lock(groupID)
+}
+
+func (e *Engine) emit(events []Event) {
+	e.mu.Lock()
+	handlers := e.handlers
+	e.mu.Unlock()
+
+	for _, event := range events {
+		for _, handler := range handlers {
+			handler(event)
+		}
+	}
+}
+
+func newGroup(groupType GroupType) *Group {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+
+	now := time.Now()
+	return &Group{
+		ID:        hex.EncodeToString(b),
+		Type:      groupType,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
@@ -0,0 +1,394 @@
+package oco_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/oco"
+)
+
+type fakeExchange struct {
+	mu        sync.Mutex
+	next      int
+	orders    map[string]coinbasepro.OrderDetail
+	done      map[string]coinbasepro.OrderDetail
+	cancelled []string
+	// onCancel is called before an order is cancelled.
+	onCancel func(id string)
+}
+
+func newFakeExchange() *fakeExchange {
+	return &fakeExchange{orders: make(map[string]coinbasepro.OrderDetail), done: make(map[string]coinbasepro.OrderDetail)}
+}
+
+func (f *fakeExchange) PlaceOrder(_ context.Context, newOrder coinbasepro.CreateOrderRequest) (coinbasepro.OrderDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.next++
+	order := coinbasepro.OrderDetail{
+		ID:        fmt.Sprintf("order-%d", f.next),
+		ProductID: newOrder.ProductID,
+		Side:      newOrder.Side,
+		Type:      newOrder.Type,
+		Price:     newOrder.Price,
+		Size:      newOrder.Size,
+		Status:    coinbasepro.OrderStatusOpen,
+	}
+	f.orders[order.ID] = order
+
+	return order, nil
+}
+
+// CancelOrder removes an order, like the exchange a partially filled order is kept as done.
+func (f *fakeExchange) CancelOrder(_ context.Context, id string, _ ...coinbasepro.CancelOrderParams) error {
+	if f.onCancel != nil {
+		f.onCancel(id)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[id]
+	if !ok {
+		return coinbasepro.ErrOrderNotFound
+	}
+	delete(f.orders, id)
+	f.cancelled = append(f.cancelled, id)
+
+	if order.FilledSize != "" {
+		order.Status = coinbasepro.OrderStatusDone
+		order.DoneReason = "canceled"
+		f.done[id] = order
+	}
+
+	return nil
+}
+
+func (f *fakeExchange) GetOrderDetail(_ context.Context, id string) (coinbasepro.OrderDetail, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[id]
+	if !ok {
+		if order, ok = f.done[id]; !ok {
+			return order, coinbasepro.ErrOrderNotFound
+		}
+	}
+
+	return order, nil
+}
+
+// fill fills size of an open order without a message, like a fill whose message has not arrived yet.
+func (f *fakeExchange) fill(id, size string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order := f.orders[id]
+	order.FilledSize = coinbasepro.Decimal(size)
+	f.orders[id] = order
+}
+
+func (f *fakeExchange) order(id string) (coinbasepro.OrderDetail, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	order, ok := f.orders[id]
+	return order, ok
+}
+
+func match(orderID string, tradeID int, size string) coinbasepro.Message {
+	return coinbasepro.Message{Type: "match", MakerOrderID: orderID, TakerOrderID: "other", TradeID: tradeID, Size: size}
+}
+
+func done(orderID, reason string) coinbasepro.Message {
+	return coinbasepro.Message{Type: "done", OrderID: orderID, Reason: reason}
+}
+
+func TestEngineOCO(t *testing.T) {
+	exchange := newFakeExchange()
+	engine, err := oco.NewEngine(exchange, oco.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	takeProfit := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "110.00", "2", nil)
+	stopLoss := coinbasepro.NewStopLimitOrder("BTC-USD", coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "2")
+
+	group, err := engine.PlaceOCO(context.Background(), takeProfit, stopLoss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tp, sl := group.Legs[0].OrderID, group.Legs[1].OrderID
+
+	// A partial fill of the take profit reduces the stop loss to the remaining position.
+	if err := engine.HandleMessage(match(tp, 1, "0.5")); err != nil {
+		t.Fatal(err)
+	}
+	// Duplicate messages are ignored.
+	if err := engine.HandleMessage(match(tp, 1, "0.5")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = engine.Group(group.ID)
+	if group.Position() != "1.5" {
+		t.Fatalf("expected position 1.5, got %s", group.Position())
+	}
+
+	if _, ok := exchange.order(sl); ok {
+		t.Error("expected the original stop loss to be cancelled")
+	}
+
+	resized, ok := exchange.order(group.Legs[1].OrderID)
+	if !ok || resized.Size != "1.5" {
+		t.Errorf("expected stop loss of 1.5, got %+v", resized)
+	}
+
+	if err := engine.HandleMessage(match(tp, 2, "1.5")); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.HandleMessage(done(tp, "filled")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = engine.Group(group.ID)
+	if !group.Done {
+		t.Fatal("expected group to be done")
+	}
+	if group.Legs[0].Status != oco.LegFilled || group.Legs[1].Status != oco.LegCancelled {
+		t.Errorf("unexpected leg status %s %s", group.Legs[0].Status, group.Legs[1].Status)
+	}
+	if _, ok := exchange.order(group.Legs[1].OrderID); ok {
+		t.Error("expected the stop loss to be cancelled")
+	}
+}
+
+func TestEngineBracket(t *testing.T) {
+	exchange := newFakeExchange()
+	store := oco.NewMemoryStore()
+
+	engine, err := oco.NewEngine(exchange, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var events []oco.EventType
+	engine.OnEvent(func(event oco.Event) {
+		events = append(events, event.Type)
+	})
+
+	entry := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "1", nil)
+	takeProfit := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "110.00", "1", nil)
+	stopLoss := coinbasepro.NewStopLimitOrder("BTC-USD", coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "1")
+
+	group, err := engine.PlaceBracket(context.Background(), entry, takeProfit, stopLoss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entryID := group.Legs[0].OrderID
+	if group.Legs[1].Status != oco.LegPending || group.Legs[2].Status != oco.LegPending {
+		t.Fatal("expected exits to wait for the entry")
+	}
+
+	if err := engine.HandleMessage(match(entryID, 1, "0.4")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = engine.Group(group.ID)
+	for _, leg := range group.Legs[1:] {
+		order, ok := exchange.order(leg.OrderID)
+		if !ok || order.Size != "0.4" {
+			t.Errorf("expected %s of 0.4, got %+v", leg.Role, order)
+		}
+	}
+
+	// The engine resumes from the store after a restart.
+	restored, err := oco.NewEngine(exchange, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restored.Restore(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	tp, _ := group.Leg(oco.LegTakeProfit)
+	if err := restored.HandleMessage(match(tp.OrderID, 2, "0.4")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = restored.Group(group.ID)
+	if !group.Done {
+		t.Fatal("expected group to be done")
+	}
+	if group.Legs[0].Status != oco.LegCancelled {
+		t.Errorf("expected the rest of the entry to be cancelled, got %s", group.Legs[0].Status)
+	}
+	if _, ok := exchange.order(group.Legs[2].OrderID); ok {
+		t.Error("expected the stop loss to be cancelled")
+	}
+
+	if groups, _ := store.Load(context.Background()); len(groups) != 0 {
+		t.Errorf("expected done group to be removed from the store, got %d", len(groups))
+	}
+
+	expected := []oco.EventType{oco.EventLegPlaced, oco.EventLegFilled, oco.EventLegPlaced, oco.EventLegPlaced}
+	if fmt.Sprint(events) != fmt.Sprint(expected) {
+		t.Errorf("expected events %v, got %v", expected, events)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	store, err := oco.NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	group := oco.Group{ID: "g1", Type: oco.GroupOCO, Size: "1", Legs: []oco.Leg{{Role: oco.LegExit, OrderID: "o1", Status: oco.LegOpen}}}
+	if err := store.Save(context.Background(), group); err != nil {
+		t.Fatal(err)
+	}
+
+	groups, err := store.Load(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 1 || groups[0].Legs[0].OrderID != "o1" {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	if err := store.Delete(context.Background(), "g1"); err != nil {
+		t.Fatal(err)
+	}
+	if groups, _ = store.Load(context.Background()); len(groups) != 0 {
+		t.Errorf("expected no groups, got %d", len(groups))
+	}
+}
+
+func TestEngineResizeAfterUnhandledFill(t *testing.T) {
+	exchange := newFakeExchange()
+	engine, err := oco.NewEngine(exchange, oco.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	takeProfit := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "110.00", "2", nil)
+	stopLoss := coinbasepro.NewStopLimitOrder("BTC-USD", coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "2")
+
+	group, err := engine.PlaceOCO(context.Background(), takeProfit, stopLoss)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tp, sl := group.Legs[0].OrderID, group.Legs[1].OrderID
+
+	// The stop loss fills while it is replaced, its match message arrives after the resize.
+	exchange.fill(sl, "0.25")
+	if err := engine.HandleMessage(match(tp, 1, "0.5")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = engine.Group(group.ID)
+	if resized, ok := exchange.order(group.Legs[1].OrderID); !ok || resized.Size != "1.25" {
+		t.Fatalf("expected stop loss of 1.25, got %+v", resized)
+	}
+
+	if err := engine.HandleMessage(match(sl, 2, "0.25")); err != nil {
+		t.Fatal(err)
+	}
+
+	group, _ = engine.Group(group.ID)
+	if group.Position() != "1.25" {
+		t.Fatalf("expected position 1.25, got %s", group.Position())
+	}
+	for _, leg := range group.Legs {
+		if order, ok := exchange.order(leg.OrderID); !ok || order.Size != "1.25" {
+			t.Errorf("expected %s of 1.25, got %+v", leg.OrderID, order)
+		}
+	}
+}
+
+type failingStore struct {
+	*oco.MemoryStore
+	err error
+}
+
+func (s failingStore) Save(context.Context, oco.Group) error {
+	return s.err
+}
+
+func TestEngineStoreError(t *testing.T) {
+	storeErr := errors.New("disk full")
+	engine, err := oco.NewEngine(newFakeExchange(), failingStore{MemoryStore: oco.NewMemoryStore(), err: storeErr})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	takeProfit := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "110.00", "2", nil)
+	stopLoss := coinbasepro.NewStopLimitOrder("BTC-USD", coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "2")
+
+	group, err := engine.PlaceOCO(context.Background(), takeProfit, stopLoss)
+	if !errors.Is(err, storeErr) {
+		t.Fatalf("expected the store error, got %v", err)
+	}
+
+	if err := engine.HandleMessage(match(group.Legs[0].OrderID, 1, "0.5")); !errors.Is(err, storeErr) {
+		t.Fatalf("expected the store error, got %v", err)
+	}
+
+	// The engine keeps managing the group.
+	if group, _ = engine.Group(group.ID); group.Position() != "1.5" {
+		t.Errorf("expected position 1.5, got %s", group.Position())
+	}
+}
+
+func TestEngineGroupsAreIndependent(t *testing.T) {
+	exchange := newFakeExchange()
+	engine, err := oco.NewEngine(exchange, oco.NewMemoryStore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	place := func(productID string) oco.Group {
+		takeProfit := coinbasepro.NewLimitOrder(productID, coinbasepro.SideSell, "110.00", "2", nil)
+		stopLoss := coinbasepro.NewStopLimitOrder(productID, coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "2")
+
+		group, err := engine.PlaceOCO(context.Background(), takeProfit, stopLoss)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return group
+	}
+	first, second := place("BTC-USD"), place("ETH-USD")
+
+	cancelling, release := make(chan struct{}), make(chan struct{})
+	exchange.onCancel = func(string) {
+		close(cancelling)
+		<-release
+	}
+
+	handled := make(chan error, 1)
+	go func() {
+		handled <- engine.HandleMessage(match(first.Legs[0].OrderID, 1, "0.5"))
+	}()
+
+	// While the first group waits for its cancel the second one can be read and cancelled.
+	<-cancelling
+	exchange.onCancel = nil
+	if group, ok := engine.Group(second.ID); !ok || group.Done {
+		t.Errorf("unexpected group %+v", group)
+	}
+	if err := engine.Cancel(context.Background(), second.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	close(release)
+	if err := <-handled; err != nil {
+		t.Fatal(err)
+	}
+}
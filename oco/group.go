@@ -0,0 +1,150 @@
+package oco
+
+import (
+	"math/big"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+type (
+	GroupType string
+	LegRole   string
+	LegStatus string
+)
+
+const (
+	// GroupOCO is a pair of exit orders where a fill of one reduces or cancels the other.
+	GroupOCO GroupType = "oco"
+	// GroupBracket is an entry order followed by a take profit and a stop loss for the filled size.
+	GroupBracket GroupType = "bracket"
+
+	LegEntry      LegRole = "entry"
+	LegTakeProfit LegRole = "take_profit"
+	LegStopLoss   LegRole = "stop_loss"
+	LegExit       LegRole = "exit"
+
+	// LegPending legs have not been placed yet.
+	LegPending   LegStatus = "pending"
+	LegOpen      LegStatus = "open"
+	LegFilled    LegStatus = "filled"
+	LegCancelled LegStatus = "cancelled"
+)
+
+// Leg is a single order of a group. The order can be replaced when its size is adjusted, so OrderID changes over
+// the life of the leg while FilledSize accumulates across all of its orders.
+type Leg struct {
+	Role    LegRole                        `json:"role"`
+	Order   coinbasepro.CreateOrderRequest `json:"order"`
+	OrderID string                         `json:"order_id,omitempty"`
+	// PreviousOrderIDs are the replaced orders of the leg, late fills of these orders are still counted.
+	PreviousOrderIDs []string  `json:"previous_order_ids,omitempty"`
+	Status           LegStatus `json:"status"`
+	FilledSize       string    `json:"filled_size"`
+	// OrderFilledSize is the filled size of the current order.
+	OrderFilledSize string `json:"order_filled_size"`
+	TradeIDs        []int  `json:"trade_ids,omitempty"`
+}
+
+// Group is a set of linked orders managed by the Engine.
+type Group struct {
+	ID   string    `json:"id"`
+	Type GroupType `json:"type"`
+	// Size is the position covered by the exit legs of an OCO group.
+	Size      string    `json:"size,omitempty"`
+	Legs      []Leg     `json:"legs"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Leg returns the first leg with role.
+func (g *Group) Leg(role LegRole) (*Leg, bool) {
+	for i := range g.Legs {
+		if g.Legs[i].Role == role {
+			return &g.Legs[i], true
+		}
+	}
+
+	return nil, false
+}
+
+// Position returns the size which is still covered by the open exit legs.
+func (g *Group) Position() string {
+	return decimal.String(g.position())
+}
+
+func (g *Group) position() *big.Rat {
+	position := new(big.Rat)
+
+	switch g.Type {
+	case GroupBracket:
+		if entry, ok := g.Leg(LegEntry); ok {
+			position.Set(decimal.OrZero(entry.FilledSize))
+		}
+	default:
+		position.Set(decimal.OrZero(g.Size))
+	}
+
+	for _, leg := range g.Legs {
+		if leg.Role != LegEntry {
+			position.Sub(position, decimal.OrZero(leg.FilledSize))
+		}
+	}
+
+	if position.Sign() < 0 {
+		position.SetInt64(0)
+	}
+
+	return position
+}
+
+func (g *Group) clone() Group {
+	c := *g
+	c.Legs = make([]Leg, len(g.Legs))
+	for i, leg := range g.Legs {
+		leg.PreviousOrderIDs = append([]string(nil), leg.PreviousOrderIDs...)
+		leg.TradeIDs = append([]int(nil), leg.TradeIDs...)
+		c.Legs[i] = leg
+	}
+
+	return c
+}
+
+func (l *Leg) final() bool {
+	return l.Status == LegFilled || l.Status == LegCancelled
+}
+
+func (l *Leg) ownsOrder(id string) bool {
+	if l.OrderID == id {
+		return true
+	}
+
+	for _, previous := range l.PreviousOrderIDs {
+		if previous == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (l *Leg) seen(tradeID int) bool {
+	for _, id := range l.TradeIDs {
+		if id == tradeID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// remaining returns the unfilled size of the current order.
+func (l *Leg) remaining() *big.Rat {
+	return new(big.Rat).Sub(decimal.OrZero(l.Order.Size), decimal.OrZero(l.OrderFilledSize))
+}
+
+func addSize(a, b string) string {
+	return decimal.String(new(big.Rat).Add(decimal.OrZero(a), decimal.OrZero(b)))
+}
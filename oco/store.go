@@ -0,0 +1,131 @@
+package oco
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Store persists groups so the engine can resume managing them after a restart.
+type Store interface {
+	Save(ctx context.Context, group Group) error
+	Delete(ctx context.Context, id string) error
+	Load(ctx context.Context) ([]Group, error)
+}
+
+// MemoryStore keeps groups in memory, it does not survive restarts and is mostly useful for tests.
+type MemoryStore struct {
+	mu     sync.Mutex
+	groups map[string]Group
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{groups: make(map[string]Group)}
+}
+
+func (s *MemoryStore) Save(_ context.Context, group Group) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.groups[group.ID] = group.clone()
+	return nil
+}
+
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.groups, id)
+	return nil
+}
+
+func (s *MemoryStore) Load(_ context.Context) ([]Group, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	groups := make([]Group, 0, len(s.groups))
+	for _, group := range s.groups {
+		groups = append(groups, group.clone())
+	}
+
+	return groups, nil
+}
+
+// FileStore keeps every group as a json file in a directory.
+type FileStore struct {
+	dir string
+}
+
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		return nil, errors.New("dir cannot be empty")
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	return &FileStore{dir: dir}, nil
+}
+
+func (s *FileStore) Save(_ context.Context, group Group) error {
+	data, err := json.Marshal(group)
+	if err != nil {
+		return fmt.Errorf("failed to marshal group: %w", err)
+	}
+
+	path := s.path(group.ID)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write group: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write group: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) Delete(_ context.Context, id string) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+
+	return nil
+}
+
+func (s *FileStore) Load(_ context.Context) ([]Group, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read store directory: %w", err)
+	}
+
+	var groups []Group
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read group: %w", err)
+		}
+
+		var group Group
+		if err := json.Unmarshal(data, &group); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal group %s: %w", entry.Name(), err)
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
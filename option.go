@@ -56,3 +56,40 @@ func WithTimeOffsetSeconds(offset int) ClientOption {
 		return nil
 	}
 }
+
+// WithBaseURL sets the URL of the REST API, it can be used to send requests through a proxy or to a fake exchange.
+func WithBaseURL(baseURL string) ClientOption {
+	return func(c *client) error {
+		if baseURL == "" {
+			return errors.New("baseURL cannot be empty")
+		}
+		c.baseURL = baseURL
+
+		return nil
+	}
+}
+
+// WithWebsocketURL sets the URL of the websocket feed used by Subscribe.
+func WithWebsocketURL(websocketURL string) ClientOption {
+	return func(c *client) error {
+		if websocketURL == "" {
+			return errors.New("websocketURL cannot be empty")
+		}
+		c.websocketURL = websocketURL
+
+		return nil
+	}
+}
+
+// WithWithdrawalGuard checks every CreateWithdrawalCrypto against guard before it is sent, rejected withdrawals
+// return a WithdrawalRejectedError.
+func WithWithdrawalGuard(guard *WithdrawalGuard) ClientOption {
+	return func(c *client) error {
+		if guard == nil {
+			return errors.New("guard cannot be nil")
+		}
+		c.withdrawalGuard = guard
+
+		return nil
+	}
+}
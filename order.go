@@ -2,79 +2,420 @@ package coinbasepro
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
+// ErrOrderNotFound matches every OrderNotFoundError when used with errors.Is.
+var ErrOrderNotFound = errors.New("order not found")
+
+type (
+	OrderType           string
+	Side                string
+	SelfTradePrevention string
+	StopType            string
+	TimeInForce         string
+	CancelAfter         string
+	OrderStatus         string
+)
+
+const (
+	OrderTypeLimit  OrderType = "limit"
+	OrderTypeMarket OrderType = "market"
+	OrderTypeStop   OrderType = "stop"
+
+	SideBuy  Side = "buy"
+	SideSell Side = "sell"
+
+	STPDecreaseAndCancel SelfTradePrevention = "dc"
+	STPCancelOldest      SelfTradePrevention = "co"
+	STPCancelNewest      SelfTradePrevention = "cn"
+	STPCancelBoth        SelfTradePrevention = "cb"
+
+	// StopLoss triggers when the last trade price is at or below the stop price.
+	StopLoss StopType = "loss"
+	// StopEntry triggers when the last trade price is at or above the stop price.
+	StopEntry StopType = "entry"
+
+	TIFGoodTillCancelled TimeInForce = "GTC"
+	TIFGoodTillTime      TimeInForce = "GTT"
+	TIFImmediateOrCancel TimeInForce = "IOC"
+	TIFFillOrKill        TimeInForce = "FOK"
+
+	CancelAfterMinute CancelAfter = "min"
+	CancelAfterHour   CancelAfter = "hour"
+	CancelAfterDay    CancelAfter = "day"
+
+	OrderStatusOpen     OrderStatus = "open"
+	OrderStatusPending  OrderStatus = "pending"
+	OrderStatusReceived OrderStatus = "received"
+	OrderStatusRejected OrderStatus = "rejected"
+	OrderStatusDone     OrderStatus = "done"
+	OrderStatusActive   OrderStatus = "active"
+	OrderStatusAll      OrderStatus = "all"
+)
+
+// CreateOrderRequest is the body of a new order. The constructors NewLimitOrder, NewMarketOrderByFunds,
+// NewMarketOrderBySize and NewStopLimitOrder only allow valid combinations of fields.
+type CreateOrderRequest struct {
+	Type      OrderType           `json:"type"`
+	Side      Side                `json:"side"`
+	ProductID string              `json:"product_id"`
+	ClientOID string              `json:"client_oid,omitempty"`
+	Stp       SelfTradePrevention `json:"stp,omitempty"`
+	Stop      StopType            `json:"stop,omitempty"`
+	StopPrice string              `json:"stop_price,omitempty"`
+	Size      string              `json:"size,omitempty"`
+	// Limit Order
+	Price       string      `json:"price,omitempty"`
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"`
+	PostOnly    bool        `json:"post_only,omitempty"`
+	CancelAfter CancelAfter `json:"cancel_after,omitempty"`
+	// Market Order
+	Funds string `json:"funds,omitempty"`
+	// ProfileID defaults to the profile of a client scoped with ForProfile, or the default profile.
+	ProfileID string `json:"profile_id,omitempty"`
+}
+
+// OrderDetail is an order as returned by the API.
+type OrderDetail struct {
+	ID             string              `json:"id"`
+	ClientOID      string              `json:"client_oid"`
+	ProfileID      string              `json:"profile_id"`
+	ProductID      string              `json:"product_id"`
+	Type           OrderType           `json:"type"`
+	Side           Side                `json:"side"`
+	Price          string              `json:"price"`
+	Size           string              `json:"size"`
+	Funds          string              `json:"funds"`
+	SpecifiedFunds string              `json:"specified_funds"`
+	Stp            SelfTradePrevention `json:"stp"`
+	Stop           StopType            `json:"stop"`
+	StopPrice      Decimal             `json:"stop_price"`
+	TimeInForce    TimeInForce         `json:"time_in_force"`
+	PostOnly       bool                `json:"post_only"`
+	ExpireTime     Time                `json:"expire_time,string"`
+	FundingAmount  Decimal             `json:"funding_amount"`
+	Status         OrderStatus         `json:"status"`
+	Settled        bool                `json:"settled"`
+	DoneReason     string              `json:"done_reason"`
+	RejectReason   string              `json:"reject_reason"`
+	CreatedAt      Time                `json:"created_at,string"`
+	DoneAt         Time                `json:"done_at,string"`
+	FillFees       Decimal             `json:"fill_fees"`
+	FilledSize     Decimal             `json:"filled_size"`
+	ExecutedValue  Decimal             `json:"executed_value"`
+}
+
+// Order is used both as request and response by CreateOrder and GetOrder.
+//
+// Deprecated: use CreateOrderRequest with PlaceOrder and OrderDetail with GetOrderDetail instead. Order.Request
+// converts an existing order to a CreateOrderRequest.
 type Order struct {
-	Type      string `json:"type"`
-	Size      string `json:"size,omitempty"`
-	Side      string `json:"side"`
-	ProductID string `json:"product_id"`
-	ClientOID string `json:"client_oid,omitempty"`
-	Stp       string `json:"stp,omitempty"`
-	Stop      string `json:"stop,omitempty"`
-	StopPrice string `json:"stop_price,omitempty"`
+	Type      OrderType           `json:"type"`
+	Size      string              `json:"size,omitempty"`
+	Side      Side                `json:"side"`
+	ProductID string              `json:"product_id"`
+	ClientOID string              `json:"client_oid,omitempty"`
+	Stp       SelfTradePrevention `json:"stp,omitempty"`
+	Stop      StopType            `json:"stop,omitempty"`
+	StopPrice string              `json:"stop_price,omitempty"`
 	// Limit Order
-	Price       string `json:"price,omitempty"`
-	TimeInForce string `json:"time_in_force,omitempty"`
-	PostOnly    bool   `json:"post_only,omitempty"`
-	CancelAfter string `json:"cancel_after,omitempty"`
+	Price       string      `json:"price,omitempty"`
+	TimeInForce TimeInForce `json:"time_in_force,omitempty"`
+	PostOnly    bool        `json:"post_only,omitempty"`
+	CancelAfter CancelAfter `json:"cancel_after,omitempty"`
 	// Market Order
 	Funds          string `json:"funds,omitempty"`
 	SpecifiedFunds string `json:"specified_funds,omitempty"`
 	// Response Fields
-	ID            string `json:"id"`
-	Status        string `json:"status,omitempty"`
-	Settled       bool   `json:"settled,omitempty"`
-	DoneReason    string `json:"done_reason,omitempty"`
-	DoneAt        Time   `json:"done_at,string,omitempty"`
-	CreatedAt     Time   `json:"created_at,string,omitempty"`
-	FillFees      string `json:"fill_fees,omitempty"`
-	FilledSize    string `json:"filled_size,omitempty"`
-	ExecutedValue string `json:"executed_value,omitempty"`
+	ID            string      `json:"id,omitempty"`
+	Status        OrderStatus `json:"status,omitempty"`
+	Settled       bool        `json:"settled,omitempty"`
+	DoneReason    string      `json:"done_reason,omitempty"`
+	DoneAt        Time        `json:"done_at,string,omitempty"`
+	CreatedAt     Time        `json:"created_at,string,omitempty"`
+	FillFees      string      `json:"fill_fees,omitempty"`
+	FilledSize    string      `json:"filled_size,omitempty"`
+	ExecutedValue string      `json:"executed_value,omitempty"`
+}
+
+// LimitTimeInForce is the time in force policy of a limit order, see GoodTillCancelled, GoodTillTime,
+// ImmediateOrCancel and FillOrKill.
+type LimitTimeInForce interface {
+	apply(o *CreateOrderRequest)
+}
+
+// RestingTimeInForce is a time in force policy which can rest on the book and can therefore be post only.
+type RestingTimeInForce struct {
+	timeInForce TimeInForce
+	cancelAfter CancelAfter
+	postOnly    bool
+}
+
+type immediateTimeInForce TimeInForce
+
+// GoodTillCancelled keeps the order on the book until it is filled or cancelled.
+func GoodTillCancelled() RestingTimeInForce {
+	return RestingTimeInForce{timeInForce: TIFGoodTillCancelled}
+}
+
+// GoodTillTime keeps the order on the book until it is filled, cancelled or cancelAfter has passed.
+func GoodTillTime(cancelAfter CancelAfter) RestingTimeInForce {
+	return RestingTimeInForce{timeInForce: TIFGoodTillTime, cancelAfter: cancelAfter}
+}
+
+// ImmediateOrCancel fills as much of the order as possible immediately and cancels the remainder.
+func ImmediateOrCancel() LimitTimeInForce {
+	return immediateTimeInForce(TIFImmediateOrCancel)
+}
+
+// FillOrKill cancels the order unless it can be filled completely and immediately.
+func FillOrKill() LimitTimeInForce {
+	return immediateTimeInForce(TIFFillOrKill)
+}
+
+// PostOnly returns a copy of the policy which only adds liquidity, the order is rejected if it would match
+// immediately.
+func (t RestingTimeInForce) PostOnly() RestingTimeInForce {
+	t.postOnly = true
+	return t
+}
+
+func (t RestingTimeInForce) apply(o *CreateOrderRequest) {
+	o.TimeInForce = t.timeInForce
+	o.CancelAfter = t.cancelAfter
+	o.PostOnly = t.postOnly
+}
+
+func (t immediateTimeInForce) apply(o *CreateOrderRequest) {
+	o.TimeInForce = TimeInForce(t)
+}
+
+// NewLimitOrder creates a limit order, tif defaults to GoodTillCancelled when nil.
+func NewLimitOrder(productID string, side Side, price, size string, tif LimitTimeInForce) CreateOrderRequest {
+	o := CreateOrderRequest{
+		Type:      OrderTypeLimit,
+		ProductID: productID,
+		Side:      side,
+		Price:     price,
+		Size:      size,
+	}
+
+	if tif == nil {
+		tif = GoodTillCancelled()
+	}
+	tif.apply(&o)
+
+	return o
+}
+
+// NewMarketOrderByFunds creates a market order which spends (buy) or receives (sell) the amount of quote currency
+// in funds.
+func NewMarketOrderByFunds(productID string, side Side, funds string) CreateOrderRequest {
+	return CreateOrderRequest{
+		Type:      OrderTypeMarket,
+		ProductID: productID,
+		Side:      side,
+		Funds:     funds,
+	}
+}
+
+// NewMarketOrderBySize creates a market order which buys or sells size of the base currency.
+func NewMarketOrderBySize(productID string, side Side, size string) CreateOrderRequest {
+	return CreateOrderRequest{
+		Type:      OrderTypeMarket,
+		ProductID: productID,
+		Side:      side,
+		Size:      size,
+	}
+}
+
+// NewStopLimitOrder creates a limit order which is placed on the book at price once the last trade price passes
+// stopPrice in the direction of stop.
+func NewStopLimitOrder(productID string, side Side, stop StopType, stopPrice, price, size string) CreateOrderRequest {
+	return CreateOrderRequest{
+		Type:      OrderTypeLimit,
+		ProductID: productID,
+		Side:      side,
+		Stop:      stop,
+		StopPrice: stopPrice,
+		Price:     price,
+		Size:      size,
+	}
+}
+
+// Request returns the fields of the order which can be sent to PlaceOrder.
+func (o Order) Request() CreateOrderRequest {
+	return CreateOrderRequest{
+		Type:        o.Type,
+		Side:        o.Side,
+		ProductID:   o.ProductID,
+		ClientOID:   o.ClientOID,
+		Stp:         o.Stp,
+		Stop:        o.Stop,
+		StopPrice:   o.StopPrice,
+		Size:        o.Size,
+		Price:       o.Price,
+		TimeInForce: o.TimeInForce,
+		PostOnly:    o.PostOnly,
+		CancelAfter: o.CancelAfter,
+		Funds:       o.Funds,
+	}
+}
+
+// OrderNotFoundError is returned when an order does not exist. Orders which are cancelled before any fills are
+// removed by the exchange and are also not found.
+type OrderNotFoundError struct {
+	ID        string
+	ClientOID string
+	Message   string
+
+	err error
+}
+
+func (e OrderNotFoundError) Error() string {
+	if e.ClientOID != "" {
+		return fmt.Sprintf("order with client_oid %s not found: %s", e.ClientOID, e.Message)
+	}
+
+	return fmt.Sprintf("order %s not found: %s", e.ID, e.Message)
+}
+
+func (e OrderNotFoundError) Is(target error) bool {
+	return target == ErrOrderNotFound
+}
+
+// Unwrap returns the error response of the exchange.
+func (e OrderNotFoundError) Unwrap() error {
+	return e.err
+}
+
+// orderNotFound converts a 404 response on an order route into an OrderNotFoundError.
+func orderNotFound(res *http.Response, err error, id, clientOID string) error {
+	var coinbaseErr Error
+	if res == nil || res.StatusCode != http.StatusNotFound || !errors.As(err, &coinbaseErr) {
+		return err
+	}
+
+	return OrderNotFoundError{ID: id, ClientOID: clientOID, Message: coinbaseErr.Message, err: coinbaseErr}
+}
+
+type CancelOrderParams struct {
+	// ProductID is optional, it makes the request more efficient.
+	ProductID string
 }
 
 type CancelAllOrdersParams struct {
 	ProductID string
+	ProfileID string
 }
 
 type ListOrdersParams struct {
-	Status     string
+	Status     OrderStatus
 	ProductID  string
+	ProfileID  string
 	Pagination PaginationParams
 }
 
-func (c *client) CreateOrder(ctx context.Context, newOrder Order) (Order, error) {
-	var savedOrder Order
+// PlaceOrder submits a new order, Type defaults to limit.
+func (c *client) PlaceOrder(ctx context.Context, newOrder CreateOrderRequest) (OrderDetail, error) {
+	var savedOrder OrderDetail
 
 	if len(newOrder.Type) == 0 {
-		newOrder.Type = "limit"
+		newOrder.Type = OrderTypeLimit
 	}
+	newOrder.ProfileID = c.profile(newOrder.ProfileID)
 
 	url := fmt.Sprintf("/orders")
 	_, err := c.Request(ctx, http.MethodPost, url, newOrder, &savedOrder)
 	return savedOrder, err
 }
 
-func (c *client) CancelOrder(ctx context.Context, id string) error {
-	url := fmt.Sprintf("/orders/%s", id)
-	_, err := c.Request(ctx, http.MethodDelete, url, nil, nil)
-	return err
+// CreateOrder submits the request fields of newOrder, Type defaults to limit.
+//
+// Deprecated: use PlaceOrder instead.
+func (c *client) CreateOrder(ctx context.Context, newOrder Order) (Order, error) {
+	var savedOrder Order
+
+	request := newOrder.Request()
+	if len(request.Type) == 0 {
+		request.Type = OrderTypeLimit
+	}
+	request.ProfileID = c.profile(request.ProfileID)
+
+	url := fmt.Sprintf("/orders")
+	_, err := c.Request(ctx, http.MethodPost, url, request, &savedOrder)
+	return savedOrder, err
+}
+
+func (c *client) CancelOrder(ctx context.Context, id string, p ...CancelOrderParams) error {
+	requestURL := fmt.Sprintf("/orders/%s", url.PathEscape(id))
+	requestURL = cancelOrderURL(requestURL, p)
+
+	res, err := c.Request(ctx, http.MethodDelete, requestURL, nil, nil)
+	return orderNotFound(res, err, id, "")
+}
+
+// CancelOrderByClientOID cancels the order which was created with clientOID.
+func (c *client) CancelOrderByClientOID(ctx context.Context, clientOID string, p ...CancelOrderParams) error {
+	requestURL := fmt.Sprintf("/orders/client:%s", url.PathEscape(clientOID))
+	requestURL = cancelOrderURL(requestURL, p)
+
+	res, err := c.Request(ctx, http.MethodDelete, requestURL, nil, nil)
+	return orderNotFound(res, err, "", clientOID)
+}
+
+func cancelOrderURL(requestURL string, p []CancelOrderParams) string {
+	if len(p) > 0 && p[0].ProductID != "" {
+		requestURL = fmt.Sprintf("%s?product_id=%s", requestURL, url.QueryEscape(p[0].ProductID))
+	}
+
+	return requestURL
 }
 
 func (c *client) CancelAllOrders(ctx context.Context, p CancelAllOrdersParams) ([]string, error) {
 	var orderIDs []string
-	url := "/orders"
+	requestURL := "/orders"
 
+	query := url.Values{}
 	if p.ProductID != "" {
-		url = fmt.Sprintf("%s?product_id=%s", url, p.ProductID)
+		query.Set("product_id", p.ProductID)
+	}
+	if profileID := c.profile(p.ProfileID); profileID != "" {
+		query.Set("profile_id", profileID)
+	}
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
 	}
 
-	_, err := c.Request(ctx, http.MethodDelete, url, nil, &orderIDs)
+	_, err := c.Request(ctx, http.MethodDelete, requestURL, nil, &orderIDs)
 	return orderIDs, err
 }
 
+// GetOrderDetail retrieves a single order
+func (c *client) GetOrderDetail(ctx context.Context, id string) (OrderDetail, error) {
+	var savedOrder OrderDetail
+
+	requestURL := fmt.Sprintf("/orders/%s", url.PathEscape(id))
+	res, err := c.Request(ctx, http.MethodGet, requestURL, nil, &savedOrder)
+	return savedOrder, orderNotFound(res, err, id, "")
+}
+
+// GetOrderByClientOID retrieves the order which was created with clientOID
+func (c *client) GetOrderByClientOID(ctx context.Context, clientOID string) (OrderDetail, error) {
+	var savedOrder OrderDetail
+
+	requestURL := fmt.Sprintf("/orders/client:%s", url.PathEscape(clientOID))
+	res, err := c.Request(ctx, http.MethodGet, requestURL, nil, &savedOrder)
+	return savedOrder, orderNotFound(res, err, "", clientOID)
+}
+
+// GetOrder retrieves a single order
+//
+// Deprecated: use GetOrderDetail instead.
 func (c *client) GetOrder(ctx context.Context, id string) (Order, error) {
 	var savedOrder Order
 
@@ -88,11 +429,14 @@ func (c *client) ListOrders(p ListOrdersParams) *Cursor {
 	paginationParams = p.Pagination
 
 	if p.Status != "" {
-		paginationParams.AddExtraParam("status", p.Status)
+		paginationParams.AddExtraParam("status", string(p.Status))
 	}
 	if p.ProductID != "" {
 		paginationParams.AddExtraParam("product_id", p.ProductID)
 	}
+	if profileID := c.profile(p.ProfileID); profileID != "" {
+		paginationParams.AddExtraParam("profile_id", profileID)
+	}
 
 	return c.newCursor(http.MethodGet, fmt.Sprintf("/orders"), paginationParams)
 }
@@ -0,0 +1,209 @@
+package coinbasepro
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// ErrOrderDone is returned by ReplaceOrder when the order is already done and cannot be replaced.
+var ErrOrderDone = errors.New("order is already done")
+
+// OrderChanges describes the replacement of a resting limit order, empty fields keep the value of the original order.
+type OrderChanges struct {
+	Price string
+	// Size is the new total size, including what the original order has filled. The replacement is placed for the
+	// remainder so a partially filled order isn't over-filled.
+	Size string
+	// ClientOID of the replacement. When empty and the original order has a client oid, the replacement gets the
+	// ReplacementClientOID of the original one.
+	ClientOID string
+	// CancelAfter is required when the original order is good till time, the exchange doesn't return it.
+	CancelAfter CancelAfter
+	// PollInterval is the interval between checks whether the cancel has been confirmed, defaults to 100ms.
+	PollInterval time.Duration
+}
+
+// ReplacedOrder is the result of ReplaceOrder.
+type ReplacedOrder struct {
+	// Order is the replacement, its ID is empty when nothing remained to be placed.
+	Order OrderDetail
+	// Replaced is the final state of the original order.
+	Replaced OrderDetail
+	// Fills contains the fills of the original order which happened between the request and the confirmed cancel.
+	Fills []Fill
+}
+
+// ReplaceOrder amends a resting limit order by cancelling it, waiting until the cancel is confirmed and placing a
+// replacement with the same product and side for the remaining size.
+func (c *client) ReplaceOrder(ctx context.Context, id string, changes OrderChanges) (ReplacedOrder, error) {
+	var result ReplacedOrder
+
+	original, err := c.GetOrderDetail(ctx, id)
+	if err != nil {
+		return result, err
+	}
+
+	switch {
+	case original.Status == OrderStatusDone:
+		return result, ErrOrderDone
+	case original.Type != OrderTypeLimit:
+		return result, fmt.Errorf("only limit orders can be replaced, got %s", original.Type)
+	case original.TimeInForce == TIFGoodTillTime && changes.CancelAfter == "":
+		return result, errors.New("cancel after is required to replace a good till time order")
+	}
+
+	fills, err := c.orderFills(ctx, id)
+	if err != nil {
+		return result, err
+	}
+
+	knownFills := make(map[int]bool, len(fills))
+	for _, fill := range fills {
+		knownFills[fill.TradeID] = true
+	}
+
+	if err := c.CancelOrder(ctx, id, CancelOrderParams{ProductID: original.ProductID}); err != nil && !errors.Is(err, ErrOrderNotFound) {
+		// The cancel is also rejected when the order was filled in the meantime.
+		order, statusErr := c.GetOrderDetail(ctx, id)
+		switch {
+		case statusErr != nil && !errors.Is(statusErr, ErrOrderNotFound):
+			return result, fmt.Errorf("failed to cancel order: %w, failed to get its status: %v", err, statusErr)
+		case statusErr == nil && order.Status != OrderStatusDone:
+			return result, fmt.Errorf("failed to cancel order: %w", err)
+		}
+	}
+
+	result.Replaced, err = c.waitForDone(ctx, original, changes.PollInterval)
+	if err != nil {
+		return result, err
+	}
+
+	if fills, err = c.orderFills(ctx, id); err != nil {
+		return result, err
+	}
+	for _, fill := range fills {
+		if !knownFills[fill.TradeID] {
+			result.Fills = append(result.Fills, fill)
+		}
+	}
+
+	size := original.Size
+	if changes.Size != "" {
+		size = changes.Size
+	}
+
+	target, ok := decimal.Parse(size)
+	if !ok {
+		return result, fmt.Errorf("invalid size %q", size)
+	}
+
+	filled := result.Replaced.FilledSize.RatOrZero()
+
+	remaining := new(big.Rat).Sub(target, filled)
+	if remaining.Sign() <= 0 {
+		return result, nil
+	}
+
+	replacement := CreateOrderRequest{
+		Type:        OrderTypeLimit,
+		Side:        original.Side,
+		ProductID:   original.ProductID,
+		ClientOID:   changes.ClientOID,
+		Stp:         original.Stp,
+		Stop:        original.Stop,
+		StopPrice:   original.StopPrice.String(),
+		Price:       original.Price,
+		Size:        decimal.Trim(remaining, trackerPlaces),
+		TimeInForce: original.TimeInForce,
+		PostOnly:    original.PostOnly,
+		CancelAfter: changes.CancelAfter,
+	}
+
+	if changes.Price != "" {
+		replacement.Price = changes.Price
+	}
+
+	if replacement.ClientOID == "" && original.ClientOID != "" {
+		replacement.ClientOID = ReplacementClientOID(original.ClientOID)
+	}
+
+	result.Order, err = c.PlaceOrder(ctx, replacement)
+	return result, err
+}
+
+// waitForDone polls the order until the exchange reports it as done. Orders cancelled without fills are removed,
+// which is also treated as done.
+func (c *client) waitForDone(ctx context.Context, original OrderDetail, interval time.Duration) (OrderDetail, error) {
+	if interval <= 0 {
+		interval = 100 * time.Millisecond
+	}
+
+	for {
+		order, err := c.GetOrderDetail(ctx, original.ID)
+		switch {
+		case errors.Is(err, ErrOrderNotFound):
+			original.Status = OrderStatusDone
+			original.DoneReason = "canceled"
+			original.FilledSize = ""
+			return original, nil
+		case err != nil:
+			return order, err
+		case order.Status == OrderStatusDone:
+			return order, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return order, ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (c *client) orderFills(ctx context.Context, id string) ([]Fill, error) {
+	var fills []Fill
+
+	cursor := c.ListFills(ListFillsParams{OrderID: id})
+	for cursor.HasMore {
+		var page []Fill
+		if err := cursor.NextPage(ctx, &page); err != nil {
+			return nil, err
+		}
+		fills = append(fills, page...)
+	}
+
+	return fills, nil
+}
+
+// ReplacementClientOID returns the client oid ReplaceOrder gives the replacement of an order with clientOID. It is a
+// version 5 uuid derived from clientOID, so the replacements of an order can be followed from its client oid and a
+// retried replace is placed with the same client oid.
+func ReplacementClientOID(clientOID string) string {
+	sum := sha1.Sum([]byte("replacement:" + clientOID))
+	b := sum[:16]
+
+	b[6] = (b[6] & 0x0f) | 0x50
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// newClientOID generates a random version 4 uuid.
+func newClientOID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate client oid: %w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
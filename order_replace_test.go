@@ -0,0 +1,114 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestReplaceOrder(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		cancelled bool
+		placed    coinbasepro.CreateOrderRequest
+	)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orders/original", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodDelete:
+			cancelled = true
+			w.Write([]byte(`"original"`))
+		case cancelled:
+			w.Write([]byte(`{"id": "original", "client_oid": "c1", "type": "limit", "side": "sell", "product_id": "BTC-USD", "price": "100.00", "size": "2.0", "filled_size": "0.75", "time_in_force": "GTC", "status": "done", "done_reason": "canceled"}`))
+		default:
+			w.Write([]byte(`{"id": "original", "client_oid": "c1", "type": "limit", "side": "sell", "product_id": "BTC-USD", "price": "100.00", "size": "2.0", "filled_size": "0.5", "time_in_force": "GTC", "status": "open"}`))
+		}
+	})
+	handler.HandleFunc("/fills", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fills := `[{"trade_id": 1, "order_id": "original", "price": "100.00", "size": "0.5"}]`
+		if cancelled {
+			fills = `[{"trade_id": 2, "order_id": "original", "price": "100.00", "size": "0.25"}, {"trade_id": 1, "order_id": "original", "price": "100.00", "size": "0.5"}]`
+		}
+		w.Write([]byte(fills))
+	})
+	handler.HandleFunc("/orders", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := json.NewDecoder(r.Body).Decode(&placed); err != nil {
+			t.Error(err)
+		}
+		json.NewEncoder(w).Encode(coinbasepro.OrderDetail{ID: "replacement", ClientOID: placed.ClientOID, Price: placed.Price, Size: placed.Size})
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	result, err := client.ReplaceOrder(context.Background(), "original", coinbasepro.OrderChanges{Price: "101.00"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if result.Order.ID != "replacement" {
+		t.Fatal("replacement was not placed")
+	}
+
+	if placed.Size != "1.25" || placed.Price != "101.00" || placed.Side != coinbasepro.SideSell || placed.ProductID != "BTC-USD" {
+		t.Fatalf("unexpected replacement: %+v", placed)
+	}
+
+	if placed.ClientOID != coinbasepro.ReplacementClientOID("c1") || placed.ClientOID == "c1" {
+		t.Fatalf("replacement should have a client oid derived from the original, got %q", placed.ClientOID)
+	}
+
+	if len(result.Fills) != 1 || result.Fills[0].TradeID != 2 {
+		t.Fatalf("expected the fill during the cancel, got %+v", result.Fills)
+	}
+}
+
+func TestReplaceOrderUnconfirmedCancel(t *testing.T) {
+	var (
+		mu        sync.Mutex
+		cancelled bool
+	)
+
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orders/original", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		switch {
+		case r.Method == http.MethodDelete:
+			cancelled = true
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "cancel rejected"}`))
+		case cancelled:
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"message": "status unavailable"}`))
+		default:
+			w.Write([]byte(`{"id": "original", "type": "limit", "side": "sell", "product_id": "BTC-USD", "price": "100.00", "size": "2.0", "time_in_force": "GTC", "status": "open"}`))
+		}
+	})
+	handler.HandleFunc("/fills", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	_, err := client.ReplaceOrder(context.Background(), "original", coinbasepro.OrderChanges{Price: "101.00"})
+
+	var apiErr coinbasepro.Error
+	if !errors.As(err, &apiErr) || apiErr.Message != "cancel rejected" || !strings.Contains(err.Error(), "status unavailable") {
+		t.Fatalf("expected the cancel error, got %v", err)
+	}
+}
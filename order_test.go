@@ -2,6 +2,10 @@ package coinbasepro_test
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
 	"testing"
 
 	"github.com/moonr-app/go-coinbasepro"
@@ -173,3 +177,158 @@ func TestCancelAllOrders(t *testing.T) {
 		}
 	}
 }
+
+func TestOrderConstructors(t *testing.T) {
+	limit := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "1.00", coinbasepro.GoodTillTime(coinbasepro.CancelAfterHour).PostOnly())
+	if limit.TimeInForce != coinbasepro.TIFGoodTillTime || limit.CancelAfter != coinbasepro.CancelAfterHour || !limit.PostOnly {
+		t.Fatalf("unexpected limit order: %+v", limit)
+	}
+
+	ioc := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "100.00", "1.00", coinbasepro.ImmediateOrCancel())
+	if ioc.TimeInForce != coinbasepro.TIFImmediateOrCancel || ioc.PostOnly || ioc.CancelAfter != "" {
+		t.Fatalf("unexpected immediate or cancel order: %+v", ioc)
+	}
+
+	gtc := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "100.00", "1.00", nil)
+	if gtc.TimeInForce != coinbasepro.TIFGoodTillCancelled {
+		t.Fatalf("time in force should default to good till cancelled: %+v", gtc)
+	}
+
+	byFunds := coinbasepro.NewMarketOrderByFunds("BTC-USD", coinbasepro.SideBuy, "25.00")
+	bySize := coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideSell, "0.01")
+	stop := coinbasepro.NewStopLimitOrder("BTC-USD", coinbasepro.SideSell, coinbasepro.StopLoss, "90.00", "89.00", "1.00")
+
+	for _, o := range []coinbasepro.CreateOrderRequest{limit, ioc, gtc, byFunds, bySize, stop} {
+		if err := o.Validate(testProduct); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestPlaceOrder(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Error(err)
+		}
+
+		for _, field := range []string{"id", "status", "filled_size", "done_at"} {
+			if _, ok := body[field]; ok {
+				t.Errorf("response field %s should not be sent", field)
+			}
+		}
+
+		w.Write([]byte(`{
+			"id": "d0c5340b-6d6c-49d9-b567-48c4bfca13d2",
+			"price": "0.10000000",
+			"size": "0.01000000",
+			"product_id": "BTC-USD",
+			"side": "buy",
+			"stp": "dc",
+			"type": "limit",
+			"time_in_force": "GTC",
+			"post_only": false,
+			"created_at": "2016-12-08T20:02:28.53864Z",
+			"fill_fees": "0.0000000000000000",
+			"filled_size": "0.00000000",
+			"executed_value": "0.0000000000000000",
+			"status": "pending",
+			"settled": false
+		}`))
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	order := coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "0.10", "0.01", nil)
+
+	savedOrder, err := client.PlaceOrder(context.Background(), order)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if savedOrder.ID == "" || savedOrder.Status != coinbasepro.OrderStatusPending || savedOrder.FilledSize != "0.00000000" {
+		t.Fatalf("unexpected order: %+v", savedOrder)
+	}
+
+	// Orders created with the deprecated type don't send response fields either.
+	legacy := coinbasepro.Order{Price: "0.10", Size: "0.01", Side: "buy", ProductID: "BTC-USD"}
+	if _, err := client.CreateOrder(context.Background(), legacy); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOrderByClientOID(t *testing.T) {
+	const clientOID = "0b3bb7a0-e4f9-4e73-b8a9-3b3f4e8b2f0c"
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/orders/client:"+clientOID && r.Method == http.MethodGet:
+			w.Write([]byte(`{"id": "d0c5340b-6d6c-49d9-b567-48c4bfca13d2", "client_oid": "` + clientOID + `", "status": "open"}`))
+		case r.URL.Path == "/orders/client:"+clientOID && r.Method == http.MethodDelete:
+			if r.URL.Query().Get("product_id") != "BTC-USD" {
+				t.Errorf("product_id missing from cancel: %s", r.URL)
+			}
+			w.Write([]byte(`"d0c5340b-6d6c-49d9-b567-48c4bfca13d2"`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message": "NotFound"}`))
+		}
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	ctx := context.Background()
+
+	order, err := client.GetOrderByClientOID(ctx, clientOID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if order.ClientOID != clientOID {
+		t.Fatal("client oids do not match")
+	}
+
+	if err := client.CancelOrderByClientOID(ctx, clientOID, coinbasepro.CancelOrderParams{ProductID: "BTC-USD"}); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = client.GetOrderByClientOID(ctx, "missing")
+	var notFound coinbasepro.OrderNotFoundError
+	if !errors.As(err, &notFound) || notFound.ClientOID != "missing" {
+		t.Fatalf("expected order not found error, got %v", err)
+	}
+
+	var apiErr coinbasepro.Error
+	if !errors.As(err, &apiErr) || apiErr.Message != notFound.Message {
+		t.Fatalf("expected the exchange error to be wrapped, got %v", err)
+	}
+
+	if err := client.CancelOrder(ctx, "missing"); !errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		t.Fatalf("expected order not found error, got %v", err)
+	}
+}
+
+func TestOrderDetailDecimals(t *testing.T) {
+	data := []byte(`{"filled_size":"0.10000000","executed_value":1.23456789012345678,"fill_fees":null,"stop_price":""}`)
+
+	var order coinbasepro.OrderDetail
+	if err := json.Unmarshal(data, &order); err != nil {
+		t.Fatal(err)
+	}
+
+	if order.FilledSize != "0.10000000" {
+		t.Fatalf("unexpected filled size %q", order.FilledSize)
+	}
+	if order.ExecutedValue != "1.23456789012345678" {
+		t.Fatalf("numeric executed value lost precision: %q", order.ExecutedValue)
+	}
+
+	filled, ok := order.FilledSize.Rat()
+	if !ok || filled.Cmp(big.NewRat(1, 10)) != 0 {
+		t.Fatalf("unexpected filled size %v", filled)
+	}
+	if _, ok := order.StopPrice.Rat(); ok {
+		t.Fatal("empty stop price parsed")
+	}
+	if order.FillFees.RatOrZero().Sign() != 0 {
+		t.Fatalf("unexpected fill fees %q", order.FillFees)
+	}
+}
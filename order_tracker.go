@@ -0,0 +1,494 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+const (
+	// maxPendingOrders bounds the number of untracked orders for which user channel messages are buffered.
+	maxPendingOrders = 1000
+	trackerPlaces    = 16
+)
+
+// TrackedFill is a single fill of a tracked order.
+type TrackedFill struct {
+	TradeID int
+	Price   string
+	Size    string
+	Fee     string
+	// Liquidity is M when the order was the maker and T when it was the taker.
+	Liquidity string
+	Time      time.Time
+}
+
+// TrackedOrderState is a consistent snapshot of a tracked order.
+type TrackedOrderState struct {
+	ID            string
+	ClientOID     string
+	ProductID     string
+	Side          Side
+	Type          OrderType
+	Price         string
+	Size          string
+	Funds         string
+	Status        OrderStatus
+	RemainingSize string
+	FilledSize    string
+	ExecutedValue string
+	AveragePrice  string
+	Fees          string
+	DoneReason    string
+	Fills         []TrackedFill
+	UpdatedAt     time.Time
+}
+
+// TrackedOrder follows a single order through received, open, match and done. The state is updated from the user
+// channel and by OrderTracker.Reconcile.
+type TrackedOrder struct {
+	mu            sync.Mutex
+	state         TrackedOrderState
+	filled        *big.Rat
+	executedValue *big.Rat
+	fees          *big.Rat
+	trades        map[int]bool
+	done          chan struct{}
+	onFill        []func(TrackedFill, TrackedOrderState)
+	onDone        []func(TrackedOrderState)
+}
+
+// OrderTracker keeps the state of orders submitted through PlaceOrder up to date using the authenticated user
+// channel. Pass every user channel message to HandleMessage, the subscriptions message sent when the websocket subscribes
+// again after a reconnect reconciles the open orders, so messages missed while disconnected are recovered from the API.
+type OrderTracker struct {
+	client *client
+
+	mu           sync.Mutex
+	orders       map[string]*TrackedOrder
+	byClientOID  map[string]*TrackedOrder
+	pending      map[string][]Message
+	pendingOrder []string
+}
+
+func NewOrderTracker(c *client) *OrderTracker {
+	return &OrderTracker{
+		client:      c,
+		orders:      make(map[string]*TrackedOrder),
+		byClientOID: make(map[string]*TrackedOrder),
+		pending:     make(map[string][]Message),
+	}
+}
+
+// PlaceOrder submits newOrder and tracks it.
+func (t *OrderTracker) PlaceOrder(ctx context.Context, newOrder CreateOrderRequest) (*TrackedOrder, error) {
+	order, err := t.client.PlaceOrder(ctx, newOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.Track(order), nil
+}
+
+// Track starts tracking an order which was submitted through PlaceOrder. Messages which were received for the
+// order before it was tracked are applied immediately.
+func (t *OrderTracker) Track(order OrderDetail) *TrackedOrder {
+	t.mu.Lock()
+	if tracked, ok := t.orders[order.ID]; ok {
+		t.mu.Unlock()
+		return tracked
+	}
+
+	tracked := &TrackedOrder{
+		state: TrackedOrderState{
+			ID:            order.ID,
+			ClientOID:     order.ClientOID,
+			ProductID:     order.ProductID,
+			Side:          order.Side,
+			Type:          order.Type,
+			Price:         order.Price,
+			Size:          order.Size,
+			Funds:         order.Funds,
+			Status:        order.Status,
+			RemainingSize: order.Size,
+			UpdatedAt:     time.Now(),
+		},
+		filled:        new(big.Rat),
+		executedValue: new(big.Rat),
+		fees:          new(big.Rat),
+		trades:        make(map[int]bool),
+		done:          make(chan struct{}),
+	}
+	tracked.updateTotals()
+
+	t.orders[order.ID] = tracked
+	if order.ClientOID != "" {
+		t.byClientOID[order.ClientOID] = tracked
+	}
+
+	pending := t.pending[order.ID]
+	delete(t.pending, order.ID)
+	t.mu.Unlock()
+
+	for _, msg := range pending {
+		tracked.apply(msg)
+	}
+
+	return tracked
+}
+
+// Order returns the tracked order with id.
+func (t *OrderTracker) Order(id string) (*TrackedOrder, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.orders[id]
+	return tracked, ok
+}
+
+// OrderByClientOID returns the tracked order which was created with clientOID.
+func (t *OrderTracker) OrderByClientOID(clientOID string) (*TrackedOrder, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked, ok := t.byClientOID[clientOID]
+	return tracked, ok
+}
+
+// HandleMessage applies a user channel message to the order it belongs to. It can be used as, or called from, the
+// handler passed to Subscribe. The subscriptions message reconciles the open orders with Reconcile.
+func (t *OrderTracker) HandleMessage(msg Message) error {
+	switch msg.Type {
+	case "subscriptions":
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		if err := t.Reconcile(ctx); err != nil {
+			return fmt.Errorf("failed to reconcile orders: %w", err)
+		}
+	case "received", "open", "done", "change", "activate":
+		t.dispatch(msg, msg.OrderID, msg.ClientOID)
+	case "match":
+		t.dispatch(msg, msg.MakerOrderID, "")
+		t.dispatch(msg, msg.TakerOrderID, "")
+	}
+
+	return nil
+}
+
+func (t *OrderTracker) dispatch(msg Message, id, clientOID string) {
+	if id == "" && clientOID == "" {
+		return
+	}
+
+	t.mu.Lock()
+	tracked, ok := t.orders[id]
+	if !ok && clientOID != "" {
+		tracked, ok = t.byClientOID[clientOID]
+	}
+
+	if !ok {
+		t.buffer(id, msg)
+		t.mu.Unlock()
+		return
+	}
+
+	t.mu.Unlock()
+
+	tracked.apply(msg)
+}
+
+// buffer keeps a message for an order which isn't tracked yet, because PlaceOrder hasn't returned.
+func (t *OrderTracker) buffer(id string, msg Message) {
+	if id == "" {
+		return
+	}
+
+	if _, ok := t.pending[id]; !ok {
+		t.pendingOrder = append(t.pendingOrder, id)
+		if len(t.pendingOrder) > maxPendingOrders {
+			delete(t.pending, t.pendingOrder[0])
+			t.pendingOrder = t.pendingOrder[1:]
+		}
+	}
+
+	t.pending[id] = append(t.pending[id], msg)
+}
+
+// Reconcile refreshes every order which isn't done from the API, fills which were missed are applied and orders
+// which finished while disconnected are completed.
+func (t *OrderTracker) Reconcile(ctx context.Context) error {
+	t.mu.Lock()
+	var open []*TrackedOrder
+	for _, tracked := range t.orders {
+		if !tracked.isDone() {
+			open = append(open, tracked)
+		}
+	}
+	t.mu.Unlock()
+
+	for _, tracked := range open {
+		if err := t.reconcile(ctx, tracked); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (t *OrderTracker) reconcile(ctx context.Context, tracked *TrackedOrder) error {
+	id := tracked.ID()
+
+	order, err := t.client.GetOrderDetail(ctx, id)
+	if errors.Is(err, ErrOrderNotFound) {
+		// Orders cancelled without any fills are removed by the exchange.
+		tracked.finish("canceled", time.Now())
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cursor := t.client.ListFills(ListFillsParams{OrderID: id})
+	for cursor.HasMore {
+		var fills []Fill
+		if err := cursor.NextPage(ctx, &fills); err != nil {
+			return err
+		}
+
+		for _, fill := range fills {
+			tracked.fill(TrackedFill{
+				TradeID:   fill.TradeID,
+				Price:     fill.Price,
+				Size:      fill.Size,
+				Fee:       fill.Fee,
+				Liquidity: fill.Liquidity,
+				Time:      fill.CreatedAt.Time(),
+			})
+		}
+	}
+
+	switch order.Status {
+	case OrderStatusDone:
+		tracked.finish(order.DoneReason, order.DoneAt.Time())
+	case OrderStatusOpen, OrderStatusActive:
+		tracked.setStatus(order.Status, time.Now())
+	}
+
+	return nil
+}
+
+// ID returns the exchange id of the order.
+func (o *TrackedOrder) ID() string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.state.ID
+}
+
+// State returns a snapshot of the order.
+func (o *TrackedOrder) State() TrackedOrderState {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	state := o.state
+	state.Fills = append([]TrackedFill(nil), o.state.Fills...)
+
+	return state
+}
+
+// Done is closed when the order is done.
+func (o *TrackedOrder) Done() <-chan struct{} {
+	return o.done
+}
+
+// Wait blocks until the order is done or ctx is done.
+func (o *TrackedOrder) Wait(ctx context.Context) (TrackedOrderState, error) {
+	select {
+	case <-o.done:
+		return o.State(), nil
+	case <-ctx.Done():
+		return o.State(), ctx.Err()
+	}
+}
+
+// OnFill registers a handler which is called for every fill of the order.
+func (o *TrackedOrder) OnFill(handler func(TrackedFill, TrackedOrderState)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	o.onFill = append(o.onFill, handler)
+}
+
+// OnDone registers a handler which is called once the order is done, it is called immediately when the order is
+// already done.
+func (o *TrackedOrder) OnDone(handler func(TrackedOrderState)) {
+	o.mu.Lock()
+	if !o.isDoneLocked() {
+		o.onDone = append(o.onDone, handler)
+		o.mu.Unlock()
+		return
+	}
+	o.mu.Unlock()
+
+	handler(o.State())
+}
+
+func (o *TrackedOrder) isDone() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.isDoneLocked()
+}
+
+func (o *TrackedOrder) isDoneLocked() bool {
+	select {
+	case <-o.done:
+		return true
+	default:
+		return false
+	}
+}
+
+func (o *TrackedOrder) apply(msg Message) {
+	at := msg.Time.Time()
+
+	switch msg.Type {
+	case "received":
+		o.setStatus(OrderStatusReceived, at)
+	case "open":
+		o.mu.Lock()
+		o.state.RemainingSize = msg.RemainingSize
+		o.mu.Unlock()
+		o.setStatus(OrderStatusOpen, at)
+	case "activate":
+		o.setStatus(OrderStatusActive, at)
+	case "change":
+		o.mu.Lock()
+		if msg.NewSize != "" {
+			o.state.Size = msg.NewSize
+		}
+		o.mu.Unlock()
+	case "match":
+		fill := TrackedFill{
+			TradeID: msg.TradeID,
+			Price:   msg.Price,
+			Size:    msg.Size,
+			Time:    at,
+		}
+
+		feeRate := msg.TakerFeeRate
+		fill.Liquidity = "T"
+		if msg.MakerOrderID == o.ID() {
+			feeRate = msg.MakerFeeRate
+			fill.Liquidity = "M"
+		}
+
+		price, okPrice := decimal.Parse(msg.Price)
+		size, okSize := decimal.Parse(msg.Size)
+		rate, okRate := decimal.Parse(feeRate)
+		if okPrice && okSize && okRate {
+			fee := new(big.Rat).Mul(price, size)
+			fill.Fee = decimal.Trim(fee.Mul(fee, rate), trackerPlaces)
+		}
+
+		o.fill(fill)
+	case "done":
+		o.mu.Lock()
+		if msg.RemainingSize != "" {
+			o.state.RemainingSize = msg.RemainingSize
+		}
+		o.mu.Unlock()
+		o.finish(msg.Reason, at)
+	}
+}
+
+func (o *TrackedOrder) setStatus(status OrderStatus, at time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.isDoneLocked() {
+		return
+	}
+
+	o.state.Status = status
+	o.state.UpdatedAt = at
+}
+
+func (o *TrackedOrder) fill(fill TrackedFill) {
+	o.mu.Lock()
+	if o.trades[fill.TradeID] {
+		o.mu.Unlock()
+		return
+	}
+	o.trades[fill.TradeID] = true
+
+	price, _ := decimal.Parse(fill.Price)
+	size, _ := decimal.Parse(fill.Size)
+	if price != nil && size != nil {
+		o.filled.Add(o.filled, size)
+		o.executedValue.Add(o.executedValue, new(big.Rat).Mul(price, size))
+	}
+	if fee, ok := decimal.Parse(fill.Fee); ok {
+		o.fees.Add(o.fees, fee)
+	}
+
+	o.state.Fills = append(o.state.Fills, fill)
+	o.state.UpdatedAt = fill.Time
+	o.updateTotals()
+
+	handlers := o.onFill
+	o.mu.Unlock()
+
+	state := o.State()
+	for _, handler := range handlers {
+		handler(fill, state)
+	}
+}
+
+func (o *TrackedOrder) finish(reason string, at time.Time) {
+	o.mu.Lock()
+	if o.isDoneLocked() {
+		o.mu.Unlock()
+		return
+	}
+
+	o.state.Status = OrderStatusDone
+	o.state.DoneReason = reason
+	o.state.UpdatedAt = at
+	close(o.done)
+
+	handlers := o.onDone
+	o.onDone = nil
+	o.mu.Unlock()
+
+	state := o.State()
+	for _, handler := range handlers {
+		handler(state)
+	}
+}
+
+// updateTotals formats the running totals into the state, the caller must hold the lock.
+func (o *TrackedOrder) updateTotals() {
+	o.state.FilledSize = decimal.Trim(o.filled, trackerPlaces)
+	o.state.ExecutedValue = decimal.Trim(o.executedValue, trackerPlaces)
+	o.state.Fees = decimal.Trim(o.fees, trackerPlaces)
+	o.state.AveragePrice = ""
+
+	if o.filled.Sign() > 0 {
+		o.state.AveragePrice = decimal.Trim(new(big.Rat).Quo(o.executedValue, o.filled), trackerPlaces)
+	}
+
+	if size, ok := decimal.Parse(o.state.Size); ok && o.filled.Sign() > 0 {
+		remaining := new(big.Rat).Sub(size, o.filled)
+		if remaining.Sign() < 0 {
+			remaining.SetInt64(0)
+		}
+		o.state.RemainingSize = decimal.Trim(remaining, trackerPlaces)
+	}
+}
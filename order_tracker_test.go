@@ -0,0 +1,168 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestOrderTrackerLifecycle(t *testing.T) {
+	tracker := coinbasepro.NewOrderTracker(nil)
+	const id = "d0c5340b-6d6c-49d9-b567-48c4bfca13d2"
+
+	// Messages received before the order is tracked are buffered.
+	tracker.HandleMessage(coinbasepro.Message{Type: "received", OrderID: id, ClientOID: "c1", Size: "2.00", Price: "100.00", Side: "buy"})
+
+	tracked := tracker.Track(coinbasepro.OrderDetail{ID: id, ClientOID: "c1", ProductID: "BTC-USD", Side: coinbasepro.SideBuy, Price: "100.00", Size: "2.00", Status: coinbasepro.OrderStatusPending})
+	if tracked.State().Status != coinbasepro.OrderStatusReceived {
+		t.Fatalf("buffered message was not applied: %s", tracked.State().Status)
+	}
+
+	var fills []coinbasepro.TrackedFill
+	tracked.OnFill(func(fill coinbasepro.TrackedFill, state coinbasepro.TrackedOrderState) {
+		fills = append(fills, fill)
+	})
+
+	var done coinbasepro.TrackedOrderState
+	tracked.OnDone(func(state coinbasepro.TrackedOrderState) {
+		done = state
+	})
+
+	messages := []coinbasepro.Message{
+		{Type: "open", OrderID: id, RemainingSize: "2.00"},
+		{Type: "match", TradeID: 1, MakerOrderID: id, TakerOrderID: "other", Size: "0.5", Price: "100.00", MakerFeeRate: "0.001"},
+		{Type: "match", TradeID: 2, MakerOrderID: id, TakerOrderID: "other", Size: "1.5", Price: "98.00", MakerFeeRate: "0.001"},
+		// Duplicate matches are ignored.
+		{Type: "match", TradeID: 2, MakerOrderID: id, TakerOrderID: "other", Size: "1.5", Price: "98.00", MakerFeeRate: "0.001"},
+		{Type: "done", OrderID: id, Reason: "filled", RemainingSize: "0"},
+	}
+	for _, msg := range messages {
+		if err := tracker.HandleMessage(msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	state, err := tracked.Wait(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(fills) != 2 {
+		t.Fatalf("expected 2 fills, got %d", len(fills))
+	}
+
+	if state.FilledSize != "2" || state.ExecutedValue != "197" || state.AveragePrice != "98.5" || state.Fees != "0.197" {
+		t.Fatalf("unexpected totals: %+v", state)
+	}
+
+	if state.DoneReason != "filled" || done.Status != coinbasepro.OrderStatusDone {
+		t.Fatalf("done callback was not called: %+v", done)
+	}
+
+	if byClientOID, ok := tracker.OrderByClientOID("c1"); !ok || byClientOID != tracked {
+		t.Fatal("order not found by client oid")
+	}
+}
+
+func TestOrderTrackerReconcile(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/orders/filled", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id": "filled", "status": "done", "done_reason": "filled", "done_at": "2021-01-01T00:00:00Z", "filled_size": "1.0"}`))
+	})
+	handler.HandleFunc("/orders/cancelled", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "NotFound"}`))
+	})
+	handler.HandleFunc("/fills", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"trade_id": 7, "order_id": "filled", "price": "10.00", "size": "1.0", "fee": "0.05", "liquidity": "T", "created_at": "2021-01-01T00:00:00Z"}]`))
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	tracker := coinbasepro.NewOrderTracker(client)
+
+	filled := tracker.Track(coinbasepro.OrderDetail{ID: "filled", Size: "1.0", Status: coinbasepro.OrderStatusOpen})
+	cancelled := tracker.Track(coinbasepro.OrderDetail{ID: "cancelled", Size: "1.0", Status: coinbasepro.OrderStatusOpen})
+
+	if err := tracker.Reconcile(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	state, err := filled.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.FilledSize != "1" || state.Fees != "0.05" || state.AveragePrice != "10" {
+		t.Fatalf("missed fill was not reconciled: %+v", state)
+	}
+
+	state, err = cancelled.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.DoneReason != "canceled" {
+		t.Fatalf("unexpected done reason: %s", state.DoneReason)
+	}
+}
+
+func TestOrderTrackerReconcileOnResubscribe(t *testing.T) {
+	client, server := coinbasepro.NewFakeClient(t)
+	tracker := coinbasepro.NewOrderTracker(client)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscribe := coinbasepro.Message{
+		Type:     "subscribe",
+		Channels: []coinbasepro.MessageChannel{{Name: "user", ProductIds: []string{"BTC-USD"}}},
+	}
+	subscribed := make(chan struct{}, 1)
+	handler := func(msg coinbasepro.Message) error {
+		if err := tracker.HandleMessage(msg); err != nil {
+			return err
+		}
+
+		if msg.Type == "subscriptions" {
+			subscribed <- struct{}{}
+		}
+
+		return nil
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- client.Subscribe(ctx, subscribe, handler) }()
+	<-subscribed
+
+	tracked, err := tracker.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "2.00", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server.DisconnectFeed()
+	if err := <-closed; err == nil {
+		t.Fatal("expected the websocket to be closed")
+	}
+
+	// The fill is missed while disconnected and recovered when subscribing again.
+	if err := server.FillOrder(tracked.ID(), "2.00"); err != nil {
+		t.Fatal(err)
+	}
+
+	go client.Subscribe(ctx, subscribe, handler)
+
+	state, err := tracked.Wait(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if state.FilledSize != "2" || state.DoneReason != "filled" {
+		t.Fatalf("missed fill was not reconciled: %+v", state)
+	}
+}
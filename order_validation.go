@@ -0,0 +1,256 @@
+package coinbasepro
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// FieldError describes why a single order field does not satisfy the trading rules of a product.
+type FieldError struct {
+	Field  string
+	Value  string
+	Reason string
+}
+
+// ValidationError is returned by Order.Validate and contains an entry for every invalid field.
+type ValidationError struct {
+	Errors []FieldError
+}
+
+func (e FieldError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("%s: %s", e.Field, e.Reason)
+	}
+
+	return fmt.Sprintf("%s %q: %s", e.Field, e.Value, e.Reason)
+}
+
+func (e ValidationError) Error() string {
+	reasons := make([]string, len(e.Errors))
+	for i, fieldErr := range e.Errors {
+		reasons[i] = fieldErr.Error()
+	}
+
+	return fmt.Sprintf("invalid order: %s", strings.Join(reasons, "; "))
+}
+
+// Field returns the error for field, if there is one.
+func (e ValidationError) Field(field string) (FieldError, bool) {
+	for _, fieldErr := range e.Errors {
+		if fieldErr.Field == field {
+			return fieldErr, true
+		}
+	}
+
+	return FieldError{}, false
+}
+
+type orderValidator struct {
+	errors []FieldError
+}
+
+func (v *orderValidator) add(field, value, reason string, args ...interface{}) {
+	v.errors = append(v.errors, FieldError{Field: field, Value: value, Reason: fmt.Sprintf(reason, args...)})
+}
+
+// decimal checks that value is a positive multiple of increment within [min, max], empty bounds are not checked.
+func (v *orderValidator) decimal(field, value, increment, min, max string) {
+	d, ok := decimal.Parse(value)
+	if !ok {
+		v.add(field, value, "not a decimal")
+		return
+	}
+
+	if d.Sign() <= 0 {
+		v.add(field, value, "must be greater than 0")
+		return
+	}
+
+	if inc, ok := decimal.Parse(increment); ok && !decimal.IsMultiple(d, inc) {
+		v.add(field, value, "must be a multiple of %s", increment)
+	}
+
+	if m, ok := decimal.Parse(min); ok && d.Cmp(m) < 0 {
+		v.add(field, value, "must be at least %s", min)
+	}
+
+	if m, ok := decimal.Parse(max); ok && m.Sign() > 0 && d.Cmp(m) > 0 {
+		v.add(field, value, "must be at most %s", max)
+	}
+}
+
+// Validate checks the order against the trading rules of product before it is submitted, so invalid orders are
+// rejected without a round trip to the API. The returned error is a ValidationError listing every invalid field.
+func (o Order) Validate(product Product) error {
+	return o.Request().Validate(product)
+}
+
+// Validate checks the order against the trading rules of product before it is submitted, so invalid orders are
+// rejected without a round trip to the API. The returned error is a ValidationError listing every invalid field.
+func (o CreateOrderRequest) Validate(product Product) error {
+	v := &orderValidator{}
+
+	orderType := o.Type
+	if orderType == "" {
+		orderType = OrderTypeLimit
+	}
+
+	if o.ProductID != product.ID {
+		v.add("product_id", o.ProductID, "does not match product %s", product.ID)
+	}
+
+	switch {
+	case product.TradingDisabled:
+		v.add("product_id", o.ProductID, "trading is disabled")
+	case product.CancelOnly:
+		v.add("product_id", o.ProductID, "product is cancel only")
+	case product.LimitOnly && orderType != OrderTypeLimit:
+		v.add("type", string(orderType), "product is limit only")
+	case product.PostOnly && (orderType != OrderTypeLimit || !o.PostOnly):
+		v.add("post_only", fmt.Sprint(o.PostOnly), "product is post only")
+	}
+
+	if o.Side != SideBuy && o.Side != SideSell {
+		v.add("side", string(o.Side), "must be buy or sell")
+	}
+
+	switch orderType {
+	case OrderTypeLimit:
+		v.decimal("price", o.Price, product.QuoteIncrement, "", "")
+		v.decimal("size", o.Size, product.BaseIncrement, product.BaseMinSize, product.BaseMaxSize)
+
+		if o.Funds != "" {
+			v.add("funds", o.Funds, "not allowed on limit orders")
+		}
+	case OrderTypeMarket:
+		switch {
+		case o.Size != "" && o.Funds != "":
+			v.add("funds", o.Funds, "cannot be combined with size")
+		case o.Size != "":
+			v.decimal("size", o.Size, product.BaseIncrement, product.BaseMinSize, product.BaseMaxSize)
+		case o.Funds != "":
+			v.decimal("funds", o.Funds, product.QuoteIncrement, product.MinMarketFunds, product.MaxMarketFunds)
+		default:
+			v.add("size", "", "size or funds is required on market orders")
+		}
+
+		if o.Price != "" {
+			v.add("price", o.Price, "not allowed on market orders")
+		}
+		if o.TimeInForce != "" || o.PostOnly || o.CancelAfter != "" {
+			v.add("time_in_force", string(o.TimeInForce), "only allowed on limit orders")
+		}
+	default:
+		v.add("type", string(orderType), "must be limit or market")
+	}
+
+	switch {
+	case o.Stop != "" && o.Stop != StopLoss && o.Stop != StopEntry:
+		v.add("stop", string(o.Stop), "must be loss or entry")
+	case o.Stop != "":
+		v.decimal("stop_price", o.StopPrice, product.QuoteIncrement, "", "")
+	case o.StopPrice != "":
+		v.add("stop_price", o.StopPrice, "requires stop")
+	}
+
+	switch o.TimeInForce {
+	case "", TIFGoodTillCancelled:
+	case TIFGoodTillTime:
+		if o.CancelAfter == "" {
+			v.add("cancel_after", "", "required when time_in_force is GTT")
+		}
+	case TIFImmediateOrCancel, TIFFillOrKill:
+		if o.PostOnly {
+			v.add("post_only", "true", "cannot be combined with time_in_force %s", o.TimeInForce)
+		}
+	default:
+		v.add("time_in_force", string(o.TimeInForce), "must be GTC, GTT, IOC or FOK")
+	}
+
+	if o.CancelAfter != "" && o.TimeInForce != TIFGoodTillTime {
+		v.add("cancel_after", string(o.CancelAfter), "requires time_in_force GTT")
+	}
+
+	if len(v.errors) > 0 {
+		return ValidationError{Errors: v.errors}
+	}
+
+	return nil
+}
+
+// RoundToProduct returns a copy of the order with price, stop price, size and funds rounded to the nearest
+// increment of product. Sizes are reduced to the maximum size, sizes below the minimum size return an error.
+func (o Order) RoundToProduct(product Product) (Order, error) {
+	err := roundOrder(product, &o.Price, &o.StopPrice, &o.Funds, &o.Size)
+	return o, err
+}
+
+// RoundToProduct returns a copy of the order with price, stop price, size and funds rounded to the nearest
+// increment of product. Sizes are reduced to the maximum size, sizes below the minimum size return an error.
+func (o CreateOrderRequest) RoundToProduct(product Product) (CreateOrderRequest, error) {
+	err := roundOrder(product, &o.Price, &o.StopPrice, &o.Funds, &o.Size)
+	return o, err
+}
+
+func roundOrder(product Product, price, stopPrice, funds, size *string) error {
+	var err error
+
+	if *price, err = product.RoundPrice(*price); err != nil {
+		return err
+	}
+	if *stopPrice, err = product.RoundPrice(*stopPrice); err != nil {
+		return err
+	}
+	if *funds, err = product.RoundPrice(*funds); err != nil {
+		return err
+	}
+	if *size, err = product.RoundSize(*size); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RoundPrice rounds price to the nearest quote increment, an empty price is returned unchanged.
+func (p Product) RoundPrice(price string) (string, error) {
+	return roundField("price", price, p.QuoteIncrement, "", "")
+}
+
+// RoundSize rounds size to the nearest base increment and down to the maximum size, an empty size is returned
+// unchanged. A size which rounds to less than the minimum size is not increased, it returns an error.
+func (p Product) RoundSize(size string) (string, error) {
+	return roundField("size", size, p.BaseIncrement, p.BaseMinSize, p.BaseMaxSize)
+}
+
+func roundField(field, value, increment, min, max string) (string, error) {
+	if value == "" {
+		return value, nil
+	}
+
+	d, ok := decimal.Parse(value)
+	if !ok {
+		return value, FieldError{Field: field, Value: value, Reason: "not a decimal"}
+	}
+
+	inc, ok := decimal.Parse(increment)
+	if !ok {
+		inc = new(big.Rat)
+	}
+	d = decimal.Round(d, inc)
+
+	if m, ok := decimal.Parse(min); ok && d.Cmp(m) < 0 {
+		return value, FieldError{Field: field, Value: value, Reason: fmt.Sprintf("must be at least %s", min)}
+	}
+	if m, ok := decimal.Parse(max); ok && m.Sign() > 0 && d.Cmp(m) > 0 {
+		d = decimal.Floor(m, inc)
+	}
+
+	if increment == "" {
+		return decimal.Format(d, value), nil
+	}
+
+	return decimal.Format(d, increment), nil
+}
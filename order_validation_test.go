@@ -0,0 +1,104 @@
+package coinbasepro_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+var testProduct = coinbasepro.Product{
+	ID:             "BTC-USD",
+	BaseCurrency:   "BTC",
+	QuoteCurrency:  "USD",
+	BaseMinSize:    "0.00100000",
+	BaseMaxSize:    "280.00000000",
+	QuoteIncrement: "0.01000000",
+	BaseIncrement:  "0.00000001",
+	MinMarketFunds: "10",
+	MaxMarketFunds: "1000000",
+}
+
+func TestOrderValidate(t *testing.T) {
+	valid := []coinbasepro.Order{
+		{Price: "100.01", Size: "0.5", Side: "buy", ProductID: "BTC-USD"},
+		{Type: "market", Funds: "25.00", Side: "sell", ProductID: "BTC-USD"},
+		{Type: "market", Size: "0.001", Side: "sell", ProductID: "BTC-USD"},
+		{Price: "100", Size: "1", Side: "sell", ProductID: "BTC-USD", Stop: "loss", StopPrice: "101.00"},
+		{Price: "100", Size: "1", Side: "sell", ProductID: "BTC-USD", TimeInForce: "GTT", CancelAfter: "hour"},
+	}
+
+	for _, o := range valid {
+		if err := o.Validate(testProduct); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invalid := []struct {
+		order  coinbasepro.Order
+		fields []string
+	}{
+		{coinbasepro.Order{Price: "100.001", Size: "0.5", Side: "buy", ProductID: "BTC-USD"}, []string{"price"}},
+		{coinbasepro.Order{Price: "100", Size: "0.0001", Side: "buy", ProductID: "BTC-USD"}, []string{"size"}},
+		{coinbasepro.Order{Price: "100", Size: "300", Side: "buy", ProductID: "BTC-USD"}, []string{"size"}},
+		{coinbasepro.Order{Price: "100", Size: "1", Side: "long", ProductID: "BTC-USD"}, []string{"side"}},
+		{coinbasepro.Order{Type: "market", Funds: "5", Side: "buy", ProductID: "BTC-USD"}, []string{"funds"}},
+		{coinbasepro.Order{Type: "market", Funds: "5000000", Side: "buy", ProductID: "BTC-USD"}, []string{"funds"}},
+		{coinbasepro.Order{Type: "market", Funds: "50", Size: "1", Side: "buy", ProductID: "BTC-USD"}, []string{"funds"}},
+		{coinbasepro.Order{Price: "100", Size: "1", Side: "buy", ProductID: "BTC-USD", TimeInForce: "IOC", PostOnly: true}, []string{"post_only"}},
+		{coinbasepro.Order{Price: "100", Size: "1", Side: "buy", ProductID: "BTC-USD", CancelAfter: "day"}, []string{"cancel_after"}},
+		{coinbasepro.Order{Price: "100", Size: "1", Side: "buy", ProductID: "ETH-USD"}, []string{"product_id"}},
+	}
+
+	for _, test := range invalid {
+		err := test.order.Validate(testProduct)
+
+		var validationErr coinbasepro.ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("expected validation error, got %v", err)
+		}
+
+		for _, field := range test.fields {
+			if _, ok := validationErr.Field(field); !ok {
+				t.Fatalf("expected error for %s, got %v", field, err)
+			}
+		}
+	}
+}
+
+func TestOrderValidateProductFlags(t *testing.T) {
+	order := coinbasepro.Order{Type: "market", Size: "1", Side: "buy", ProductID: "BTC-USD"}
+
+	for _, product := range []coinbasepro.Product{
+		{ID: "BTC-USD", TradingDisabled: true},
+		{ID: "BTC-USD", CancelOnly: true},
+		{ID: "BTC-USD", LimitOnly: true},
+		{ID: "BTC-USD", PostOnly: true},
+	} {
+		if err := order.Validate(product); err == nil {
+			t.Fatalf("expected error for product %+v", product)
+		}
+	}
+}
+
+func TestOrderRoundToProduct(t *testing.T) {
+	order := coinbasepro.Order{Price: "100.0051", Size: "0.123456789", Side: "buy", ProductID: "BTC-USD"}
+
+	rounded, err := order.RoundToProduct(testProduct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if rounded.Price != "100.01" || rounded.Size != "0.12345679" {
+		t.Fatalf("unexpected rounding: %s %s", rounded.Price, rounded.Size)
+	}
+
+	if err := rounded.Validate(testProduct); err != nil {
+		t.Fatal(err)
+	}
+
+	var fieldErr coinbasepro.FieldError
+	if _, err := testProduct.RoundSize("0.0000001"); !errors.As(err, &fieldErr) || fieldErr.Field != "size" {
+		t.Fatalf("expected an error for a size below the minimum, got %v", err)
+	}
+}
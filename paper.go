@@ -0,0 +1,1106 @@
+package coinbasepro
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+const paperPlaces = 16
+
+// Trader contains the trading methods implemented by both the client and PaperClient, so strategies can move from
+// paper to live trading by swapping the implementation.
+type Trader interface {
+	PlaceOrder(ctx context.Context, newOrder CreateOrderRequest) (OrderDetail, error)
+	CreateOrder(ctx context.Context, newOrder Order) (Order, error)
+	CancelOrder(ctx context.Context, id string, p ...CancelOrderParams) error
+	CancelAllOrders(ctx context.Context, p CancelAllOrdersParams) ([]string, error)
+	GetOrderDetail(ctx context.Context, id string) (OrderDetail, error)
+	GetOrder(ctx context.Context, id string) (Order, error)
+	ListOrders(p ListOrdersParams) *Cursor
+	ListFills(p ListFillsParams) *Cursor
+	GetAccounts(ctx context.Context) ([]Account, error)
+	ListAccountLedger(id string, p ...GetAccountLedgerParams) *Cursor
+	ListHolds(id string, p ...ListHoldsParams) *Cursor
+	Subscribe(ctx context.Context, message Message, handler func(Message) error) error
+}
+
+// PaperClient simulates trading against the level 2 book of the websocket feed. Orders which cross the book are
+// filled as taker, resting orders are filled as maker when the book or a trade moves through their price. Balances,
+// holds and the ledger are kept in memory and user channel messages are delivered to Subscribe.
+type PaperClient struct {
+	client   *client
+	clock    clock
+	fees     *Fees
+	products map[string]Product
+	balances map[string]string
+
+	mu          sync.Mutex
+	makerRate   *big.Rat
+	takerRate   *big.Rat
+	books       map[string]*paperBook
+	lastPrice   map[string]*big.Rat
+	orders      map[string]*paperOrder
+	orderList   []*paperOrder
+	fills       []Fill
+	accounts    map[string]*paperAccount
+	nextTradeID int
+	nextEntryID int
+	sequence    int64
+	subscribers map[*paperSubscriber]bool
+}
+
+type paperOrder struct {
+	detail  OrderDetail
+	product Product
+	price   *big.Rat
+	stop    *big.Rat
+	// remaining is the unfilled size, or the unspent funds of market orders by funds.
+	remaining *big.Rat
+	byFunds   bool
+	filled    *big.Rat
+	value     *big.Rat
+	fees      *big.Rat
+	// hold is the amount still held on holdCurrency.
+	hold         *big.Rat
+	holdCurrency string
+	updatedAt    time.Time
+}
+
+type paperAccount struct {
+	id       string
+	currency string
+	balance  *big.Rat
+	hold     *big.Rat
+	ledger   []LedgerEntry
+}
+
+type paperSubscriber struct {
+	products map[string]bool
+	notify   chan struct{}
+
+	mu    sync.Mutex
+	queue []Message
+}
+
+type PaperOption func(*PaperClient) error
+
+// WithPaperBalances sets the starting balances by currency.
+func WithPaperBalances(balances map[string]string) PaperOption {
+	return func(p *PaperClient) error {
+		for currency, balance := range balances {
+			if _, ok := decimal.Parse(balance); !ok {
+				return fmt.Errorf("invalid balance %q for %s", balance, currency)
+			}
+		}
+		p.balances = balances
+
+		return nil
+	}
+}
+
+// WithPaperFees sets the fee rates, by default they are loaded with GetFees.
+func WithPaperFees(fees Fees) PaperOption {
+	return func(p *PaperClient) error {
+		p.fees = &fees
+		return nil
+	}
+}
+
+// WithPaperProducts sets the tradable products, by default they are loaded with GetProducts.
+func WithPaperProducts(products ...Product) PaperOption {
+	return func(p *PaperClient) error {
+		for _, product := range products {
+			p.products[product.ID] = product
+		}
+
+		return nil
+	}
+}
+
+// NewPaperClient creates a paper client, c is used by Load to fetch the fees and products and can be nil when both
+// are passed as options.
+func NewPaperClient(c *client, opts ...PaperOption) (*PaperClient, error) {
+	p := &PaperClient{
+		client:      c,
+		clock:       systemClock{},
+		products:    make(map[string]Product),
+		makerRate:   new(big.Rat),
+		takerRate:   new(big.Rat),
+		books:       make(map[string]*paperBook),
+		lastPrice:   make(map[string]*big.Rat),
+		orders:      make(map[string]*paperOrder),
+		accounts:    make(map[string]*paperAccount),
+		subscribers: make(map[*paperSubscriber]bool),
+	}
+
+	for _, opt := range opts {
+		if err := opt(p); err != nil {
+			return nil, err
+		}
+	}
+
+	for currency, balance := range p.balances {
+		account, err := p.account(currency)
+		if err != nil {
+			return nil, err
+		}
+		account.balance, _ = decimal.Parse(balance)
+	}
+
+	return p, nil
+}
+
+// Load fetches the fee rates and the products which were not passed as options.
+func (p *PaperClient) Load(ctx context.Context) error {
+	fees := p.fees
+	if fees == nil {
+		if p.client == nil {
+			return errors.New("fees are required without a client")
+		}
+
+		loaded, err := p.client.GetFees(ctx)
+		if err != nil {
+			return err
+		}
+		fees = &loaded
+	}
+
+	var products []Product
+	if len(p.products) == 0 {
+		if p.client == nil {
+			return errors.New("products are required without a client")
+		}
+
+		var err error
+		if products, err = p.client.GetProducts(ctx); err != nil {
+			return err
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if rate, ok := decimal.Parse(fees.MakerFeeRate); ok {
+		p.makerRate = rate
+	}
+	if rate, ok := decimal.Parse(fees.TakerFeeRate); ok {
+		p.takerRate = rate
+	}
+	for _, product := range products {
+		p.products[product.ID] = product
+	}
+
+	return nil
+}
+
+// HandleMessage applies level2 snapshots and updates to the simulated books and fills resting orders which the
+// market moved through. Messages of the matches channel are used as the last trade price for stop orders and fill
+// resting orders which were traded through. It can be used as, or called from, the handler passed to Subscribe.
+func (p *PaperClient) HandleMessage(msg Message) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	book := p.book(msg.ProductID)
+
+	var trade *paperLevel
+	switch msg.Type {
+	case "snapshot":
+		book.reset(msg.Bids, msg.Asks)
+	case "l2update":
+		for _, change := range msg.Changes {
+			book.update(change)
+		}
+	case "match", "last_match":
+		price, okPrice := decimal.Parse(msg.Price)
+		size, okSize := decimal.Parse(msg.Size)
+		if !okPrice || !okSize {
+			return nil
+		}
+
+		p.lastPrice[msg.ProductID] = price
+		if msg.Type == "match" {
+			trade = &paperLevel{price: price, size: size}
+		}
+	default:
+		return nil
+	}
+
+	p.simulate(msg.ProductID, trade)
+
+	return nil
+}
+
+// PlaceOrder validates newOrder against the product, holds the funds and matches it against the simulated book.
+func (p *PaperClient) PlaceOrder(_ context.Context, newOrder CreateOrderRequest) (OrderDetail, error) {
+	if newOrder.Type == "" {
+		newOrder.Type = OrderTypeLimit
+	}
+	if newOrder.Type == OrderTypeLimit && newOrder.TimeInForce == "" {
+		newOrder.TimeInForce = TIFGoodTillCancelled
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	product, ok := p.products[newOrder.ProductID]
+	if !ok {
+		return OrderDetail{}, Error{Message: "Product not found"}
+	}
+	if err := newOrder.Validate(product); err != nil {
+		return OrderDetail{}, err
+	}
+
+	id, err := newClientOID()
+	if err != nil {
+		return OrderDetail{}, err
+	}
+
+	now := p.now()
+	o := &paperOrder{
+		detail: OrderDetail{
+			ID:          id,
+			ClientOID:   newOrder.ClientOID,
+			ProductID:   newOrder.ProductID,
+			Type:        newOrder.Type,
+			Side:        newOrder.Side,
+			Price:       newOrder.Price,
+			Size:        newOrder.Size,
+			Funds:       newOrder.Funds,
+			Stp:         newOrder.Stp,
+			Stop:        newOrder.Stop,
+			StopPrice:   Decimal(newOrder.StopPrice),
+			TimeInForce: newOrder.TimeInForce,
+			PostOnly:    newOrder.PostOnly,
+			Status:      OrderStatusPending,
+			CreatedAt:   Time(now),
+		},
+		product:   product,
+		filled:    new(big.Rat),
+		value:     new(big.Rat),
+		fees:      new(big.Rat),
+		hold:      new(big.Rat),
+		updatedAt: now,
+	}
+	o.price, _ = decimal.Parse(newOrder.Price)
+	o.stop, _ = decimal.Parse(newOrder.StopPrice)
+
+	if o.remaining, ok = decimal.Parse(newOrder.Size); !ok {
+		o.remaining, _ = decimal.Parse(newOrder.Funds)
+		o.byFunds = true
+	}
+
+	if newOrder.TimeInForce == TIFGoodTillTime {
+		o.detail.ExpireTime = Time(now.Add(cancelAfterDuration(newOrder.CancelAfter)))
+	}
+
+	if newOrder.Type == OrderTypeLimit && newOrder.PostOnly && o.stop == nil && p.takes(o) {
+		o.detail.Status = OrderStatusRejected
+		o.detail.RejectReason = "post only"
+		p.record(o)
+
+		return o.detail, nil
+	}
+
+	if err := p.holdFunds(o); err != nil {
+		return OrderDetail{}, err
+	}
+
+	p.record(o)
+
+	if o.stop != nil {
+		o.detail.Status = OrderStatusActive
+		p.emit(p.orderMessage("activate", o))
+		p.simulate(o.detail.ProductID, nil)
+
+		return o.detail, nil
+	}
+
+	p.emit(p.orderMessage("received", o))
+	p.execute(o)
+
+	return o.detail, nil
+}
+
+// CreateOrder submits the request fields of newOrder.
+//
+// Deprecated: use PlaceOrder instead.
+func (p *PaperClient) CreateOrder(ctx context.Context, newOrder Order) (Order, error) {
+	detail, err := p.PlaceOrder(ctx, newOrder.Request())
+	if err != nil {
+		return Order{}, err
+	}
+
+	var order Order
+	return order, convertPaper(detail, &order)
+}
+
+func (p *PaperClient) CancelOrder(_ context.Context, id string, _ ...CancelOrderParams) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	o, ok := p.orders[id]
+	if !ok || o.detail.Status == OrderStatusDone || o.detail.Status == OrderStatusRejected {
+		return OrderNotFoundError{ID: id, Message: "order not found"}
+	}
+
+	p.finish(o, "canceled")
+
+	return nil
+}
+
+func (p *PaperClient) CancelOrderByClientOID(ctx context.Context, clientOID string, params ...CancelOrderParams) error {
+	detail, err := p.GetOrderByClientOID(ctx, clientOID)
+	if err != nil {
+		return err
+	}
+
+	return p.CancelOrder(ctx, detail.ID, params...)
+}
+
+func (p *PaperClient) CancelAllOrders(_ context.Context, params CancelAllOrdersParams) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var ids []string
+	for _, o := range p.orderList {
+		if !o.working() || (params.ProductID != "" && o.detail.ProductID != params.ProductID) {
+			continue
+		}
+
+		p.finish(o, "canceled")
+		ids = append(ids, o.detail.ID)
+	}
+
+	return ids, nil
+}
+
+func (p *PaperClient) GetOrderDetail(_ context.Context, id string) (OrderDetail, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	o, ok := p.orders[id]
+	if !ok {
+		return OrderDetail{}, OrderNotFoundError{ID: id, Message: "order not found"}
+	}
+
+	return o.detail, nil
+}
+
+func (p *PaperClient) GetOrderByClientOID(_ context.Context, clientOID string) (OrderDetail, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := len(p.orderList) - 1; i >= 0; i-- {
+		if p.orderList[i].detail.ClientOID == clientOID {
+			return p.orderList[i].detail, nil
+		}
+	}
+
+	return OrderDetail{}, OrderNotFoundError{ClientOID: clientOID, Message: "order not found"}
+}
+
+// GetOrder retrieves a single order
+//
+// Deprecated: use GetOrderDetail instead.
+func (p *PaperClient) GetOrder(ctx context.Context, id string) (Order, error) {
+	detail, err := p.GetOrderDetail(ctx, id)
+	if err != nil {
+		return Order{}, err
+	}
+
+	var order Order
+	return order, convertPaper(detail, &order)
+}
+
+// ListOrders lists the orders newest first, without a status only working orders are listed.
+func (p *PaperClient) ListOrders(params ListOrdersParams) *Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var orders []OrderDetail
+	for i := len(p.orderList) - 1; i >= 0; i-- {
+		o := p.orderList[i]
+
+		switch {
+		case params.ProductID != "" && o.detail.ProductID != params.ProductID:
+			continue
+		case params.Status == "" && !o.working():
+			continue
+		case params.Status != "" && params.Status != OrderStatusAll && o.detail.Status != params.Status:
+			continue
+		}
+
+		orders = append(orders, o.detail)
+	}
+
+	return newPaperCursor(orders, params.Pagination)
+}
+
+// ListFills lists the fills newest first.
+func (p *PaperClient) ListFills(params ListFillsParams) *Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var fills []Fill
+	for i := len(p.fills) - 1; i >= 0; i-- {
+		fill := p.fills[i]
+		if (params.OrderID != "" && fill.FillID != params.OrderID) || (params.ProductID != "" && fill.ProductID != params.ProductID) {
+			continue
+		}
+
+		fills = append(fills, fill)
+	}
+
+	return newPaperCursor(fills, params.Pagination)
+}
+
+func (p *PaperClient) GetAccounts(_ context.Context) ([]Account, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	accounts := make([]Account, 0, len(p.accounts))
+	for _, account := range p.accounts {
+		accounts = append(accounts, Account{
+			ID:        account.id,
+			Currency:  account.currency,
+			Balance:   decimal.Trim(account.balance, paperPlaces),
+			Hold:      decimal.Trim(account.hold, paperPlaces),
+			Available: decimal.Trim(new(big.Rat).Sub(account.balance, account.hold), paperPlaces),
+		})
+	}
+
+	sort.Slice(accounts, func(i, j int) bool {
+		return accounts[i].Currency < accounts[j].Currency
+	})
+
+	return accounts, nil
+}
+
+// ListAccountLedger lists the ledger entries of the account newest first.
+func (p *PaperClient) ListAccountLedger(id string, params ...GetAccountLedgerParams) *Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var (
+		pagination PaginationParams
+		start, end time.Time
+	)
+	if len(params) > 0 {
+		pagination = params[0].Pagination
+		start, end = params[0].StartDate, params[0].EndDate
+	}
+
+	var entries []LedgerEntry
+	for _, account := range p.accounts {
+		if account.id != id {
+			continue
+		}
+
+		for i := len(account.ledger) - 1; i >= 0; i-- {
+			created := account.ledger[i].CreatedAt.Time()
+			if (!start.IsZero() && created.Before(start)) || (!end.IsZero() && !created.Before(end)) {
+				continue
+			}
+			entries = append(entries, account.ledger[i])
+		}
+	}
+
+	return newPaperCursor(entries, pagination)
+}
+
+// ListHolds lists the holds of the working orders on the account.
+func (p *PaperClient) ListHolds(id string, params ...ListHoldsParams) *Cursor {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var pagination PaginationParams
+	if len(params) > 0 {
+		pagination = params[0].Pagination
+	}
+
+	var holds []Hold
+	for i := len(p.orderList) - 1; i >= 0; i-- {
+		o := p.orderList[i]
+		account := p.accounts[o.holdCurrency]
+		if account == nil || account.id != id || o.hold.Sign() == 0 {
+			continue
+		}
+
+		holds = append(holds, Hold{
+			AccountID: id,
+			CreatedAt: o.detail.CreatedAt,
+			UpdatedAt: Time(o.updatedAt),
+			Amount:    decimal.Trim(o.hold, paperPlaces),
+			Type:      "order",
+			Ref:       o.detail.ID,
+		})
+	}
+
+	return newPaperCursor(holds, pagination)
+}
+
+// Subscribe delivers the simulated user channel messages to handler until ctx is done or handler returns an
+// error. The product ids of message limit the delivered messages, the channels are ignored. Like the exchange, the
+// first message is a subscriptions message sent once the subscription is active.
+func (p *PaperClient) Subscribe(ctx context.Context, message Message, handler func(Message) error) error {
+	sub := &paperSubscriber{
+		products: make(map[string]bool),
+		notify:   make(chan struct{}, 1),
+	}
+	for _, id := range message.ProductIds {
+		sub.products[id] = true
+	}
+
+	sub.queue = append(sub.queue, Message{
+		Type:       "subscriptions",
+		ProductIds: message.ProductIds,
+		Channels:   message.Channels,
+	})
+
+	p.mu.Lock()
+	p.subscribers[sub] = true
+	p.mu.Unlock()
+
+	defer func() {
+		p.mu.Lock()
+		delete(p.subscribers, sub)
+		p.mu.Unlock()
+	}()
+
+	for {
+		sub.mu.Lock()
+		queue := sub.queue
+		sub.queue = nil
+		sub.mu.Unlock()
+
+		for _, msg := range queue {
+			if err := handler(msg); err != nil {
+				return err
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-sub.notify:
+		}
+	}
+}
+
+// simulate expires, triggers and fills the working orders of product after the market changed. trade is the last
+// trade of the matches channel, if any.
+func (p *PaperClient) simulate(productID string, trade *paperLevel) {
+	now := p.now()
+
+	for _, o := range p.orderList {
+		if o.detail.ProductID != productID || !o.working() {
+			continue
+		}
+
+		if o.detail.TimeInForce == TIFGoodTillTime && !now.Before(o.detail.ExpireTime.Time()) {
+			p.finish(o, "canceled")
+			continue
+		}
+
+		if o.detail.Status == OrderStatusActive {
+			if p.triggered(o) {
+				p.emit(p.orderMessage("received", o))
+				p.execute(o)
+			}
+			continue
+		}
+
+		if o.detail.Status == OrderStatusOpen {
+			p.rest(o, trade)
+		}
+	}
+}
+
+func (p *PaperClient) triggered(o *paperOrder) bool {
+	last := p.lastPrice[o.detail.ProductID]
+	if last == nil {
+		last = p.book(o.detail.ProductID).mid()
+	}
+	if last == nil {
+		return false
+	}
+
+	if o.detail.Stop == StopEntry {
+		return last.Cmp(o.stop) >= 0
+	}
+
+	return last.Cmp(o.stop) <= 0
+}
+
+// execute matches a new or triggered order against the book as taker and rests or finishes the remainder.
+func (p *PaperClient) execute(o *paperOrder) {
+	if o.detail.TimeInForce == TIFFillOrKill && p.fillable(o).Cmp(o.remaining) < 0 {
+		p.finish(o, "canceled")
+		return
+	}
+
+	p.take(o)
+
+	switch {
+	case o.remaining.Sign() <= 0:
+		p.finish(o, "filled")
+	case o.detail.Type == OrderTypeMarket || o.detail.TimeInForce == TIFImmediateOrCancel || o.detail.TimeInForce == TIFFillOrKill:
+		p.finish(o, "canceled")
+	default:
+		o.detail.Status = OrderStatusOpen
+		p.emit(p.orderMessage("open", o))
+	}
+}
+
+// takes reports whether o would trade immediately.
+func (p *PaperClient) takes(o *paperOrder) bool {
+	levels := p.book(o.detail.ProductID).opposite(o.detail.Side)
+	return len(levels) > 0 && crosses(o.detail.Side, levels[0].price, o.price)
+}
+
+// fillable returns the size of o which can be filled immediately.
+func (p *PaperClient) fillable(o *paperOrder) *big.Rat {
+	fillable := new(big.Rat)
+	for _, level := range p.book(o.detail.ProductID).opposite(o.detail.Side) {
+		if !crosses(o.detail.Side, level.price, o.price) {
+			break
+		}
+		fillable.Add(fillable, level.size)
+	}
+
+	return fillable
+}
+
+func (p *PaperClient) take(o *paperOrder) {
+	book := p.book(o.detail.ProductID)
+
+	for _, level := range book.opposite(o.detail.Side) {
+		if o.remaining.Sign() <= 0 || !crosses(o.detail.Side, level.price, o.price) {
+			return
+		}
+
+		size := p.tradeSize(o, level, p.takerRate)
+		if size.Sign() <= 0 {
+			return
+		}
+
+		book.consume(o.detail.Side, level, size)
+		p.fill(o, level.price, size, "T")
+	}
+}
+
+// rest fills a resting limit order as maker at its own price when the opposite side of the book or a trade moved
+// through it.
+func (p *PaperClient) rest(o *paperOrder, trade *paperLevel) {
+	book := p.book(o.detail.ProductID)
+
+	for _, level := range book.opposite(o.detail.Side) {
+		if o.remaining.Sign() <= 0 || !crosses(o.detail.Side, level.price, o.price) {
+			break
+		}
+
+		size := p.tradeSize(o, &paperLevel{price: o.price, size: level.size}, p.makerRate)
+		if size.Sign() <= 0 {
+			break
+		}
+
+		book.consume(o.detail.Side, level, size)
+		p.fill(o, o.price, size, "M")
+	}
+
+	// A trade at the order price may have been ahead in the queue, only trades through the price fill it.
+	if trade != nil && trade.size.Sign() > 0 && o.remaining.Sign() > 0 && trade.price.Cmp(o.price) != 0 && crosses(o.detail.Side, trade.price, o.price) {
+		size := p.tradeSize(o, &paperLevel{price: o.price, size: trade.size}, p.makerRate)
+		if size.Sign() > 0 {
+			trade.size.Sub(trade.size, size)
+			p.fill(o, o.price, size, "M")
+		}
+	}
+
+	if o.remaining.Sign() <= 0 {
+		p.finish(o, "filled")
+	}
+}
+
+// tradeSize returns the size o can trade against level, limited by the remainder of the order and, for market
+// orders without a hold, by the available balance.
+func (p *PaperClient) tradeSize(o *paperOrder, level *paperLevel, rate *big.Rat) *big.Rat {
+	increment, ok := decimal.Parse(o.product.BaseIncrement)
+	if !ok {
+		increment = new(big.Rat)
+	}
+
+	// Cost of one unit including the fee.
+	unit := new(big.Rat).Mul(level.price, new(big.Rat).Add(big.NewRat(1, 1), rate))
+
+	size := new(big.Rat).Set(level.size)
+	if o.byFunds {
+		limit := new(big.Rat).Quo(o.remaining, unit)
+		if o.detail.Side == SideSell {
+			limit.Quo(o.remaining, level.price)
+		}
+		size = minRat(size, limit)
+	} else {
+		size = minRat(size, o.remaining)
+	}
+
+	if o.hold.Sign() == 0 {
+		if o.detail.Side == SideBuy {
+			quote := p.accounts[o.product.QuoteCurrency]
+			size = minRat(size, new(big.Rat).Quo(available(quote), unit))
+		} else {
+			size = minRat(size, available(p.accounts[o.product.BaseCurrency]))
+		}
+	}
+
+	return decimal.Floor(size, increment)
+}
+
+func (p *PaperClient) fill(o *paperOrder, price, size *big.Rat, liquidity string) {
+	rate := p.takerRate
+	if liquidity == "M" {
+		rate = p.makerRate
+	}
+
+	value := new(big.Rat).Mul(price, size)
+	fee := new(big.Rat).Mul(value, rate)
+
+	o.filled.Add(o.filled, size)
+	o.value.Add(o.value, value)
+	o.fees.Add(o.fees, fee)
+
+	switch {
+	case !o.byFunds:
+		o.remaining.Sub(o.remaining, size)
+	case o.detail.Side == SideBuy:
+		o.remaining.Sub(o.remaining, new(big.Rat).Add(value, fee))
+	default:
+		o.remaining.Sub(o.remaining, value)
+	}
+
+	o.detail.FilledSize = Decimal(decimal.Trim(o.filled, paperPlaces))
+	o.detail.ExecutedValue = Decimal(decimal.Trim(o.value, paperPlaces))
+	o.detail.FillFees = Decimal(decimal.Trim(o.fees, paperPlaces))
+
+	p.nextTradeID++
+	now := p.now()
+	o.updatedAt = now
+
+	base := p.mustAccount(o.product.BaseCurrency)
+	quote := p.mustAccount(o.product.QuoteCurrency)
+	details := LedgerDetails{OrderID: o.detail.ID, TradeID: strconv.Itoa(p.nextTradeID), ProductID: o.detail.ProductID}
+
+	if o.hold.Sign() > 0 {
+		p.release(o, new(big.Rat).Sub(o.hold, p.holdAmount(o)))
+	}
+
+	if o.detail.Side == SideBuy {
+		p.entry(base, LedgerEntryTypeMatch, size, details, now)
+		p.entry(quote, LedgerEntryTypeMatch, new(big.Rat).Neg(value), details, now)
+	} else {
+		p.entry(base, LedgerEntryTypeMatch, new(big.Rat).Neg(size), details, now)
+		p.entry(quote, LedgerEntryTypeMatch, value, details, now)
+	}
+	if fee.Sign() > 0 {
+		p.entry(quote, LedgerEntryTypeFee, new(big.Rat).Neg(fee), details, now)
+	}
+
+	p.fills = append(p.fills, Fill{
+		TradeID:   p.nextTradeID,
+		ProductID: o.detail.ProductID,
+		Price:     decimal.Trim(price, paperPlaces),
+		Size:      decimal.Trim(size, paperPlaces),
+		FillID:    o.detail.ID,
+		CreatedAt: Time(now),
+		Fee:       decimal.Trim(fee, paperPlaces),
+		Settled:   true,
+		Side:      string(o.detail.Side),
+		Liquidity: liquidity,
+	})
+
+	msg := p.orderMessage("match", o)
+	msg.TradeID = p.nextTradeID
+	msg.Price = decimal.Trim(price, paperPlaces)
+	msg.Size = decimal.Trim(size, paperPlaces)
+	msg.Funds = ""
+	if liquidity == "M" {
+		msg.MakerOrderID = o.detail.ID
+		msg.MakerFeeRate = decimal.Trim(rate, paperPlaces)
+	} else {
+		msg.TakerOrderID = o.detail.ID
+		msg.TakerFeeRate = decimal.Trim(rate, paperPlaces)
+		// The side of match messages is the side of the maker.
+		msg.Side = string(SideSell)
+		if o.detail.Side == SideSell {
+			msg.Side = string(SideBuy)
+		}
+	}
+	msg.OrderID = ""
+	p.emit(msg)
+}
+
+func (p *PaperClient) finish(o *paperOrder, reason string) {
+	p.release(o, o.hold)
+
+	now := p.now()
+	o.detail.Status = OrderStatusDone
+	o.detail.DoneReason = reason
+	o.detail.DoneAt = Time(now)
+	o.detail.Settled = true
+	o.updatedAt = now
+
+	msg := p.orderMessage("done", o)
+	msg.Reason = reason
+	if !o.byFunds {
+		msg.RemainingSize = decimal.Trim(o.remaining, paperPlaces)
+	}
+	p.emit(msg)
+}
+
+// holdFunds holds the funds of limit orders on the account they are paid from. Market orders are filled right away
+// and limited to the available balance instead.
+func (p *PaperClient) holdFunds(o *paperOrder) error {
+	if o.detail.Type == OrderTypeMarket {
+		return nil
+	}
+
+	o.holdCurrency = o.product.BaseCurrency
+	if o.detail.Side == SideBuy {
+		o.holdCurrency = o.product.QuoteCurrency
+	}
+	amount := p.holdAmount(o)
+
+	account, err := p.account(o.holdCurrency)
+	if err != nil {
+		return err
+	}
+
+	if available(account).Cmp(amount) < 0 {
+		return Error{Message: "Insufficient funds"}
+	}
+
+	account.hold.Add(account.hold, amount)
+	o.hold = amount
+
+	return nil
+}
+
+// holdAmount returns the hold needed for the remainder of a limit order, buys hold the cost including the taker
+// fee.
+func (p *PaperClient) holdAmount(o *paperOrder) *big.Rat {
+	amount := new(big.Rat).Set(o.remaining)
+	if o.detail.Side == SideBuy {
+		amount.Mul(amount, o.price)
+		amount.Mul(amount, new(big.Rat).Add(big.NewRat(1, 1), p.takerRate))
+	}
+
+	return amount
+}
+
+// release removes up to amount of the hold of o.
+func (p *PaperClient) release(o *paperOrder, amount *big.Rat) {
+	if o.hold.Sign() == 0 {
+		return
+	}
+
+	amount = minRat(amount, o.hold)
+	account := p.mustAccount(o.holdCurrency)
+	account.hold.Sub(account.hold, amount)
+	o.hold = new(big.Rat).Sub(o.hold, amount)
+}
+
+func (p *PaperClient) entry(account *paperAccount, entryType LedgerEntryType, amount *big.Rat, details LedgerDetails, now time.Time) {
+	account.balance.Add(account.balance, amount)
+
+	p.nextEntryID++
+	account.ledger = append(account.ledger, LedgerEntry{
+		ID:        strconv.Itoa(p.nextEntryID),
+		CreatedAt: Time(now),
+		Amount:    Decimal(decimal.Trim(amount, paperPlaces)),
+		Balance:   Decimal(decimal.Trim(account.balance, paperPlaces)),
+		Type:      entryType,
+		Details:   details,
+	})
+}
+
+func (p *PaperClient) record(o *paperOrder) {
+	p.orders[o.detail.ID] = o
+	p.orderList = append(p.orderList, o)
+}
+
+func (p *PaperClient) orderMessage(msgType string, o *paperOrder) Message {
+	msg := Message{
+		Type:      msgType,
+		Time:      Time(p.now()),
+		ProductID: o.detail.ProductID,
+		OrderID:   o.detail.ID,
+		ClientOID: o.detail.ClientOID,
+		Side:      string(o.detail.Side),
+		OrderType: string(o.detail.Type),
+		Price:     o.detail.Price,
+		Size:      o.detail.Size,
+		Funds:     o.detail.Funds,
+	}
+
+	if msgType == "open" {
+		msg.RemainingSize = decimal.Trim(o.remaining, paperPlaces)
+	}
+
+	return msg
+}
+
+// emit queues msg for every subscriber, it must be called with the lock held so messages keep their order.
+func (p *PaperClient) emit(msg Message) {
+	p.sequence++
+	msg.Sequence = p.sequence
+
+	for sub := range p.subscribers {
+		if len(sub.products) > 0 && !sub.products[msg.ProductID] {
+			continue
+		}
+
+		sub.mu.Lock()
+		sub.queue = append(sub.queue, msg)
+		sub.mu.Unlock()
+
+		select {
+		case sub.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (p *PaperClient) book(productID string) *paperBook {
+	book, ok := p.books[productID]
+	if !ok {
+		book = newPaperBook()
+		p.books[productID] = book
+	}
+
+	return book
+}
+
+func (p *PaperClient) account(currency string) (*paperAccount, error) {
+	if account, ok := p.accounts[currency]; ok {
+		return account, nil
+	}
+
+	id, err := newClientOID()
+	if err != nil {
+		return nil, err
+	}
+
+	account := &paperAccount{id: id, currency: currency, balance: new(big.Rat), hold: new(big.Rat)}
+	p.accounts[currency] = account
+
+	return account, nil
+}
+
+func (p *PaperClient) mustAccount(currency string) *paperAccount {
+	account, err := p.account(currency)
+	if err != nil {
+		// Only fails when the system random source fails.
+		panic(err)
+	}
+
+	return account
+}
+
+func (p *PaperClient) now() time.Time {
+	// Times are truncated to the precision of the API so they survive a json round trip.
+	return p.clock.Now().UTC().Truncate(time.Microsecond)
+}
+
+// working reports whether the order can still trade.
+func (o *paperOrder) working() bool {
+	return o.detail.Status == OrderStatusOpen || o.detail.Status == OrderStatusActive || o.detail.Status == OrderStatusPending
+}
+
+func available(account *paperAccount) *big.Rat {
+	if account == nil {
+		return new(big.Rat)
+	}
+
+	return new(big.Rat).Sub(account.balance, account.hold)
+}
+
+func minRat(a, b *big.Rat) *big.Rat {
+	if a.Cmp(b) <= 0 {
+		return a
+	}
+
+	return b
+}
+
+func cancelAfterDuration(cancelAfter CancelAfter) time.Duration {
+	switch cancelAfter {
+	case CancelAfterMinute:
+		return time.Minute
+	case CancelAfterHour:
+		return time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
+
+// newPaperCursor pages through items, a slice, using the index of the next item as the after cursor.
+func newPaperCursor(items interface{}, pagination PaginationParams) *Cursor {
+	return newPagerCursor(pagination, func(_ context.Context, i interface{}, p *PaginationParams, direction string) error {
+		var all []json.RawMessage
+		if err := convertPaper(items, &all); err != nil {
+			return err
+		}
+
+		limit := p.Limit
+		if limit <= 0 {
+			limit = 100
+		}
+
+		start, end := 0, 0
+		if direction == "prev" {
+			end, _ = strconv.Atoi(p.Before)
+			start = end - limit
+		} else {
+			start, _ = strconv.Atoi(p.After)
+			end = start + limit
+		}
+		if start < 0 {
+			start = 0
+		}
+		if end > len(all) {
+			end = len(all)
+		}
+		if start > end {
+			start = end
+		}
+
+		p.After, p.Before = "", ""
+		if end < len(all) {
+			p.After = strconv.Itoa(end)
+		}
+		if start > 0 {
+			p.Before = strconv.Itoa(start)
+		}
+
+		return convertPaper(all[start:end], i)
+	})
+}
+
+// convertPaper copies from into to through their json representation, like a response of the API would be.
+func convertPaper(from, to interface{}) error {
+	data, err := json.Marshal(from)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, to)
+}
@@ -0,0 +1,125 @@
+package coinbasepro
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// paperBook is the level 2 book of a product as seen by the paper client.
+type paperBook struct {
+	bids map[string]*paperLevel
+	asks map[string]*paperLevel
+}
+
+type paperLevel struct {
+	price *big.Rat
+	size  *big.Rat
+}
+
+func newPaperBook() *paperBook {
+	return &paperBook{
+		bids: make(map[string]*paperLevel),
+		asks: make(map[string]*paperLevel),
+	}
+}
+
+func (b *paperBook) reset(bids, asks []SnapshotEntry) {
+	b.bids = make(map[string]*paperLevel)
+	b.asks = make(map[string]*paperLevel)
+
+	for _, e := range bids {
+		b.set(b.bids, e.Price, e.Size)
+	}
+	for _, e := range asks {
+		b.set(b.asks, e.Price, e.Size)
+	}
+}
+
+func (b *paperBook) update(change SnapshotChange) {
+	if change.Side == string(SideBuy) {
+		b.set(b.bids, change.Price, change.Size)
+	} else {
+		b.set(b.asks, change.Price, change.Size)
+	}
+}
+
+func (b *paperBook) set(levels map[string]*paperLevel, price, size string) {
+	p, okPrice := decimal.Parse(price)
+	s, okSize := decimal.Parse(size)
+	if !okPrice || !okSize {
+		return
+	}
+
+	key := p.RatString()
+	if s.Sign() <= 0 {
+		delete(levels, key)
+		return
+	}
+
+	levels[key] = &paperLevel{price: p, size: s}
+}
+
+// opposite returns the levels an order of side trades against, best price first.
+func (b *paperBook) opposite(side Side) []*paperLevel {
+	if side == SideBuy {
+		return sortedLevels(b.asks, false)
+	}
+
+	return sortedLevels(b.bids, true)
+}
+
+// consume removes size from a level, the next book update replaces the level again.
+func (b *paperBook) consume(side Side, level *paperLevel, size *big.Rat) {
+	level.size.Sub(level.size, size)
+	if level.size.Sign() > 0 {
+		return
+	}
+
+	if side == SideBuy {
+		delete(b.asks, level.price.RatString())
+	} else {
+		delete(b.bids, level.price.RatString())
+	}
+}
+
+func (b *paperBook) mid() *big.Rat {
+	bids, asks := sortedLevels(b.bids, true), sortedLevels(b.asks, false)
+	if len(bids) == 0 || len(asks) == 0 {
+		return nil
+	}
+
+	mid := new(big.Rat).Add(bids[0].price, asks[0].price)
+	return mid.Quo(mid, big.NewRat(2, 1))
+}
+
+func sortedLevels(levels map[string]*paperLevel, descending bool) []*paperLevel {
+	sorted := make([]*paperLevel, 0, len(levels))
+	for _, level := range levels {
+		sorted = append(sorted, level)
+	}
+
+	sort.Slice(sorted, func(i, j int) bool {
+		if descending {
+			return sorted[i].price.Cmp(sorted[j].price) > 0
+		}
+		return sorted[i].price.Cmp(sorted[j].price) < 0
+	})
+
+	return sorted
+}
+
+// crosses reports whether a trade at price is acceptable for an order of side with limit, a nil limit accepts
+// every price.
+func crosses(side Side, price, limit *big.Rat) bool {
+	if limit == nil {
+		return true
+	}
+
+	if side == SideBuy {
+		return price.Cmp(limit) <= 0
+	}
+
+	return price.Cmp(limit) >= 0
+}
@@ -0,0 +1,176 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestPaperClient(t *testing.T) {
+	paper, err := coinbasepro.NewPaperClient(nil,
+		coinbasepro.WithPaperBalances(map[string]string{"USD": "1000", "BTC": "1"}),
+		coinbasepro.WithPaperFees(coinbasepro.Fees{MakerFeeRate: "0.001", TakerFeeRate: "0.002"}),
+		coinbasepro.WithPaperProducts(testProduct),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var _ coinbasepro.Trader = paper
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if err := paper.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	messages := make(chan coinbasepro.Message, 100)
+	go paper.Subscribe(ctx, coinbasepro.Message{ProductIds: []string{"BTC-USD"}}, func(msg coinbasepro.Message) error {
+		messages <- msg
+		return nil
+	})
+	// Wait for the subscription, messages emitted before it are not delivered.
+	select {
+	case msg := <-messages:
+		if msg.Type != "subscriptions" {
+			t.Fatalf("expected subscriptions message, got %s", msg.Type)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("subscription not active")
+	}
+
+	paper.HandleMessage(coinbasepro.Message{
+		Type:      "snapshot",
+		ProductID: "BTC-USD",
+		Bids:      []coinbasepro.SnapshotEntry{{Price: "99", Size: "1"}},
+		Asks:      []coinbasepro.SnapshotEntry{{Price: "101", Size: "0.5"}, {Price: "102", Size: "1"}},
+	})
+
+	// Takes 0.5 at 101 and rests the remainder at 101.5.
+	order, err := paper.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "101.5", "1", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != coinbasepro.OrderStatusOpen || order.FilledSize != "0.5" || order.FillFees != "0.101" {
+		t.Errorf("unexpected order %+v", order)
+	}
+
+	usd := paperAccount(t, paper, "USD")
+	if usd.Hold != "50.8515" || usd.Balance != "949.399" {
+		t.Errorf("unexpected USD account %+v", usd)
+	}
+
+	// A new ask below the resting order fills it as maker at its own price.
+	paper.HandleMessage(coinbasepro.Message{
+		Type:      "l2update",
+		ProductID: "BTC-USD",
+		Changes:   []coinbasepro.SnapshotChange{{Side: "sell", Price: "101.2", Size: "2"}},
+	})
+
+	order, err = paper.GetOrderDetail(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Status != coinbasepro.OrderStatusDone || order.DoneReason != "filled" || order.ExecutedValue != "101.25" {
+		t.Errorf("unexpected order %+v", order)
+	}
+
+	usd = paperAccount(t, paper, "USD")
+	if usd.Hold != "0" || usd.Balance != "898.59825" {
+		t.Errorf("unexpected USD account %+v", usd)
+	}
+	if btc := paperAccount(t, paper, "BTC"); btc.Balance != "2" {
+		t.Errorf("unexpected BTC account %+v", btc)
+	}
+
+	var fills []coinbasepro.Fill
+	cursor := paper.ListFills(coinbasepro.ListFillsParams{OrderID: order.ID, Pagination: coinbasepro.PaginationParams{Limit: 1}})
+	for cursor.HasMore {
+		var page []coinbasepro.Fill
+		if err := cursor.NextPage(ctx, &page); err != nil {
+			t.Fatal(err)
+		}
+		fills = append(fills, page...)
+	}
+	if len(fills) != 2 || fills[0].Liquidity != "M" || fills[1].Liquidity != "T" {
+		t.Errorf("unexpected fills %+v", fills)
+	}
+
+	var ledger []coinbasepro.LedgerEntry
+	if err := paper.ListAccountLedger(usd.ID).NextPage(ctx, &ledger); err != nil {
+		t.Fatal(err)
+	}
+	if len(ledger) != 4 || ledger[0].Type != "fee" || ledger[0].Balance != "898.59825" {
+		t.Errorf("unexpected ledger %+v", ledger)
+	}
+
+	// Post only orders which would take are rejected.
+	rejected, err := paper.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "99", "0.1", coinbasepro.GoodTillCancelled().PostOnly()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rejected.Status != coinbasepro.OrderStatusRejected {
+		t.Errorf("expected post only order to be rejected, got %s", rejected.Status)
+	}
+
+	_, err = paper.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "90", "10", nil))
+	var coinbaseErr coinbasepro.Error
+	if !errors.As(err, &coinbaseErr) || coinbaseErr.Message != "Insufficient funds" {
+		t.Errorf("expected insufficient funds, got %v", err)
+	}
+
+	resting, err := paper.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideSell, "110", "0.5", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if btc := paperAccount(t, paper, "BTC"); btc.Hold != "0.5" {
+		t.Errorf("expected 0.5 BTC on hold, got %+v", btc)
+	}
+
+	ids, err := paper.CancelAllOrders(ctx, coinbasepro.CancelAllOrdersParams{ProductID: "BTC-USD"})
+	if err != nil || len(ids) != 1 || ids[0] != resting.ID {
+		t.Errorf("unexpected cancel %v %v", ids, err)
+	}
+	if err := paper.CancelOrder(ctx, resting.ID); !errors.Is(err, coinbasepro.ErrOrderNotFound) {
+		t.Errorf("expected order not found, got %v", err)
+	}
+
+	var types []string
+	for len(types) < 8 {
+		select {
+		case msg := <-messages:
+			types = append(types, msg.Type)
+		case <-time.After(time.Second):
+			t.Fatalf("expected more messages, got %v", types)
+		}
+	}
+
+	expected := []string{"received", "match", "open", "match", "done", "received", "open", "done"}
+	for i, msgType := range expected {
+		if types[i] != msgType {
+			t.Fatalf("expected messages %v, got %v", expected, types)
+		}
+	}
+}
+
+func paperAccount(t *testing.T, paper *coinbasepro.PaperClient, currency string) coinbasepro.Account {
+	t.Helper()
+
+	accounts, err := paper.GetAccounts(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, account := range accounts {
+		if account.Currency == currency {
+			return account
+		}
+	}
+
+	t.Fatalf("no %s account", currency)
+	return coinbasepro.Account{}
+}
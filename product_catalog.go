@@ -0,0 +1,232 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProductChange is emitted by a ProductCatalog when the trading flags, increments or status of a product change.
+// Previous is the zero value when the product was not known before.
+type ProductChange struct {
+	Previous Product
+	Current  Product
+	// Fields contains the json names of the fields which changed.
+	Fields []string
+}
+
+// ProductCatalog keeps an in-memory copy of the products and currencies, so lookups don't hit the API. It is
+// refreshed in the background by Run and can be kept up to date in between by passing status channel messages to
+// HandleMessage.
+type ProductCatalog struct {
+	client          *client
+	refreshInterval time.Duration
+
+	mu         sync.RWMutex
+	products   map[string]Product
+	pairs      map[string]string
+	currencies map[string]Currency
+	handlers   []func(ProductChange)
+}
+
+type ProductCatalogOption func(*ProductCatalog) error
+
+// WithCatalogRefreshInterval sets how often Run reloads the products and currencies, defaults to five minutes.
+func WithCatalogRefreshInterval(interval time.Duration) ProductCatalogOption {
+	return func(pc *ProductCatalog) error {
+		if interval <= 0 {
+			return errors.New("interval must be greater than 0")
+		}
+		pc.refreshInterval = interval
+
+		return nil
+	}
+}
+
+// NewProductCatalog creates an empty catalog, call Load or Run to fill it.
+func NewProductCatalog(c *client, opts ...ProductCatalogOption) (*ProductCatalog, error) {
+	pc := &ProductCatalog{
+		client:          c,
+		refreshInterval: 5 * time.Minute,
+		products:        make(map[string]Product),
+		pairs:           make(map[string]string),
+		currencies:      make(map[string]Currency),
+	}
+
+	for _, opt := range opts {
+		if err := opt(pc); err != nil {
+			return nil, err
+		}
+	}
+
+	return pc, nil
+}
+
+// OnChange registers a handler which is called for every product change. Handlers are called synchronously from
+// Load, Run and HandleMessage.
+func (pc *ProductCatalog) OnChange(handler func(ProductChange)) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.handlers = append(pc.handlers, handler)
+}
+
+// Load fetches the products and currencies from the API and merges them into the catalog.
+func (pc *ProductCatalog) Load(ctx context.Context) error {
+	products, err := pc.client.GetProducts(ctx)
+	if err != nil {
+		return err
+	}
+
+	currencies, err := pc.client.GetCurrencies(ctx)
+	if err != nil {
+		return err
+	}
+
+	pc.merge(products, currencies)
+
+	return nil
+}
+
+// Run loads the catalog and then refreshes it every refresh interval until ctx is done. The first load must
+// succeed, errors of later refreshes are ignored and the previous state is kept.
+func (pc *ProductCatalog) Run(ctx context.Context) error {
+	if err := pc.Load(ctx); err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pc.refreshInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			_ = pc.Load(ctx)
+		}
+	}
+}
+
+// HandleMessage merges the products and currencies of status channel messages, other messages are ignored. It
+// can be used as, or called from, the handler passed to Subscribe.
+func (pc *ProductCatalog) HandleMessage(msg Message) error {
+	if msg.Type != "status" {
+		return nil
+	}
+
+	pc.merge(msg.Products, msg.Currencies)
+
+	return nil
+}
+
+// Product returns the product with id.
+func (pc *ProductCatalog) Product(id string) (Product, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	product, ok := pc.products[id]
+	return product, ok
+}
+
+// ProductByCurrencies returns the product trading base against quote.
+func (pc *ProductCatalog) ProductByCurrencies(base, quote string) (Product, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	id, ok := pc.pairs[pairKey(base, quote)]
+	if !ok {
+		return Product{}, false
+	}
+
+	product, ok := pc.products[id]
+	return product, ok
+}
+
+// Products returns all products ordered by id.
+func (pc *ProductCatalog) Products() []Product {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	products := make([]Product, 0, len(pc.products))
+	for _, product := range pc.products {
+		products = append(products, product)
+	}
+	sort.Slice(products, func(i, j int) bool {
+		return products[i].ID < products[j].ID
+	})
+
+	return products
+}
+
+// Currency returns the currency with id.
+func (pc *ProductCatalog) Currency(id string) (Currency, bool) {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	currency, ok := pc.currencies[id]
+	return currency, ok
+}
+
+func (pc *ProductCatalog) merge(products []Product, currencies []Currency) {
+	var changes []ProductChange
+
+	pc.mu.Lock()
+	for _, current := range products {
+		previous, ok := pc.products[current.ID]
+		if fields := changedProductFields(previous, current); !ok || len(fields) > 0 {
+			changes = append(changes, ProductChange{Previous: previous, Current: current, Fields: fields})
+		}
+
+		pc.products[current.ID] = current
+		pc.pairs[pairKey(current.BaseCurrency, current.QuoteCurrency)] = current.ID
+	}
+
+	for _, currency := range currencies {
+		pc.currencies[currency.ID] = currency
+	}
+
+	handlers := pc.handlers
+	pc.mu.Unlock()
+
+	for _, change := range changes {
+		for _, handler := range handlers {
+			handler(change)
+		}
+	}
+}
+
+func pairKey(base, quote string) string {
+	return base + "-" + quote
+}
+
+// changedProductFields compares the fields which affect trading.
+func changedProductFields(previous, current Product) []string {
+	var fields []string
+
+	for _, f := range []struct {
+		name     string
+		previous interface{}
+		current  interface{}
+	}{
+		{"base_min_size", previous.BaseMinSize, current.BaseMinSize},
+		{"base_max_size", previous.BaseMaxSize, current.BaseMaxSize},
+		{"quote_increment", previous.QuoteIncrement, current.QuoteIncrement},
+		{"base_increment", previous.BaseIncrement, current.BaseIncrement},
+		{"min_market_funds", previous.MinMarketFunds, current.MinMarketFunds},
+		{"max_market_funds", previous.MaxMarketFunds, current.MaxMarketFunds},
+		{"post_only", previous.PostOnly, current.PostOnly},
+		{"limit_only", previous.LimitOnly, current.LimitOnly},
+		{"cancel_only", previous.CancelOnly, current.CancelOnly},
+		{"trading_disabled", previous.TradingDisabled, current.TradingDisabled},
+		{"status", previous.Status, current.Status},
+	} {
+		if f.previous != f.current {
+			fields = append(fields, f.name)
+		}
+	}
+
+	return fields
+}
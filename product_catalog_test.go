@@ -0,0 +1,76 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestProductCatalog(t *testing.T) {
+	handler := http.NewServeMux()
+	handler.HandleFunc("/products", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]coinbasepro.Product{testProduct, {ID: "ETH-BTC", BaseCurrency: "ETH", QuoteCurrency: "BTC"}})
+	})
+	handler.HandleFunc("/currencies", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]coinbasepro.Currency{{ID: "BTC", Name: "Bitcoin", MinSize: "0.00000001"}})
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+	catalog, err := coinbasepro.NewProductCatalog(client)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes []coinbasepro.ProductChange
+	catalog.OnChange(func(change coinbasepro.ProductChange) {
+		changes = append(changes, change)
+	})
+
+	ctx := context.Background()
+	if err := catalog.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 2 {
+		t.Fatalf("expected a change for every new product, got %d", len(changes))
+	}
+
+	product, ok := catalog.ProductByCurrencies("BTC", "USD")
+	if !ok || product.ID != "BTC-USD" {
+		t.Fatal("product not found by currencies")
+	}
+
+	if _, ok := catalog.Currency("BTC"); !ok {
+		t.Fatal("currency not found")
+	}
+
+	// Reloading unchanged products does not emit changes.
+	changes = nil
+	if err := catalog.Load(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes, got %d", len(changes))
+	}
+
+	updated := testProduct
+	updated.CancelOnly = true
+	updated.Status = "cancel_only"
+
+	err = catalog.HandleMessage(coinbasepro.Message{Type: "status", Products: []coinbasepro.Product{updated}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(changes) != 1 || len(changes[0].Fields) != 2 {
+		t.Fatalf("expected cancel_only and status to change, got %+v", changes)
+	}
+
+	if product, _ := catalog.Product("BTC-USD"); !product.CancelOnly {
+		t.Fatal("status update was not merged")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 type Profile struct {
@@ -22,6 +23,43 @@ type ProfileTransfer struct {
 	Amount   string `json:"amount"`
 }
 
+type createProfileRequest struct {
+	Name string `json:"name"`
+}
+
+type renameProfileRequest struct {
+	ProfileID string `json:"profile_id"`
+	Name      string `json:"name"`
+}
+
+type deactivateProfileRequest struct {
+	ProfileID string `json:"profile_id"`
+	To        string `json:"to"`
+}
+
+// ForProfile returns a copy of the client scoped to profileID. Orders are placed in the profile, list endpoints,
+// CancelAllOrders and CreateConversion default to it and Subscribe drops messages of other profiles.
+func (c *client) ForProfile(profileID string) *client {
+	scoped := *c
+	scoped.profileID = profileID
+
+	return &scoped
+}
+
+// ProfileID returns the profile the client is scoped to, it is empty for clients which are not scoped.
+func (c *client) ProfileID() string {
+	return c.profileID
+}
+
+// profile returns profileID, or the profile of the client when it is empty.
+func (c *client) profile(profileID string) string {
+	if profileID != "" {
+		return profileID
+	}
+
+	return c.profileID
+}
+
 // httpClient Funcs
 
 // GetProfiles retrieves a list of profiles
@@ -37,8 +75,8 @@ func (c *client) GetProfiles(ctx context.Context) ([]Profile, error) {
 func (c *client) GetProfile(ctx context.Context, id string) (Profile, error) {
 	var profile Profile
 
-	url := fmt.Sprintf("/profiles/%s", id)
-	_, err := c.Request(ctx, http.MethodGet, url, nil, &profile)
+	requestURL := fmt.Sprintf("/profiles/%s", url.PathEscape(id))
+	_, err := c.Request(ctx, http.MethodGet, requestURL, nil, &profile)
 	return profile, err
 }
 
@@ -49,3 +87,31 @@ func (c *client) CreateProfileTransfer(ctx context.Context, newTransfer ProfileT
 
 	return err
 }
+
+// CreateProfile creates a new profile
+func (c *client) CreateProfile(ctx context.Context, name string) (Profile, error) {
+	var profile Profile
+
+	url := fmt.Sprintf("/profiles")
+	_, err := c.Request(ctx, http.MethodPost, url, createProfileRequest{Name: name}, &profile)
+	return profile, err
+}
+
+// RenameProfile renames a profile
+func (c *client) RenameProfile(ctx context.Context, id, name string) (Profile, error) {
+	var profile Profile
+
+	requestURL := fmt.Sprintf("/profiles/%s", url.PathEscape(id))
+	_, err := c.Request(ctx, http.MethodPut, requestURL, renameProfileRequest{ProfileID: id, Name: name}, &profile)
+	return profile, err
+}
+
+// DeactivateProfile moves the funds of a profile to the profile to and deactivates it, the default profile cannot be
+// deactivated
+func (c *client) DeactivateProfile(ctx context.Context, id, to string) (Profile, error) {
+	var profile Profile
+
+	requestURL := fmt.Sprintf("/profiles/%s/deactivate", url.PathEscape(id))
+	_, err := c.Request(ctx, http.MethodPut, requestURL, deactivateProfileRequest{ProfileID: id, To: to}, &profile)
+	return profile, err
+}
@@ -80,3 +80,165 @@ func TestCreateProfileTransfer(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestCreateProfile(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	profile, err := client.CreateProfile(ctx, "strategy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.ID == "" || profile.Name != "strategy" || !profile.Active {
+		t.Fatalf("unexpected profile %+v", profile)
+	}
+
+	renamed, err := client.RenameProfile(ctx, profile.ID, "momentum")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.ID != profile.ID || renamed.Name != "momentum" {
+		t.Errorf("unexpected renamed profile %+v", renamed)
+	}
+}
+
+func TestDeactivateProfile(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var defaultProfile coinbasepro.Profile
+	for _, p := range profiles {
+		if p.IsDefault {
+			defaultProfile = p
+		}
+	}
+
+	profile, err := client.CreateProfile(ctx, "retired")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateProfileTransfer(ctx, coinbasepro.ProfileTransfer{From: defaultProfile.ID, To: profile.ID, Currency: "USD", Amount: "10.00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	deactivated, err := client.DeactivateProfile(ctx, profile.ID, defaultProfile.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deactivated.Active {
+		t.Error("expected profile to be deactivated")
+	}
+
+	var transfers []coinbasepro.TransferDetail
+	if err := client.ListTransfers(coinbasepro.ListTransfersParams{Type: coinbasepro.TransferTypeInternalWithdraw, ProfileID: profile.ID}).NextPage(ctx, &transfers); err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 1 || transfers[0].Amount != "10.00000000" {
+		t.Errorf("expected funds to be moved to the default profile, got %+v", transfers)
+	}
+}
+
+func TestForProfile(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var defaultProfile coinbasepro.Profile
+	for _, p := range profiles {
+		if p.IsDefault {
+			defaultProfile = p
+		}
+	}
+
+	profile, err := client.CreateProfile(ctx, "scoped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateProfileTransfer(ctx, coinbasepro.ProfileTransfer{From: defaultProfile.ID, To: profile.ID, Currency: "USD", Amount: "1000.00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	scoped := client.ForProfile(profile.ID)
+	if scoped.ProfileID() != profile.ID || client.ProfileID() != "" {
+		t.Fatal("expected only the copy to be scoped")
+	}
+
+	if _, err := client.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "1.00", nil)); err != nil {
+		t.Fatal(err)
+	}
+	order, err := scoped.PlaceOrder(ctx, coinbasepro.NewLimitOrder("BTC-USD", coinbasepro.SideBuy, "100.00", "1.00", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.ProfileID != profile.ID {
+		t.Errorf("expected order in profile %s, got %s", profile.ID, order.ProfileID)
+	}
+
+	var orders []coinbasepro.OrderDetail
+	if err := scoped.ListOrders(coinbasepro.ListOrdersParams{}).NextPage(ctx, &orders); err != nil {
+		t.Fatal(err)
+	}
+	if len(orders) != 1 || orders[0].ID != order.ID {
+		t.Errorf("expected only the order of the profile, got %+v", orders)
+	}
+
+	canceled, err := scoped.CancelAllOrders(ctx, coinbasepro.CancelAllOrdersParams{ProductID: "BTC-USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(canceled) != 1 || canceled[0] != order.ID {
+		t.Errorf("expected only the order of the profile to be canceled, got %v", canceled)
+	}
+}
+
+func TestForProfileCreateOrder(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	profiles, err := client.GetProfiles(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var defaultProfile coinbasepro.Profile
+	for _, p := range profiles {
+		if p.IsDefault {
+			defaultProfile = p
+		}
+	}
+
+	profile, err := client.CreateProfile(ctx, "scoped")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.CreateProfileTransfer(ctx, coinbasepro.ProfileTransfer{From: defaultProfile.ID, To: profile.ID, Currency: "USD", Amount: "1000.00"}); err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := client.ForProfile(profile.ID).CreateOrder(ctx, coinbasepro.Order{
+		Side:      coinbasepro.SideBuy,
+		ProductID: "BTC-USD",
+		Price:     "100.00",
+		Size:      "1.00",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	detail, err := client.GetOrderDetail(ctx, order.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if detail.ProfileID != profile.ID {
+		t.Errorf("expected order in profile %s, got %s", profile.ID, detail.ProfileID)
+	}
+}
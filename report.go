@@ -2,14 +2,45 @@ package coinbasepro
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
 	"time"
 )
 
+// ErrReportNotReady is returned by DownloadReport when the report has no file yet.
+var ErrReportNotReady = errors.New("report is not ready")
+
+type (
+	ReportType   string
+	ReportFormat string
+	ReportStatus string
+)
+
+const (
+	ReportTypeFills   ReportType = "fills"
+	ReportTypeAccount ReportType = "account"
+
+	ReportFormatPDF ReportFormat = "pdf"
+	ReportFormatCSV ReportFormat = "csv"
+
+	ReportStatusPending  ReportStatus = "pending"
+	ReportStatusCreating ReportStatus = "creating"
+	ReportStatusReady    ReportStatus = "ready"
+)
+
 type ReportParams struct {
-	StartDate time.Time
-	EndDate   time.Time
+	StartDate time.Time    `json:"start_date"`
+	EndDate   time.Time    `json:"end_date"`
+	ProductID string       `json:"product_id"`
+	AccountID string       `json:"account_id"`
+	ProfileID string       `json:"profile_id"`
+	Format    ReportFormat `json:"format"`
+	Email     string       `json:"email"`
 }
 
 type CreateReportParams struct {
@@ -17,10 +48,26 @@ type CreateReportParams struct {
 	End   time.Time
 }
 
+// CreateReportRequest is the body of RequestReport.
+type CreateReportRequest struct {
+	Type      ReportType
+	StartDate time.Time
+	EndDate   time.Time
+	// ProductID is required for fills reports, use 'ALL' to get all products.
+	ProductID string
+	// AccountID is required for account reports.
+	AccountID string
+	// Format defaults to pdf.
+	Format ReportFormat
+	// Email receives the report once it is ready, when set.
+	Email     string
+	ProfileID string
+}
+
 type Report struct {
-	ID     string `json:"id"`
-	Type   string `json:"type"`
-	Status string `json:"status"`
+	ID     string       `json:"id"`
+	Type   ReportType   `json:"type"`
+	Status ReportStatus `json:"status"`
 	// ProductID is required for fills type reports.
 	// Use 'ALL' to get all products.
 	ProductID string `json:"product_id"`
@@ -31,10 +78,55 @@ type Report struct {
 	ExpiresAt   Time         `json:"expires_at,string"`
 	FileURL     string       `json:"file_url"`
 	Params      ReportParams `json:"params"`
-	StartDate   time.Time
-	EndDate     time.Time
+	StartDate   time.Time    `json:"start_date"`
+	EndDate     time.Time    `json:"end_date"`
+}
+
+// ListReportsParams filters ListReports, the zero value lists all reports. Clients scoped to a profile only list
+// the reports of the profile.
+type ListReportsParams struct {
+	Type      ReportType
+	ProfileID string
+	// After only lists reports created after the time.
+	After time.Time
+	// IgnoreExpired skips reports whose file URL has expired.
+	IgnoreExpired bool
+	// Limit is the number of reports per page, defaults to 100.
+	Limit int
+}
+
+// ReportCursor iterates over reports in the order they were created. The exchange pages reports by creation time,
+// every page starts at the creation time of the newest report seen so far and the reports which were already
+// returned are skipped. The order of the reports within a page is not relied on.
+type ReportCursor struct {
+	client *client
+	params ListReportsParams
+	// after is the creation time the next page starts at, seen holds the ids of the reports created at that time.
+	after   time.Time
+	seen    map[string]bool
+	HasMore bool
 }
 
+type (
+	createReportRequest struct {
+		Type      ReportType          `json:"type"`
+		Format    ReportFormat        `json:"format,omitempty"`
+		Email     string              `json:"email,omitempty"`
+		ProfileID string              `json:"profile_id,omitempty"`
+		Fills     *reportRequestRange `json:"fills,omitempty"`
+		Account   *reportRequestRange `json:"account,omitempty"`
+	}
+	reportRequestRange struct {
+		StartDate time.Time `json:"start_date"`
+		EndDate   time.Time `json:"end_date"`
+		ProductID string    `json:"product_id,omitempty"`
+		AccountID string    `json:"account_id,omitempty"`
+	}
+)
+
+// CreateReport requests a report.
+//
+// Deprecated: use RequestReport, which also supports the format, email and profile of the report.
 func (c *client) CreateReport(ctx context.Context, newReport Report) (Report, error) {
 	var savedReport Report
 
@@ -44,6 +136,33 @@ func (c *client) CreateReport(ctx context.Context, newReport Report) (Report, er
 	return savedReport, err
 }
 
+// RequestReport requests a fills or account report, use WaitForReport to wait until its file can be downloaded.
+func (c *client) RequestReport(ctx context.Context, r CreateReportRequest) (Report, error) {
+	body := createReportRequest{
+		Type:      r.Type,
+		Format:    r.Format,
+		Email:     r.Email,
+		ProfileID: c.profile(r.ProfileID),
+	}
+
+	dates := &reportRequestRange{StartDate: r.StartDate, EndDate: r.EndDate}
+	switch r.Type {
+	case ReportTypeFills:
+		dates.ProductID = r.ProductID
+		body.Fills = dates
+	case ReportTypeAccount:
+		dates.AccountID = r.AccountID
+		body.Account = dates
+	default:
+		return Report{}, fmt.Errorf("unsupported report type %q", r.Type)
+	}
+
+	var report Report
+	_, err := c.Request(ctx, http.MethodPost, "/reports", body, &report)
+
+	return report, err
+}
+
 func (c *client) GetReportStatus(ctx context.Context, id string) (Report, error) {
 	report := Report{}
 
@@ -52,3 +171,106 @@ func (c *client) GetReportStatus(ctx context.Context, id string) (Report, error)
 
 	return report, err
 }
+
+// ListReports lists the reports of a profile, including the reports generated by other users of the profile.
+func (c *client) ListReports(p ListReportsParams) *ReportCursor {
+	p.ProfileID = c.profile(p.ProfileID)
+	if p.Limit <= 0 {
+		p.Limit = 100
+	}
+
+	return &ReportCursor{client: c, params: p, after: p.After, HasMore: true}
+}
+
+// NextPage returns the next reports oldest first, HasMore is false once a page is not full. The last page can be
+// empty. A full page of reports which were already returned means that at least Limit reports share a creation time,
+// the cursor cannot advance past them and returns an error.
+func (c *ReportCursor) NextPage(ctx context.Context) ([]Report, error) {
+	query := url.Values{}
+	query.Set("limit", strconv.Itoa(c.params.Limit))
+	if c.params.Type != "" {
+		query.Set("type", string(c.params.Type))
+	}
+	if c.params.ProfileID != "" {
+		query.Set("portfolio_id", c.params.ProfileID)
+	}
+	// after is always set so every page starts at a creation time, also the first one without an After filter
+	after := c.after
+	if after.IsZero() {
+		after = time.Unix(0, 0)
+	}
+	query.Set("after", after.UTC().Format(time.RFC3339Nano))
+	if c.params.IgnoreExpired {
+		query.Set("ignore_expired", "true")
+	}
+
+	var reports []Report
+	if _, err := c.client.Request(ctx, http.MethodGet, "/reports?"+query.Encode(), nil, &reports); err != nil {
+		c.HasMore = false
+		return nil, err
+	}
+
+	page := make([]Report, 0, len(reports))
+	for _, r := range reports {
+		created := r.CreatedAt.Time()
+		if !created.After(c.params.After) || created.Before(c.after) || (created.Equal(c.after) && c.seen[r.ID]) {
+			continue
+		}
+		page = append(page, r)
+	}
+	sort.SliceStable(page, func(i, j int) bool {
+		return page[i].CreatedAt.Time().Before(page[j].CreatedAt.Time())
+	})
+
+	// the next page starts at the newest creation time of this page
+	if len(page) > 0 {
+		if newest := page[len(page)-1].CreatedAt.Time(); newest.After(c.after) {
+			c.after = newest
+			c.seen = make(map[string]bool)
+		}
+	}
+	for _, r := range page {
+		if r.CreatedAt.Time().Equal(c.after) {
+			c.seen[r.ID] = true
+		}
+	}
+
+	if len(reports) < c.params.Limit {
+		c.HasMore = false
+	} else if len(page) == 0 {
+		c.HasMore = false
+		return nil, fmt.Errorf("at least %d reports were created at %s, use a larger limit", c.params.Limit, c.after.UTC().Format(time.RFC3339Nano))
+	}
+
+	return page, nil
+}
+
+// DownloadReport writes the file of a ready report to dst and returns the number of bytes written. The file URL
+// is presigned, it is requested without credentials.
+func (c *client) DownloadReport(ctx context.Context, report Report, dst io.Writer) (int64, error) {
+	if report.FileURL == "" {
+		return 0, ErrReportNotReady
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, report.FileURL, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create new request: %w", err)
+	}
+
+	res, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("failed to download report: %s", res.Status)
+	}
+
+	n, err := io.Copy(dst, res.Body)
+	if err != nil {
+		return n, fmt.Errorf("failed to download report: %w", err)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package coinbasepro
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ParseFillsReport parses a fills report in csv format.
+func ParseFillsReport(r io.Reader) ([]Fill, error) {
+	var fills []Fill
+	err := readReport(r, []string{"trade id", "product", "side", "created at", "size", "price", "fee"}, func(row reportRow) error {
+		tradeID, err := strconv.Atoi(row.get("trade id"))
+		if err != nil {
+			return fmt.Errorf("invalid trade id: %w", err)
+		}
+
+		fill := Fill{
+			TradeID:   tradeID,
+			ProductID: row.get("product"),
+			Side:      strings.ToLower(row.get("side")),
+			Size:      row.get("size"),
+			Price:     row.get("price"),
+			Fee:       row.get("fee"),
+		}
+		if fill.CreatedAt, err = parseReportTime(row.get("created at")); err != nil {
+			return err
+		}
+
+		fills = append(fills, fill)
+
+		return nil
+	})
+
+	return fills, err
+}
+
+// ParseAccountReport parses an account report in csv format. The report has no entry ids, the transfer, trade and
+// order ids are set in the details.
+func ParseAccountReport(r io.Reader) ([]LedgerEntry, error) {
+	var entries []LedgerEntry
+	err := readReport(r, []string{"type", "time", "amount", "balance"}, func(row reportRow) error {
+		entry := LedgerEntry{
+			Type:    LedgerEntryType(strings.ToLower(row.get("type"))),
+			Amount:  Decimal(row.get("amount")),
+			Balance: Decimal(row.get("balance")),
+			Details: LedgerDetails{
+				TransferID: row.get("transfer id"),
+				TradeID:    row.get("trade id"),
+				OrderID:    row.get("order id"),
+			},
+		}
+
+		var err error
+		if entry.CreatedAt, err = parseReportTime(row.get("time")); err != nil {
+			return err
+		}
+
+		entries = append(entries, entry)
+
+		return nil
+	})
+
+	return entries, err
+}
+
+// reportRow is a record of a report, values are looked up by column name.
+type reportRow struct {
+	columns map[string]int
+	record  []string
+}
+
+func (r reportRow) get(column string) string {
+	i, ok := r.columns[column]
+	if !ok || i >= len(r.record) {
+		return ""
+	}
+
+	return strings.TrimSpace(r.record[i])
+}
+
+// readReport calls fn for every record of a csv report after checking that the header has the required columns.
+func readReport(r io.Reader, required []string, fn func(reportRow) error) error {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("report is empty")
+		}
+		return fmt.Errorf("failed to read report header: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	for _, name := range required {
+		if _, ok := columns[name]; !ok {
+			return fmt.Errorf("report has no %q column", name)
+		}
+	}
+
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read report: %w", err)
+		}
+
+		if err := fn(reportRow{columns: columns, record: record}); err != nil {
+			line, _ := reader.FieldPos(0)
+			return fmt.Errorf("invalid record on line %d: %w", line, err)
+		}
+	}
+}
+
+func parseReportTime(s string) (Time, error) {
+	var t Time
+	if err := t.UnmarshalJSON([]byte(strconv.Quote(s))); err != nil {
+		return t, fmt.Errorf("invalid time %q: %w", s, err)
+	}
+
+	return t, nil
+}
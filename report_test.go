@@ -1,7 +1,14 @@
 package coinbasepro_test
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -29,3 +36,240 @@ func TestCreateReportAndStatus(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestRequestReport(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := client.RequestReport(ctx, coinbasepro.CreateReportRequest{
+		Type:      coinbasepro.ReportTypeFills,
+		StartDate: time.Now().Add(-time.Hour),
+		EndDate:   time.Now().Add(time.Hour),
+		ProductID: "ALL",
+		Format:    coinbasepro.ReportFormatCSV,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.DownloadReport(ctx, report, io.Discard); !errors.Is(err, coinbasepro.ErrReportNotReady) {
+		t.Fatalf("expected ErrReportNotReady, got %v", err)
+	}
+
+	var statuses []coinbasepro.ReportStatus
+	report, err = client.WaitForReport(ctx, report.ID, coinbasepro.WithReportPollInterval(time.Millisecond), coinbasepro.WithReportStatusHandler(func(r coinbasepro.Report) {
+		statuses = append(statuses, r.Status)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.FileURL == "" || statuses[len(statuses)-1] != coinbasepro.ReportStatusReady {
+		t.Fatalf("unexpected report %+v, statuses %v", report, statuses)
+	}
+
+	var buf bytes.Buffer
+	if _, err := client.DownloadReport(ctx, report, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	fills, err := coinbasepro.ParseFillsReport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 || fills[0].ProductID != "BTC-USD" || fills[0].Side != "buy" || fills[0].Size != "0.01000000" {
+		t.Errorf("unexpected fills %+v", fills)
+	}
+}
+
+func TestRequestAccountReport(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	if _, err := client.PlaceOrder(ctx, coinbasepro.NewMarketOrderBySize("BTC-USD", coinbasepro.SideBuy, "0.01")); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var account coinbasepro.Account
+	for _, a := range accounts {
+		if a.Currency == "USD" {
+			account = a
+		}
+	}
+
+	report, err := client.RequestReport(ctx, coinbasepro.CreateReportRequest{
+		Type:      coinbasepro.ReportTypeAccount,
+		StartDate: time.Now().Add(-time.Hour),
+		EndDate:   time.Now().Add(time.Hour),
+		AccountID: account.ID,
+		Format:    coinbasepro.ReportFormatCSV,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = client.WaitForReport(ctx, report.ID, coinbasepro.WithReportPollInterval(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := client.DownloadReport(ctx, report, &buf); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := coinbasepro.ParseAccountReport(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) == 0 || entries[len(entries)-1].Balance.String() != account.Balance {
+		t.Errorf("expected the entries to end at the balance %s, got %+v", account.Balance, entries)
+	}
+}
+
+func TestParseFillsReport(t *testing.T) {
+	report := "\ufeffportfolio,trade id,product,side,created at,size,size unit,price,fee,total,price/fee/total unit\n" +
+		"default,74,BTC-USD,SELL,2021-03-02T10:11:12.345Z,0.5,BTC,30000.00,90.00,14910.00,USD\n"
+
+	fills, err := coinbasepro.ParseFillsReport(strings.NewReader(report))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fills) != 1 {
+		t.Fatalf("expected one fill, got %+v", fills)
+	}
+
+	fill := fills[0]
+	if fill.TradeID != 74 || fill.Side != "sell" || fill.Price != "30000.00" || fill.Fee != "90.00" {
+		t.Errorf("unexpected fill %+v", fill)
+	}
+	if created := fill.CreatedAt.Time(); !created.Equal(time.Date(2021, 3, 2, 10, 11, 12, 345000000, time.UTC)) {
+		t.Errorf("unexpected created at %s", created)
+	}
+
+	if _, err := coinbasepro.ParseFillsReport(strings.NewReader("portfolio,type,time,amount\n")); err == nil {
+		t.Error("expected an error for an account report")
+	}
+}
+
+func TestListReports(t *testing.T) {
+	client := coinbasepro.NewTestClient(t)
+	ctx := context.Background()
+
+	accounts, err := client.GetAccounts(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	requests := []coinbasepro.CreateReportRequest{
+		{Type: coinbasepro.ReportTypeFills, ProductID: "ALL"},
+		{Type: coinbasepro.ReportTypeFills, ProductID: "BTC-USD"},
+		{Type: coinbasepro.ReportTypeAccount, AccountID: accounts[0].ID},
+	}
+
+	ids := make(map[string]bool)
+	for _, r := range requests {
+		r.StartDate, r.EndDate = start.Add(-24*time.Hour), start
+		report, err := client.RequestReport(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids[report.ID] = true
+	}
+
+	cursor := client.ListReports(coinbasepro.ListReportsParams{After: start.Add(-time.Minute), Limit: 2})
+	for cursor.HasMore {
+		reports, err := cursor.NextPage(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, r := range reports {
+			if !ids[r.ID] {
+				t.Errorf("unexpected or duplicate report %s", r.ID)
+			}
+			delete(ids, r.ID)
+		}
+	}
+	if len(ids) != 0 {
+		t.Errorf("expected all reports, missing %v", ids)
+	}
+
+	reports, err := client.ListReports(coinbasepro.ListReportsParams{Type: coinbasepro.ReportTypeAccount, After: start.Add(-time.Minute), IgnoreExpired: true}).NextPage(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Type != coinbasepro.ReportTypeAccount || reports[0].Params.AccountID != accounts[0].ID {
+		t.Errorf("expected the account report, got %+v", reports)
+	}
+}
+
+func TestListReportsSameCreationTime(t *testing.T) {
+	created := []string{
+		"2021-01-01T00:00:00.000000Z",
+		"2021-01-01T00:00:01.000000Z",
+		"2021-01-01T00:00:02.000000Z",
+		"2021-01-01T00:00:02.000000Z",
+		"2021-01-01T00:00:03.000000Z",
+	}
+
+	// the handler lists the reports created at or after the after parameter which are closest to it, newest first
+	handler := http.NewServeMux()
+	handler.HandleFunc("/reports", func(w http.ResponseWriter, r *http.Request) {
+		after, _ := time.Parse(time.RFC3339Nano, r.URL.Query().Get("after"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var page []string
+		for i, c := range created {
+			if t, _ := time.Parse(time.RFC3339Nano, c); !t.Before(after) && len(page) < limit {
+				page = append([]string{fmt.Sprintf(`{"id":"report-%d","created_at":%q}`, i, c)}, page...)
+			}
+		}
+		w.Write([]byte("[" + strings.Join(page, ",") + "]"))
+	})
+
+	client := coinbasepro.NewTestServerClient(t, handler)
+
+	var (
+		ids  = make(map[string]bool)
+		last time.Time
+	)
+	cursor := client.ListReports(coinbasepro.ListReportsParams{Limit: 3})
+	for cursor.HasMore {
+		reports, err := cursor.NextPage(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range reports {
+			if ids[r.ID] {
+				t.Errorf("duplicate report %s", r.ID)
+			}
+			if r.CreatedAt.Time().Before(last) {
+				t.Errorf("expected reports in the order they were created, got %s after %s", r.CreatedAt.Time(), last)
+			}
+			ids[r.ID] = true
+			last = r.CreatedAt.Time()
+		}
+	}
+	if len(ids) != len(created) {
+		t.Errorf("expected %d reports, got %v", len(created), ids)
+	}
+
+	// with a limit of two the reports created at the same time fill a page which cannot be paged past
+	var err error
+	cursor = client.ListReports(coinbasepro.ListReportsParams{Limit: 2})
+	for cursor.HasMore && err == nil {
+		_, err = cursor.NextPage(context.Background())
+	}
+	if err == nil {
+		t.Error("expected an error for reports sharing a creation time")
+	}
+}
@@ -0,0 +1,87 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+type (
+	reportWaiter struct {
+		interval    time.Duration
+		maxInterval time.Duration
+		onStatus    func(Report)
+	}
+	ReportWaitOption func(*reportWaiter) error
+)
+
+// WithReportPollInterval sets the delay before the second poll, it doubles after every poll. Defaults to one second.
+func WithReportPollInterval(interval time.Duration) ReportWaitOption {
+	return func(w *reportWaiter) error {
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		w.interval = interval
+
+		return nil
+	}
+}
+
+// WithReportMaxPollInterval caps the delay between polls, defaults to one minute.
+func WithReportMaxPollInterval(maxInterval time.Duration) ReportWaitOption {
+	return func(w *reportWaiter) error {
+		if maxInterval <= 0 {
+			return errors.New("maxInterval must be positive")
+		}
+		w.maxInterval = maxInterval
+
+		return nil
+	}
+}
+
+// WithReportStatusHandler registers a handler which is called with the report whenever its status changes,
+// including the status of the first poll.
+func WithReportStatusHandler(handler func(Report)) ReportWaitOption {
+	return func(w *reportWaiter) error {
+		if handler == nil {
+			return errors.New("handler cannot be nil")
+		}
+		w.onStatus = handler
+
+		return nil
+	}
+}
+
+// WaitForReport polls the report with exponential backoff until it is ready. It returns the last polled report
+// when ctx is done.
+func (c *client) WaitForReport(ctx context.Context, id string, opts ...ReportWaitOption) (Report, error) {
+	w := &reportWaiter{
+		interval:    time.Second,
+		maxInterval: time.Minute,
+		onStatus:    func(Report) {},
+	}
+
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return Report{}, err
+		}
+	}
+
+	var report Report
+	err := pollWithBackoff(ctx, w.interval, w.maxInterval, func() (bool, error) {
+		polled, err := c.GetReportStatus(ctx, id)
+		if err != nil {
+			return false, err
+		}
+
+		changed := polled.Status != report.Status
+		report = polled
+		if changed {
+			w.onStatus(report)
+		}
+
+		return report.Status == ReportStatusReady, nil
+	})
+
+	return report, err
+}
@@ -4,14 +4,86 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
+)
+
+type (
+	TransferType   string
+	TransferStatus string
+)
+
+const (
+	TransferTypeDeposit          TransferType = "deposit"
+	TransferTypeWithdraw         TransferType = "withdraw"
+	TransferTypeInternalDeposit  TransferType = "internal_deposit"
+	TransferTypeInternalWithdraw TransferType = "internal_withdraw"
+
+	TransferStatusPending    TransferStatus = "pending"
+	TransferStatusProcessing TransferStatus = "processing"
+	TransferStatusCompleted  TransferStatus = "completed"
+	TransferStatusCanceled   TransferStatus = "canceled"
 )
 
 type Transfer struct {
-	Type              string `json:"type"`
-	Amount            string `json:"amount"`
+	Type   string `json:"type"`
+	Amount string `json:"amount"`
+	// CoinbaseAccountID can be determined by calling GetCoinbaseAccounts
 	CoinbaseAccountID string `json:"coinbase_account_id,string"`
 }
 
+// TransferDetail is a deposit or withdrawal of a profile, including transfers between profiles.
+type TransferDetail struct {
+	ID          string          `json:"id"`
+	Type        TransferType    `json:"type"`
+	CreatedAt   Time            `json:"created_at,string"`
+	ProcessedAt Time            `json:"processed_at,string"`
+	CanceledAt  Time            `json:"canceled_at,string"`
+	CompletedAt Time            `json:"completed_at,string"`
+	AccountID   string          `json:"account_id"`
+	UserID      string          `json:"user_id"`
+	ProfileID   string          `json:"profile_id"`
+	Amount      string          `json:"amount"`
+	Currency    string          `json:"currency"`
+	Details     TransferDetails `json:"details"`
+	// IdempotencyKey is the nonce the transfer was created with.
+	IdempotencyKey string `json:"idempotency_key"`
+}
+
+// TransferDetails describes the source or destination of a transfer, fields which do not apply to its type are
+// empty.
+type TransferDetails struct {
+	CoinbaseAccountID       string `json:"coinbase_account_id"`
+	CoinbaseTransactionID   string `json:"coinbase_transaction_id"`
+	CoinbasePaymentMethodID string `json:"coinbase_payment_method_id"`
+	// CryptoAddress is the destination of crypto withdrawals.
+	CryptoAddress         string `json:"crypto_address"`
+	DestinationTag        string `json:"destination_tag"`
+	CryptoTransactionHash string `json:"crypto_transaction_hash"`
+	Network               string `json:"network"`
+	Fee                   string `json:"fee"`
+	Subtotal              string `json:"subtotal"`
+}
+
+type ListTransfersParams struct {
+	Type       TransferType
+	ProfileID  string
+	Pagination PaginationParams
+}
+
+// Status derives the status of the transfer from its timestamps.
+func (t TransferDetail) Status() TransferStatus {
+	switch {
+	case !t.CanceledAt.Time().IsZero():
+		return TransferStatusCanceled
+	case !t.CompletedAt.Time().IsZero():
+		return TransferStatusCompleted
+	case !t.ProcessedAt.Time().IsZero():
+		return TransferStatusProcessing
+	default:
+		return TransferStatusPending
+	}
+}
+
 func (c *client) CreateTransfer(ctx context.Context, newTransfer Transfer) (Transfer, error) {
 	var savedTransfer Transfer
 
@@ -19,3 +91,30 @@ func (c *client) CreateTransfer(ctx context.Context, newTransfer Transfer) (Tran
 	_, err := c.Request(ctx, http.MethodPost, url, newTransfer, &savedTransfer)
 	return savedTransfer, err
 }
+
+// ListTransfers lists the deposits and withdrawals of all profiles, newest first
+func (c *client) ListTransfers(p ListTransfersParams) *Cursor {
+	paginationParams := p.Pagination
+	if p.Type != "" {
+		paginationParams.AddExtraParam("type", string(p.Type))
+	}
+	if profileID := c.profile(p.ProfileID); profileID != "" {
+		paginationParams.AddExtraParam("profile_id", profileID)
+	}
+
+	return c.newCursor(http.MethodGet, fmt.Sprintf("/transfers"), paginationParams)
+}
+
+// GetTransfer retrieves a single deposit or withdrawal
+func (c *client) GetTransfer(ctx context.Context, id string) (TransferDetail, error) {
+	transfer, _, err := c.getTransfer(ctx, id)
+	return transfer, err
+}
+
+func (c *client) getTransfer(ctx context.Context, id string) (TransferDetail, *http.Response, error) {
+	var transfer TransferDetail
+
+	requestURL := fmt.Sprintf("/transfers/%s", url.PathEscape(id))
+	res, err := c.Request(ctx, http.MethodGet, requestURL, nil, &transfer)
+	return transfer, res, err
+}
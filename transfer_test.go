@@ -0,0 +1,108 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/coinbaseprotest"
+)
+
+func TestListTransfers(t *testing.T) {
+	var transfers []coinbasepro.TransferDetail
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	cursor := client.ListTransfers(coinbasepro.ListTransfersParams{Type: coinbasepro.TransferTypeDeposit})
+	for cursor.HasMore {
+		if err := cursor.NextPage(ctx, &transfers); err != nil {
+			t.Fatal(err)
+		}
+
+		for _, transfer := range transfers {
+			if transfer.Type != coinbasepro.TransferTypeDeposit {
+				t.Errorf("expected deposit, got %s", transfer.Type)
+			}
+			if transfer.ID == "" || transfer.Amount == "" || transfer.CreatedAt.Time().IsZero() {
+				t.Errorf("unexpected transfer %+v", transfer)
+			}
+		}
+	}
+}
+
+func TestGetTransfer(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	withdrawal, err := client.CreateWithdrawalCrypto(ctx, coinbasepro.WithdrawalCrypto{
+		Currency:      "BTC",
+		Amount:        "0.01",
+		CryptoAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	transfer, err := client.GetTransfer(ctx, withdrawal.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transfer.Type != coinbasepro.TransferTypeWithdraw || transfer.Details.CryptoAddress != withdrawal.CryptoAddress || transfer.Details.Network != withdrawal.Network {
+		t.Errorf("unexpected transfer %+v", transfer)
+	}
+}
+
+func TestWaitForTransfer(t *testing.T) {
+	client, server := coinbasepro.NewFakeClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	withdrawal, err := client.CreateWithdrawalCrypto(ctx, coinbasepro.WithdrawalCrypto{
+		Currency:      "BTC",
+		Amount:        "0.01",
+		CryptoAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if withdrawal.ID == "" || withdrawal.Fee == "" || withdrawal.Subtotal == "" {
+		t.Errorf("unexpected withdrawal %+v", withdrawal)
+	}
+
+	// A server error does not end the wait.
+	server.Script(http.MethodGet, "/transfers/"+withdrawal.ID, coinbaseprotest.Response{Status: http.StatusServiceUnavailable, Body: `{"message":"service unavailable"}`})
+
+	var statuses []coinbasepro.TransferStatus
+	transfer, err := client.WaitForTransfer(ctx, withdrawal.ID,
+		coinbasepro.WithPollInterval(10*time.Millisecond),
+		coinbasepro.WithMaxPollInterval(time.Second),
+		coinbasepro.WithTransferStatusHandler(func(transfer coinbasepro.TransferDetail) {
+			statuses = append(statuses, transfer.Status())
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if transfer.Status() != coinbasepro.TransferStatusCompleted || transfer.Details.CryptoTransactionHash == "" {
+		t.Errorf("unexpected transfer %+v", transfer)
+	}
+	if len(statuses) == 0 || statuses[len(statuses)-1] != coinbasepro.TransferStatusCompleted {
+		t.Errorf("unexpected statuses %v", statuses)
+	}
+}
+
+func TestWaitForUnknownTransfer(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	var coinbaseErr coinbasepro.Error
+	if _, err := client.WaitForTransfer(ctx, "unknown", coinbasepro.WithPollInterval(10*time.Millisecond)); !errors.As(err, &coinbaseErr) {
+		t.Errorf("expected the error of the exchange, got %v", err)
+	}
+}
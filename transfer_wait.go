@@ -0,0 +1,106 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrTransferCanceled is returned by WaitForTransfer when the transfer was canceled.
+var ErrTransferCanceled = errors.New("transfer was canceled")
+
+type (
+	transferWaiter struct {
+		interval    time.Duration
+		maxInterval time.Duration
+		onStatus    func(TransferDetail)
+	}
+	TransferWaitOption func(*transferWaiter) error
+)
+
+// WithPollInterval sets the delay before the second poll, it doubles after every poll. Defaults to one second.
+func WithPollInterval(interval time.Duration) TransferWaitOption {
+	return func(w *transferWaiter) error {
+		if interval <= 0 {
+			return errors.New("interval must be positive")
+		}
+		w.interval = interval
+
+		return nil
+	}
+}
+
+// WithMaxPollInterval caps the delay between polls, defaults to one minute.
+func WithMaxPollInterval(maxInterval time.Duration) TransferWaitOption {
+	return func(w *transferWaiter) error {
+		if maxInterval <= 0 {
+			return errors.New("maxInterval must be positive")
+		}
+		w.maxInterval = maxInterval
+
+		return nil
+	}
+}
+
+// WithTransferStatusHandler registers a handler which is called with the transfer whenever its status changes,
+// including the status of the first poll.
+func WithTransferStatusHandler(handler func(TransferDetail)) TransferWaitOption {
+	return func(w *transferWaiter) error {
+		if handler == nil {
+			return errors.New("handler cannot be nil")
+		}
+		w.onStatus = handler
+
+		return nil
+	}
+}
+
+// WaitForTransfer polls the transfer with exponential backoff until it is completed or canceled. It returns
+// ErrTransferCanceled along with the transfer when it was canceled, and the last polled transfer when ctx is done.
+// Polls which fail are retried unless the exchange rejected the request, for example because the transfer does not
+// exist.
+func (c *client) WaitForTransfer(ctx context.Context, id string, opts ...TransferWaitOption) (TransferDetail, error) {
+	w := &transferWaiter{
+		interval:    time.Second,
+		maxInterval: time.Minute,
+		onStatus:    func(TransferDetail) {},
+	}
+
+	for _, opt := range opts {
+		if err := opt(w); err != nil {
+			return TransferDetail{}, err
+		}
+	}
+
+	var (
+		transfer TransferDetail
+		status   TransferStatus
+	)
+	err := pollWithBackoff(ctx, w.interval, w.maxInterval, func() (bool, error) {
+		polled, res, err := c.getTransfer(ctx, id)
+		if requestRejected(res, err) {
+			return false, err
+		}
+		if err != nil {
+			// timeouts and server errors are transient, the transfer is polled again after the interval
+			return false, nil
+		}
+		transfer = polled
+
+		if transfer.Status() != status {
+			status = transfer.Status()
+			w.onStatus(transfer)
+		}
+
+		switch status {
+		case TransferStatusCompleted:
+			return true, nil
+		case TransferStatusCanceled:
+			return true, ErrTransferCanceled
+		}
+
+		return false, nil
+	})
+
+	return transfer, err
+}
@@ -0,0 +1,29 @@
+package coinbasepro
+
+import (
+	"context"
+	"time"
+)
+
+// pollWithBackoff calls poll until it is done or returns an error, the delay between calls starts at interval and
+// doubles up to maxInterval.
+func pollWithBackoff(ctx context.Context, interval, maxInterval time.Duration, poll func() (bool, error)) error {
+	for {
+		done, err := poll()
+		if err != nil || done {
+			return err
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+}
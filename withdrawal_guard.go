@@ -0,0 +1,323 @@
+package coinbasepro
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro/internal/decimal"
+)
+
+// ErrWithdrawalRejected matches every WithdrawalRejectedError when used with errors.Is.
+var ErrWithdrawalRejected = errors.New("withdrawal rejected")
+
+// WithdrawalRejectedError is returned by CreateWithdrawalCrypto when the withdrawal guard of the client rejected the
+// withdrawal, it was not sent to the exchange.
+type WithdrawalRejectedError struct {
+	Reason string
+}
+
+func (e WithdrawalRejectedError) Error() string {
+	return fmt.Sprintf("withdrawal rejected: %s", e.Reason)
+}
+
+func (e WithdrawalRejectedError) Is(target error) bool {
+	return target == ErrWithdrawalRejected
+}
+
+// AllowedAddress is a destination withdrawals of Currency may be sent to. Network selects the address format when
+// it differs from the main network of the currency, for example for tokens. An empty network is the main network of
+// the currency and only allows withdrawals on that network.
+type AllowedAddress struct {
+	Currency       string
+	Network        string
+	Address        string
+	DestinationTag string
+}
+
+// WithdrawalAttempt is recorded by the audit sink for every withdrawal, whether it was allowed or rejected.
+type WithdrawalAttempt struct {
+	Time time.Time
+	// Withdrawal is the request without TwoFactorCode.
+	Withdrawal WithdrawalCrypto
+	// Detail is the response of the exchange when the withdrawal was sent.
+	Detail  WithdrawalCryptoDetail
+	Allowed bool
+	// Err is the reason a withdrawal was rejected, or the error of the request of an allowed withdrawal.
+	Err error
+}
+
+// WithdrawalAuditSink records withdrawal attempts, it must not block.
+type WithdrawalAuditSink interface {
+	RecordWithdrawal(WithdrawalAttempt)
+}
+
+// WithdrawalAuditFunc adapts a function to a WithdrawalAuditSink.
+type WithdrawalAuditFunc func(WithdrawalAttempt)
+
+func (f WithdrawalAuditFunc) RecordWithdrawal(attempt WithdrawalAttempt) {
+	f(attempt)
+}
+
+type loggerAuditSink struct {
+	logger *log.Logger
+}
+
+func (s loggerAuditSink) RecordWithdrawal(attempt WithdrawalAttempt) {
+	w := attempt.Withdrawal
+	if attempt.Err != nil {
+		s.logger.Printf("withdrawal of %s %s to %s allowed=%t: %v", w.Amount, w.Currency, w.CryptoAddress, attempt.Allowed, attempt.Err)
+		return
+	}
+
+	s.logger.Printf("withdrawal %s of %s %s to %s sent", attempt.Detail.ID, w.Amount, w.Currency, w.CryptoAddress)
+}
+
+type rollingLimit struct {
+	amount *big.Rat
+	window time.Duration
+}
+
+type sentWithdrawal struct {
+	currency string
+	amount   *big.Rat
+	at       time.Time
+}
+
+// WithdrawalGuard checks crypto withdrawals of a client before they are sent, see WithWithdrawalGuard. Withdrawals
+// must go to an allowed address of their currency, stay within the transaction and rolling limits of their
+// currency and be approved by the approver. Currencies without allowed addresses cannot be withdrawn.
+type WithdrawalGuard struct {
+	allowed          map[string][]AllowedAddress
+	transactionLimit map[string]*big.Rat
+	rollingLimit     map[string]rollingLimit
+	approver         func(context.Context, WithdrawalCrypto) error
+	audit            WithdrawalAuditSink
+	clock            clock
+
+	mu   sync.Mutex
+	sent []*sentWithdrawal
+}
+
+type WithdrawalGuardOption func(*WithdrawalGuard) error
+
+// WithAllowedAddresses adds destinations to the allowlist, every address is validated with ValidateAddress.
+func WithAllowedAddresses(addresses ...AllowedAddress) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		for _, a := range addresses {
+			if a.Currency == "" {
+				return errors.New("currency cannot be empty")
+			}
+
+			normalized, err := normalizeAddress(a.Currency, a.Network, a.Address)
+			if err != nil {
+				return err
+			}
+			a.Currency = strings.ToUpper(a.Currency)
+			a.Network = addressNetwork(a.Currency, a.Network)
+			a.Address = normalized
+
+			g.allowed[a.Currency] = append(g.allowed[a.Currency], a)
+		}
+
+		return nil
+	}
+}
+
+// WithTransactionLimit sets the maximum amount of a single withdrawal of currency.
+func WithTransactionLimit(currency, amount string) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		limit, ok := decimal.Parse(amount)
+		if !ok || limit.Sign() < 0 {
+			return fmt.Errorf("invalid limit %s", amount)
+		}
+		g.transactionLimit[strings.ToUpper(currency)] = limit
+
+		return nil
+	}
+}
+
+// WithRollingLimit sets the maximum amount of currency which can be withdrawn within any window. Withdrawals the
+// exchange rejected do not count, withdrawals which failed otherwise, for example on a timeout, may have been sent
+// and count.
+func WithRollingLimit(currency, amount string, window time.Duration) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		limit, ok := decimal.Parse(amount)
+		if !ok || limit.Sign() < 0 {
+			return fmt.Errorf("invalid limit %s", amount)
+		}
+		if window <= 0 {
+			return errors.New("window must be positive")
+		}
+		g.rollingLimit[strings.ToUpper(currency)] = rollingLimit{amount: limit, window: window}
+
+		return nil
+	}
+}
+
+// WithWithdrawalApprover sets a function which approves withdrawals after they passed the allowlist and the
+// limits, for example by asking a human or a second service. Withdrawals are rejected when it returns an error.
+func WithWithdrawalApprover(approver func(context.Context, WithdrawalCrypto) error) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		if approver == nil {
+			return errors.New("approver cannot be nil")
+		}
+		g.approver = approver
+
+		return nil
+	}
+}
+
+// WithWithdrawalAuditSink sets where withdrawal attempts are recorded, defaults to the standard logger.
+func WithWithdrawalAuditSink(sink WithdrawalAuditSink) WithdrawalGuardOption {
+	return func(g *WithdrawalGuard) error {
+		if sink == nil {
+			return errors.New("sink cannot be nil")
+		}
+		g.audit = sink
+
+		return nil
+	}
+}
+
+// NewWithdrawalGuard creates a guard, without allowed addresses it rejects every withdrawal.
+func NewWithdrawalGuard(opts ...WithdrawalGuardOption) (*WithdrawalGuard, error) {
+	g := &WithdrawalGuard{
+		allowed:          make(map[string][]AllowedAddress),
+		transactionLimit: make(map[string]*big.Rat),
+		rollingLimit:     make(map[string]rollingLimit),
+		approver:         func(context.Context, WithdrawalCrypto) error { return nil },
+		audit:            loggerAuditSink{logger: log.Default()},
+		clock:            systemClock{},
+	}
+
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, err
+		}
+	}
+
+	return g, nil
+}
+
+// withdraw checks w and sends it with send when it is allowed. The reserved amount is only released when the exchange
+// rejected the withdrawal.
+func (g *WithdrawalGuard) withdraw(ctx context.Context, w WithdrawalCrypto, send func(context.Context, WithdrawalCrypto) (WithdrawalCryptoDetail, *http.Response, error)) (WithdrawalCryptoDetail, error) {
+	audited := w
+	audited.TwoFactorCode = ""
+
+	reserved, err := g.check(ctx, w)
+	if err != nil {
+		g.audit.RecordWithdrawal(WithdrawalAttempt{Time: g.clock.Now(), Withdrawal: audited, Err: err})
+		return WithdrawalCryptoDetail{}, err
+	}
+
+	saved, res, err := send(ctx, w)
+	if requestRejected(res, err) {
+		g.release(reserved)
+	}
+	g.audit.RecordWithdrawal(WithdrawalAttempt{Time: g.clock.Now(), Withdrawal: audited, Detail: saved, Allowed: true, Err: err})
+
+	return saved, err
+}
+
+// check rejects w unless it passes the allowlist, the limits and the approver. The amount of an allowed withdrawal
+// is reserved against the rolling limit until it is released.
+func (g *WithdrawalGuard) check(ctx context.Context, w WithdrawalCrypto) (*sentWithdrawal, error) {
+	currency := strings.ToUpper(w.Currency)
+
+	amount, ok := decimal.Parse(w.Amount)
+	if !ok || amount.Sign() <= 0 {
+		return nil, WithdrawalRejectedError{Reason: fmt.Sprintf("invalid amount %q", w.Amount)}
+	}
+
+	address, err := normalizeAddress(currency, w.Network, w.CryptoAddress)
+	if err != nil {
+		return nil, WithdrawalRejectedError{Reason: err.Error()}
+	}
+	if !g.isAllowed(currency, w, address) {
+		return nil, WithdrawalRejectedError{Reason: fmt.Sprintf("%s address %s is not allowed", currency, w.CryptoAddress)}
+	}
+
+	if limit, ok := g.transactionLimit[currency]; ok && amount.Cmp(limit) > 0 {
+		return nil, WithdrawalRejectedError{Reason: fmt.Sprintf("amount %s exceeds the transaction limit of %s %s", w.Amount, decimal.Trim(limit, 8), currency)}
+	}
+
+	reserved, err := g.reserve(currency, amount)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := g.approver(ctx, w); err != nil {
+		g.release(reserved)
+		return nil, WithdrawalRejectedError{Reason: fmt.Sprintf("not approved: %v", err)}
+	}
+
+	return reserved, nil
+}
+
+func (g *WithdrawalGuard) isAllowed(currency string, w WithdrawalCrypto, address string) bool {
+	for _, a := range g.allowed[currency] {
+		if a.Address != address || a.DestinationTag != w.DestinationTag {
+			continue
+		}
+		if !strings.EqualFold(a.Network, addressNetwork(currency, w.Network)) {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// reserve counts amount against the rolling limit of currency, it fails when the limit would be exceeded.
+func (g *WithdrawalGuard) reserve(currency string, amount *big.Rat) (*sentWithdrawal, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	now := g.clock.Now()
+	if limit, ok := g.rollingLimit[currency]; ok {
+		total := new(big.Rat).Set(amount)
+		for _, s := range g.sent {
+			if s.currency == currency && now.Sub(s.at) < limit.window {
+				total.Add(total, s.amount)
+			}
+		}
+
+		if total.Cmp(limit.amount) > 0 {
+			return nil, WithdrawalRejectedError{Reason: fmt.Sprintf("amount %s exceeds the limit of %s %s per %s", decimal.Trim(amount, 8), decimal.Trim(limit.amount, 8), currency, limit.window)}
+		}
+	}
+
+	// forget withdrawals which are outside of every window
+	kept := g.sent[:0]
+	for _, s := range g.sent {
+		if limit, ok := g.rollingLimit[s.currency]; ok && now.Sub(s.at) < limit.window {
+			kept = append(kept, s)
+		}
+	}
+
+	reserved := &sentWithdrawal{currency: currency, amount: amount, at: now}
+	g.sent = append(kept, reserved)
+
+	return reserved, nil
+}
+
+func (g *WithdrawalGuard) release(reserved *sentWithdrawal) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for i, s := range g.sent {
+		if s == reserved {
+			g.sent = append(g.sent[:i], g.sent[i+1:]...)
+			return
+		}
+	}
+}
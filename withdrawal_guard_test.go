@@ -0,0 +1,218 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/moonr-app/go-coinbasepro"
+	"github.com/moonr-app/go-coinbasepro/coinbaseprotest"
+)
+
+func TestValidateAddress(t *testing.T) {
+	for _, tc := range []struct {
+		currency, network, address string
+		valid                      bool
+	}{
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2", true},
+		{"BTC", "", "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN3", false},
+		{"BTC", "", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", true},
+		{"BTC", "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", true},
+		{"BTC", "", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr", false},
+		{"BTC", "", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", true},
+		{"BTC", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", false},
+		{"ETH", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", true},
+		{"ETH", "", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359", true},
+		{"ETH", "", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", false},
+		{"ETH", "", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", true},
+		{"USDC", "ethereum", "0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB", true},
+		{"LTC", "", "LVg2kJoFNg45Nbpy53h7Fe1wKyeXVRhMH9", true},
+		{"XRP", "", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLh", true},
+		{"XRP", "", "rEb8TK3gBgk5auZkwc6sHnwrGVJH8DuaLa", false},
+		{"DOGE", "", "not checked", false},
+		{"DOGE", "", "DH5yaieqoZN36fDVciNyRueRGvGLR3mr7L", true},
+	} {
+		err := coinbasepro.ValidateAddress(tc.currency, tc.network, tc.address)
+		if tc.valid && err != nil {
+			t.Errorf("expected %s address %s to be valid, got %v", tc.currency, tc.address, err)
+		}
+		if !tc.valid && err == nil {
+			t.Errorf("expected %s address %s to be invalid", tc.currency, tc.address)
+		}
+	}
+}
+
+func TestWithdrawalGuard(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+	if _, err := coinbasepro.NewWithdrawalGuard(coinbasepro.WithAllowedAddresses(coinbasepro.AllowedAddress{Currency: "BTC", Address: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr"})); err == nil {
+		t.Error("expected error for allowed address with invalid checksum")
+	}
+
+	var (
+		attempts []coinbasepro.WithdrawalAttempt
+		approve  error
+	)
+	clock := &manualClock{now: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)}
+	guard, err := coinbasepro.NewWithdrawalGuard(
+		coinbasepro.WithAllowedAddresses(
+			coinbasepro.AllowedAddress{Currency: "BTC", Address: address},
+			coinbasepro.AllowedAddress{Currency: "XRP", Address: "rLW9gnQo7BQhU6igk5keqYnH3TVrCxGRzm", DestinationTag: "123"},
+		),
+		coinbasepro.WithTransactionLimit("BTC", "1"),
+		coinbasepro.WithRollingLimit("BTC", "1.5", time.Hour),
+		coinbasepro.WithWithdrawalApprover(func(context.Context, coinbasepro.WithdrawalCrypto) error { return approve }),
+		coinbasepro.WithWithdrawalAuditSink(coinbasepro.WithdrawalAuditFunc(func(attempt coinbasepro.WithdrawalAttempt) {
+			attempts = append(attempts, attempt)
+		})),
+		coinbasepro.WithWithdrawalGuardClock(clock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := coinbasepro.NewFakeClient(t, coinbasepro.WithWithdrawalGuard(guard))
+	ctx := context.Background()
+
+	withdraw := func(currency, amount, address, tag string) error {
+		_, err := client.CreateWithdrawalCrypto(ctx, coinbasepro.WithdrawalCrypto{
+			Currency:       currency,
+			Amount:         amount,
+			CryptoAddress:  address,
+			DestinationTag: tag,
+			TwoFactorCode:  "123456",
+		})
+		return err
+	}
+
+	for _, tc := range []struct {
+		name                           string
+		currency, amount, address, tag string
+		rejected                       bool
+	}{
+		{"allowed", "BTC", "1", address, "", false},
+		{"not allowed", "BTC", "0.1", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", "", true},
+		{"invalid", "BTC", "0.1", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr", "", true},
+		{"no allowed addresses", "ETH", "0.1", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "", true},
+		{"transaction limit", "BTC", "1.1", address, "", true},
+		{"rolling limit", "BTC", "0.6", address, "", true},
+		{"within rolling limit", "BTC", "0.5", address, "", false},
+		{"other tag", "XRP", "10", "rLW9gnQo7BQhU6igk5keqYnH3TVrCxGRzm", "456", true},
+		{"tag", "XRP", "10", "rLW9gnQo7BQhU6igk5keqYnH3TVrCxGRzm", "123", false},
+	} {
+		err := withdraw(tc.currency, tc.amount, tc.address, tc.tag)
+		if tc.rejected != errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+			t.Errorf("%s: expected rejected %t, got %v", tc.name, tc.rejected, err)
+		}
+		if !tc.rejected && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+
+	clock.Advance(time.Hour)
+	approve = errors.New("declined")
+	if err := withdraw("BTC", "1", address, ""); !errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+		t.Errorf("expected declined withdrawal to be rejected, got %v", err)
+	}
+
+	approve = nil
+	if err := withdraw("BTC", "1", address, ""); err != nil {
+		t.Errorf("expected rolling limit to be reset after the window, got %v", err)
+	}
+
+	if len(attempts) != 11 {
+		t.Fatalf("expected 11 audited attempts, got %d", len(attempts))
+	}
+	for _, attempt := range attempts {
+		if attempt.Withdrawal.TwoFactorCode != "" {
+			t.Error("expected two factor code to be removed from the audit")
+		}
+		if attempt.Allowed && attempt.Err == nil && attempt.Detail.ID == "" {
+			t.Errorf("expected response of sent withdrawal in the audit, got %+v", attempt.Detail)
+		}
+	}
+}
+
+func TestWithdrawalGuardFailedWithdrawals(t *testing.T) {
+	const address = "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"
+
+	guard, err := coinbasepro.NewWithdrawalGuard(
+		coinbasepro.WithAllowedAddresses(coinbasepro.AllowedAddress{Currency: "BTC", Address: address}),
+		coinbasepro.WithRollingLimit("BTC", "1", time.Hour),
+		coinbasepro.WithWithdrawalAuditSink(coinbasepro.WithdrawalAuditFunc(func(coinbasepro.WithdrawalAttempt) {})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, server := coinbasepro.NewFakeClient(t, coinbasepro.WithWithdrawalGuard(guard))
+	server.Script(http.MethodPost, "/withdrawals/crypto",
+		coinbaseprotest.Response{Status: http.StatusBadRequest, Body: `{"message":"invalid address"}`},
+		coinbaseprotest.Response{Status: http.StatusInternalServerError, Body: `{"message":"internal server error"}`},
+	)
+	ctx := context.Background()
+
+	withdraw := func(amount string) error {
+		_, err := client.CreateWithdrawalCrypto(ctx, coinbasepro.WithdrawalCrypto{Currency: "BTC", Amount: amount, CryptoAddress: address})
+		return err
+	}
+
+	// A rejected withdrawal does not count against the rolling limit.
+	if err := withdraw("1"); err == nil || errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+		t.Fatalf("expected the exchange to reject the withdrawal, got %v", err)
+	}
+
+	// The exchange may have sent a withdrawal which failed with a server error, so it counts.
+	if err := withdraw("1"); err == nil || errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+		t.Fatalf("expected the withdrawal to fail, got %v", err)
+	}
+	if err := withdraw("0.1"); !errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+		t.Errorf("expected the failed withdrawal to count against the rolling limit, got %v", err)
+	}
+}
+
+func TestWithdrawalGuardNetworks(t *testing.T) {
+	// The address is lower case so it compares equal whether or not it is normalized for ethereum.
+	const address = "0xdbf03b407c01e7cd3cbea99509d93f8dddc8c6fb"
+
+	guard, err := coinbasepro.NewWithdrawalGuard(
+		coinbasepro.WithAllowedAddresses(
+			coinbasepro.AllowedAddress{Currency: "USDC", Network: "ethereum", Address: address},
+			coinbasepro.AllowedAddress{Currency: "ETH", Address: address},
+		),
+		coinbasepro.WithWithdrawalAuditSink(coinbasepro.WithdrawalAuditFunc(func(coinbasepro.WithdrawalAttempt) {})),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client, _ := coinbasepro.NewFakeClient(t, coinbasepro.WithWithdrawalGuard(guard))
+
+	for _, tc := range []struct {
+		name              string
+		currency, network string
+		rejected          bool
+	}{
+		{"allowed network", "USDC", "ethereum", false},
+		{"default network of a token", "USDC", "", true},
+		{"other network", "USDC", "solana", true},
+		{"default network", "ETH", "", false},
+		{"named default network", "ETH", "ethereum", false},
+		{"other network than the default", "ETH", "base", true},
+	} {
+		_, err := client.CreateWithdrawalCrypto(context.Background(), coinbasepro.WithdrawalCrypto{
+			Currency:      tc.currency,
+			Network:       tc.network,
+			Amount:        "1",
+			CryptoAddress: address,
+		})
+		if tc.rejected != errors.Is(err, coinbasepro.ErrWithdrawalRejected) {
+			t.Errorf("%s: expected rejected %t, got %v", tc.name, tc.rejected, err)
+		}
+		if !tc.rejected && err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+		}
+	}
+}
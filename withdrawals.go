@@ -4,17 +4,47 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/url"
 )
 
 type WithdrawalCrypto struct {
 	Currency      string `json:"currency"`
 	Amount        string `json:"amount"`
 	CryptoAddress string `json:"crypto_address"`
+	// DestinationTag is the memo or tag of currencies like XRP and XLM, set NoDestinationTag to send without one.
+	DestinationTag   string `json:"destination_tag,omitempty"`
+	NoDestinationTag bool   `json:"no_destination_tag,omitempty"`
+	// Network defaults to the main network of the currency.
+	Network string `json:"network,omitempty"`
+	// AddNetworkFeeToTotal sends Amount and charges the fee on top of it, otherwise the fee is deducted from Amount.
+	AddNetworkFeeToTotal bool   `json:"add_network_fee_to_total,omitempty"`
+	TwoFactorCode        string `json:"two_factor_code,omitempty"`
+	// Nonce makes the withdrawal idempotent, a withdrawal with the nonce of an earlier one returns the earlier one.
+	Nonce int `json:"nonce,omitempty"`
+}
+
+// WithdrawalCryptoDetail is a sent crypto withdrawal, ID can be passed to GetTransfer and WaitForTransfer.
+type WithdrawalCryptoDetail struct {
+	ID             string `json:"id"`
+	Currency       string `json:"currency"`
+	Amount         string `json:"amount"`
+	CryptoAddress  string `json:"crypto_address"`
+	DestinationTag string `json:"destination_tag"`
+	Network        string `json:"network"`
+	Fee            string `json:"fee"`
+	Subtotal       string `json:"subtotal"`
+	PayoutAt       Time   `json:"payout_at,string"`
+}
+
+type WithdrawalFeeEstimate struct {
+	Fee              string `json:"fee"`
+	FeeBeforeSubsidy string `json:"fee_before_subsidy"`
 }
 
 type WithdrawalCoinbase struct {
-	Currency          string `json:"currency"`
-	Amount            string `json:"amount"`
+	Currency string `json:"currency"`
+	Amount   string `json:"amount"`
+	// CoinbaseAccountID can be determined by calling GetCoinbaseAccounts
 	CoinbaseAccountID string `json:"coinbase_account_id"`
 }
 
@@ -39,16 +69,44 @@ func (c *client) CreateWithdrawalPaymentMethod(ctx context.Context, newWithdrawa
 	return savedWithdrawal, err
 }
 
-func (c *client) CreateWithdrawalCrypto(ctx context.Context, newWithdrawalCrypto WithdrawalCrypto) (WithdrawalCrypto, error) {
-	var savedWithdrawal WithdrawalCrypto
-	url := fmt.Sprintf("/withdrawals/crypto")
-	_, err := c.Request(ctx, http.MethodPost, url, newWithdrawalCrypto, &savedWithdrawal)
+// CreateWithdrawalCrypto sends a withdrawal to a crypto address, it is checked by the withdrawal guard of the client
+// first when there is one.
+func (c *client) CreateWithdrawalCrypto(ctx context.Context, newWithdrawalCrypto WithdrawalCrypto) (WithdrawalCryptoDetail, error) {
+	if c.withdrawalGuard != nil {
+		return c.withdrawalGuard.withdraw(ctx, newWithdrawalCrypto, c.createWithdrawalCrypto)
+	}
+
+	savedWithdrawal, _, err := c.createWithdrawalCrypto(ctx, newWithdrawalCrypto)
 	return savedWithdrawal, err
 }
 
+func (c *client) createWithdrawalCrypto(ctx context.Context, newWithdrawalCrypto WithdrawalCrypto) (WithdrawalCryptoDetail, *http.Response, error) {
+	var savedWithdrawal WithdrawalCryptoDetail
+	url := fmt.Sprintf("/withdrawals/crypto")
+	res, err := c.Request(ctx, http.MethodPost, url, newWithdrawalCrypto, &savedWithdrawal)
+	return savedWithdrawal, res, err
+}
+
 func (c *client) CreateWithdrawalCoinbase(ctx context.Context, newWithdrawalCoinbase WithdrawalCoinbase) (WithdrawalCoinbase, error) {
 	var savedWithdrawal WithdrawalCoinbase
 	url := fmt.Sprintf("/withdrawals/coinbase-account")
 	_, err := c.Request(ctx, http.MethodPost, url, newWithdrawalCoinbase, &savedWithdrawal)
 	return savedWithdrawal, err
 }
+
+// GetWithdrawalFeeEstimate estimates the network fee of withdrawing currency to address, network can be empty for
+// the main network of the currency
+func (c *client) GetWithdrawalFeeEstimate(ctx context.Context, currency, address, network string) (WithdrawalFeeEstimate, error) {
+	var estimate WithdrawalFeeEstimate
+
+	query := url.Values{}
+	query.Set("currency", currency)
+	query.Set("crypto_address", address)
+	if network != "" {
+		query.Set("network", network)
+	}
+
+	requestURL := fmt.Sprintf("/withdrawals/fee-estimate?%s", query.Encode())
+	_, err := c.Request(ctx, http.MethodGet, requestURL, nil, &estimate)
+	return estimate, err
+}
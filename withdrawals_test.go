@@ -0,0 +1,91 @@
+package coinbasepro_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/moonr-app/go-coinbasepro"
+)
+
+func TestGetWithdrawalFeeEstimate(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	estimate, err := client.GetWithdrawalFeeEstimate(context.Background(), "BTC", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if estimate.Fee == "" {
+		t.Errorf("unexpected estimate %+v", estimate)
+	}
+}
+
+func TestCreateWithdrawalCrypto(t *testing.T) {
+	client, _ := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	withdrawal := coinbasepro.WithdrawalCrypto{
+		Currency:             "XRP",
+		Amount:               "10.00",
+		CryptoAddress:        "rLW9gnQo7BQhU6igk5keqYnH3TVrCxGRzm",
+		AddNetworkFeeToTotal: true,
+		Nonce:                42,
+	}
+	if _, err := client.CreateWithdrawalCrypto(ctx, withdrawal); err == nil {
+		t.Error("expected error for withdrawal without destination tag")
+	}
+
+	withdrawal.DestinationTag = "123456"
+	saved, err := client.CreateWithdrawalCrypto(ctx, withdrawal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.DestinationTag != withdrawal.DestinationTag || saved.Network == "" || saved.Subtotal != "10.00000000" {
+		t.Errorf("unexpected withdrawal %+v", saved)
+	}
+
+	repeated, err := client.CreateWithdrawalCrypto(ctx, withdrawal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repeated.ID != saved.ID {
+		t.Errorf("expected withdrawal %s for the same nonce, got %s", saved.ID, repeated.ID)
+	}
+}
+
+func TestCreateWithdrawalCryptoRequestBody(t *testing.T) {
+	client, server := coinbasepro.NewFakeClient(t)
+	ctx := context.Background()
+
+	saved, err := client.CreateWithdrawalCrypto(ctx, coinbasepro.WithdrawalCrypto{
+		Currency:      "BTC",
+		Amount:        "0.01",
+		CryptoAddress: "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ID == "" {
+		t.Errorf("unexpected withdrawal %+v", saved)
+	}
+
+	var body map[string]interface{}
+	for _, r := range server.Requests() {
+		if r.Method == http.MethodPost && r.Path == "/withdrawals/crypto" {
+			if err := json.Unmarshal([]byte(r.Body), &body); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	expected := map[string]interface{}{
+		"currency":       "BTC",
+		"amount":         "0.01",
+		"crypto_address": "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq",
+	}
+	if !reflect.DeepEqual(body, expected) {
+		t.Errorf("expected request body %v, got %v", expected, body)
+	}
+}